@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Настройки calctl, которые можно задать в файле конфигурации
+type config struct {
+	Server string
+	UserID int
+	Output string
+	CACert string
+	Cert   string
+	Key    string
+	// Арендатор и его токен (или токен администратора для команд tenant-*)
+	Tenant string
+	Token  string
+}
+
+func defaultConfig() config {
+	return config{
+		Server: "http://localhost:8080",
+		Output: outputTable,
+	}
+}
+
+// Путь к файлу конфигурации по умолчанию: $CALCTL_CONFIG или ~/.config/calctl/config
+func defaultConfigPath() string {
+	if path := os.Getenv("CALCTL_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "calctl", "config")
+}
+
+// Читает файл вида "key = value". Пустые строки и строки с # пропускаются.
+// Отсутствующий файл не считается ошибкой, если он не был указан явно
+func loadConfig(path string, required bool) (config, error) {
+	cfg := defaultConfig()
+
+	if path == "" {
+		return cfg, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return cfg, fmt.Errorf("%s:%d: expected key = value", path, line)
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch key {
+		case "server":
+			cfg.Server = value
+		case "user_id":
+			cfg.UserID, err = strconv.Atoi(value)
+			if err != nil {
+				return cfg, fmt.Errorf("%s:%d: invalid user_id: %w", path, line, err)
+			}
+		case "output":
+			cfg.Output = value
+		case "cacert":
+			cfg.CACert = value
+		case "cert":
+			cfg.Cert = value
+		case "key":
+			cfg.Key = value
+		case "tenant":
+			cfg.Tenant = value
+		case "token":
+			cfg.Token = value
+		default:
+			return cfg, fmt.Errorf("%s:%d: unknown key %q", path, line, key)
+		}
+	}
+
+	return cfg, scanner.Err()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected config
+		// Подстрока ожидаемой ошибки
+		err string
+	}{
+		{
+			name: "all keys",
+			content: "# calctl\n\n" +
+				"server = https://calendar.example.com\n" +
+				"user_id = 3\n" +
+				"output = \"json\"\n" +
+				"cacert = ca.pem\ncert = user.pem\nkey = user-key.pem\n" +
+				"tenant = team-a\ntoken = secret\n",
+			expected: config{Server: "https://calendar.example.com", UserID: 3, Output: outputJSON, CACert: "ca.pem",
+				Cert: "user.pem", Key: "user-key.pem", Tenant: "team-a", Token: "secret"},
+		},
+		{
+			name:     "defaults for missing keys",
+			content:  "user_id=7\n",
+			expected: config{Server: "http://localhost:8080", UserID: 7, Output: outputTable},
+		},
+		{name: "invalid user id", content: "user_id = three\n", err: "config:1: invalid user_id"},
+		{name: "unknown key", content: "\nport = 80\n", err: `config:2: unknown key "port"`},
+		{name: "line without value", content: "server\n", err: "config:1: expected key = value"},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, "config")
+		if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := loadConfig(path, true)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: loadConfig() error = %v, expected %q", test.name, err, test.err)
+			}
+			continue
+		}
+
+		if err != nil || cfg != test.expected {
+			t.Errorf("%s: loadConfig() = %+v, %v, expected %+v", test.name, cfg, err, test.expected)
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+
+	// Файл по умолчанию может отсутствовать, а явно указанный - нет
+	if cfg, err := loadConfig(path, false); err != nil || cfg != defaultConfig() {
+		t.Errorf("loadConfig(missing, false) = %+v, %v, expected defaults", cfg, err)
+	}
+	if _, err := loadConfig(path, true); err == nil {
+		t.Errorf("loadConfig(missing, true) succeeded, expected error")
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/pkg/client"
+)
+
+// Формат дат в аргументах calctl, как в параметрах HTTP API
+const dateLayout = "2006-01-02"
+
+const usage = `Использование: calctl <command> [flags]
+
+Команды:
+  create  -date 2019-09-09 -description "..." [-user 3] [event flags]
+  update  -id 1 -date 2019-09-09 -description "..." [-user 3] [event flags]
+  delete  -id 1
+  day     [-date 2019-09-09] [-tag work] [-category meetings]
+  week    [-date 2019-09-09] [-tag work] [-category meetings]
+  month   [-date 2019-09-09] [-tag work] [-category meetings] [-output grid]
+  availability [-date 2019-09-09] [-period day|week|month]
+  tenants        список арендаторов (нужен токен администратора)
+  tenant-create  -tenant team-a [-name "Team A"] [-max-events 500]
+  tenant-delete  -tenant team-a
+
+Флаги события (вместо -description можно указать -title):
+  -title -location -category -color #rrggbb -tags a,b
+  -idempotency-key  ключ для безопасного повтора create и update
+
+Общие флаги:
+  -config  файл конфигурации (по умолчанию $CALCTL_CONFIG или ~/.config/calctl/config)
+  -server  адрес сервера
+  -user    user_id по умолчанию
+  -output  формат вывода: table, json или grid (только для month)
+  -tenant  арендатор (заголовок X-Tenant-ID)
+  -token   токен арендатора или администратора
+`
+
+// Флаги, общие для всех команд. Заданные флаги перекрывают значения из файла конфигурации
+type commonFlags struct {
+	configPath string
+	server     string
+	userID     int
+	output     string
+	tenant     string
+	token      string
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+
+		// Для ошибок API выводим сообщение сервера из {"error": "..."}
+		apiErr := &client.APIError{}
+		if errors.As(err, &apiErr) {
+			fmt.Fprintf(os.Stderr, "calctl: %s (%s)\n", apiErr.Message, apiErr.Code)
+		} else {
+			fmt.Fprintf(os.Stderr, "calctl: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+
+	command, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	common := commonFlags{}
+	flags.StringVar(&common.configPath, "config", defaultConfigPath(), "файл конфигурации")
+	flags.StringVar(&common.server, "server", "", "адрес сервера")
+	flags.IntVar(&common.userID, "user", 0, "user_id")
+	flags.StringVar(&common.output, "output", "", "формат вывода: table, json или grid")
+	flags.StringVar(&common.tenant, "tenant", "", "арендатор")
+	flags.StringVar(&common.token, "token", "", "токен арендатора или администратора")
+
+	eventID := flags.Int("id", 0, "event_id")
+	dateArg := flags.String("date", "", "дата в формате 2006-01-02")
+	description := flags.String("description", "", "описание события")
+	title := flags.String("title", "", "название события")
+	location := flags.String("location", "", "место события")
+	category := flags.String("category", "", "категория события; для списков - фильтр по категории")
+	tags := flags.String("tags", "", "теги события через запятую")
+	color := flags.String("color", "", "цвет события в формате #rrggbb")
+	tag := flags.String("tag", "", "показать только события с тегом")
+	period := flags.String("period", client.PeriodDay, "период для availability: day, week или month")
+	idempotencyKey := flags.String("idempotency-key", "", "ключ идемпотентности для create и update")
+	tenantName := flags.String("name", "", "название арендатора для tenant-create")
+	maxEvents := flags.Int("max-events", 0, "ограничение на количество событий арендатора, 0 - без ограничения")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := resolveConfig(flags, common)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	date := time.Now().UTC()
+	if *dateArg != "" {
+		date, err = time.Parse(dateLayout, *dateArg)
+		if err != nil {
+			return fmt.Errorf("invalid -date: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if cfg.Tenant != "" {
+		ctx = client.WithTenant(ctx, cfg.Tenant)
+	}
+	if cfg.Token != "" {
+		ctx = client.WithToken(ctx, cfg.Token)
+	}
+	if *idempotencyKey != "" {
+		ctx = client.WithIdempotencyKey(ctx, *idempotencyKey)
+	}
+
+	input := client.EventInput{
+		ID:          *eventID,
+		UserID:      cfg.UserID,
+		Description: *description,
+		Title:       *title,
+		Location:    *location,
+		Category:    *category,
+		Color:       *color,
+	}
+	if *dateArg != "" {
+		input.Date = date
+	}
+	if *tags != "" {
+		input.Tags = strings.Split(*tags, ",")
+	}
+
+	filter := client.EventFilter{Tag: *tag, Category: *category}
+
+	if cfg.Output == outputGrid && command != "month" {
+		return fmt.Errorf("-output grid is only available for month")
+	}
+
+	var events []client.Event
+
+	switch command {
+	case "create":
+		event, err := c.CreateEvent(ctx, input)
+		if err != nil {
+			return err
+		}
+		return renderEvent(stdout, cfg.Output, event)
+	case "update":
+		event, err := c.UpdateEvent(ctx, input)
+		if err != nil {
+			return err
+		}
+		return renderEvent(stdout, cfg.Output, event)
+	case "delete":
+		event, err := c.DeleteEvent(ctx, *eventID)
+		if err != nil {
+			return err
+		}
+		return renderEvent(stdout, cfg.Output, event)
+	case "day":
+		events, err = c.GetEventsForDay(ctx, date, filter)
+	case "week":
+		events, err = c.GetEventsForWeek(ctx, date, filter)
+	case "month":
+		events, err = c.GetEventsForMonth(ctx, date, filter)
+	case "availability":
+		days, err := c.GetAvailability(ctx, cfg.UserID, date, *period)
+		if err != nil {
+			return err
+		}
+		return renderAvailability(stdout, cfg.Output, days)
+	case "tenants":
+		tenants, err := c.ListTenants(ctx)
+		if err != nil {
+			return err
+		}
+		return renderTenants(stdout, cfg.Output, tenants)
+	case "tenant-create":
+		tenant, err := c.CreateTenant(ctx, cfg.Tenant, *tenantName, *maxEvents)
+		if err != nil {
+			return err
+		}
+		return renderTenants(stdout, cfg.Output, []client.Tenant{tenant})
+	case "tenant-delete":
+		tenant, err := c.DeleteTenant(ctx, cfg.Tenant)
+		if err != nil {
+			return err
+		}
+		return renderTenants(stdout, cfg.Output, []client.Tenant{tenant})
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	return renderEvents(stdout, cfg.Output, events, date)
+}
+
+// Объединяет файл конфигурации с флагами командной строки
+func resolveConfig(flags *flag.FlagSet, common commonFlags) (config, error) {
+	explicitConfig := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			explicitConfig = true
+		}
+	})
+
+	cfg, err := loadConfig(common.configPath, explicitConfig)
+	if err != nil {
+		return cfg, err
+	}
+
+	if common.server != "" {
+		cfg.Server = common.server
+	}
+	if common.userID != 0 {
+		cfg.UserID = common.userID
+	}
+	if common.output != "" {
+		cfg.Output = common.output
+	}
+	if common.tenant != "" {
+		cfg.Tenant = common.tenant
+	}
+	if common.token != "" {
+		cfg.Token = common.token
+	}
+
+	switch cfg.Output {
+	case outputTable, outputJSON, outputGrid:
+	default:
+		return cfg, fmt.Errorf("unknown output format %q", cfg.Output)
+	}
+
+	return cfg, nil
+}
+
+// Создаёт клиента API. Для https можно указать CA сервера и клиентский сертификат (mTLS)
+func newClient(cfg config) (*client.Client, error) {
+	if cfg.CACert == "" && cfg.Cert == "" {
+		return client.NewClient(cfg.Server, nil), nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CACert != "" {
+		caPEM, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true},
+	}
+
+	return client.NewClient(cfg.Server, httpClient), nil
+}
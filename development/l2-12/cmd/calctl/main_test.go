@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+func TestResolveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("server = http://calendar:8080\nuser_id = 3\noutput = json\ntenant = team-a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected config
+		err      bool
+	}{
+		{
+			name:     "config file",
+			args:     []string{"-config", path},
+			expected: config{Server: "http://calendar:8080", UserID: 3, Output: outputJSON, Tenant: "team-a"},
+		},
+		{
+			name:     "flags override config",
+			args:     []string{"-config", path, "-server", "http://localhost:9090", "-user", "5", "-output", "grid", "-token", "secret"},
+			expected: config{Server: "http://localhost:9090", UserID: 5, Output: outputGrid, Tenant: "team-a", Token: "secret"},
+		},
+		{name: "unknown output", args: []string{"-config", path, "-output", "xml"}, err: true},
+		{name: "missing explicit config", args: []string{"-config", path + ".missing"}, err: true},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+
+		common := commonFlags{}
+		flags.StringVar(&common.configPath, "config", "", "")
+		flags.StringVar(&common.server, "server", "", "")
+		flags.IntVar(&common.userID, "user", 0, "")
+		flags.StringVar(&common.output, "output", "", "")
+		flags.StringVar(&common.tenant, "tenant", "", "")
+		flags.StringVar(&common.token, "token", "", "")
+
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatalf("%s: Parse() error = %v", test.name, err)
+		}
+
+		cfg, err := resolveConfig(flags, common)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: resolveConfig() = %+v, expected error", test.name, cfg)
+			}
+			continue
+		}
+
+		if err != nil || cfg != test.expected {
+			t.Errorf("%s: resolveConfig() = %+v, %v, expected %+v", test.name, cfg, err, test.expected)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	router := http.NewServeMux()
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarBuilder.NewApplication(context.Background())))
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Файл конфигурации по умолчанию не должен влиять на тест
+	t.Setenv("CALCTL_CONFIG", filepath.Join(t.TempDir(), "missing"))
+
+	tests := []struct {
+		name     string
+		args     []string
+		contains []string
+		err      string
+	}{
+		{
+			name:     "create",
+			args:     []string{"create", "-date", "2019-09-09", "-title", "Retro", "-tags", "team,sprint", "-user", "3"},
+			contains: []string{"ID  USER  DATE", "1   3     2019-09-09  Retro"},
+		},
+		{
+			name:     "month as json",
+			args:     []string{"month", "-date", "2019-09-01", "-output", "json"},
+			contains: []string{`"Title": "Retro"`, `"sprint"`},
+		},
+		{
+			name:     "month grid",
+			args:     []string{"month", "-date", "2019-09-01", "-output", "grid"},
+			contains: []string{"September 2019", "|  9*1  |", "1   3     2019-09-09  Retro"},
+		},
+		{name: "grid outside month", args: []string{"day", "-output", "grid"}, err: "only available for month"},
+		{name: "invalid date", args: []string{"day", "-date", "09.09.2019"}, err: "invalid -date"},
+		{name: "unknown command", args: []string{"year"}, err: `unknown command "year"`},
+		{name: "api error", args: []string{"delete", "-id", "42"}, err: "event 42"},
+	}
+
+	for _, test := range tests {
+		stdout := &bytes.Buffer{}
+
+		err := run(append(test.args, "-server", server.URL), stdout)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: run() error = %v, expected %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: run() error = %v", test.name, err)
+		}
+
+		for _, part := range test.contains {
+			if !strings.Contains(stdout.String(), part) {
+				t.Errorf("%s: output\n%s\ndoes not contain %q", test.name, stdout, part)
+			}
+		}
+	}
+}
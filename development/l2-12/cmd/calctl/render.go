@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/pkg/client"
+)
+
+// Форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputGrid  = "grid"
+)
+
+// Выводит одно событие
+func renderEvent(w io.Writer, output string, event client.Event) error {
+	if output == outputJSON {
+		return renderJSON(w, event)
+	}
+
+	return renderTable(w, []client.Event{event})
+}
+
+// Выводит список событий. Сетка месяца строится по месяцу даты date
+func renderEvents(w io.Writer, output string, events []client.Event, date time.Time) error {
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	switch output {
+	case outputJSON:
+		return renderJSON(w, events)
+	case outputGrid:
+		return renderMonthGrid(w, events, date)
+	default:
+		return renderTable(w, events)
+	}
+}
+
+// Выводит рабочее время по дням
+func renderAvailability(w io.Writer, output string, days []client.Availability) error {
+	if output == outputJSON {
+		return renderJSON(w, days)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "DATE\tHOURS\tEVENTS\tHOLIDAY")
+	for _, day := range days {
+		hours := "-"
+		if day.Working {
+			hours = day.Start + "-" + day.End
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", day.Date, hours, day.Events, day.Holiday)
+	}
+
+	return tw.Flush()
+}
+
+func renderTenants(w io.Writer, output string, tenants []client.Tenant) error {
+	if output == outputJSON {
+		return renderJSON(w, tenants)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tNAME\tMAX EVENTS\tCREATED\tTOKEN")
+	for _, tenant := range tenants {
+		maxEvents := "-"
+		if tenant.MaxEvents > 0 {
+			maxEvents = strconv.Itoa(tenant.MaxEvents)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", tenant.ID, tenant.Name, maxEvents, tenant.CreatedAt.Format(dateLayout), tenant.Token)
+	}
+
+	return tw.Flush()
+}
+
+func renderJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func renderTable(w io.Writer, events []client.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tUSER\tDATE\tTITLE\tCATEGORY\tTAGS\tDESCRIPTION")
+	for _, event := range events {
+		id, userID := strconv.Itoa(event.ID), strconv.Itoa(event.UserID)
+		// Праздники не принадлежат пользователю и не имеют ID
+		if event.ReadOnly {
+			id, userID = "-", "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, userID, event.Date.Format(dateLayout),
+			event.Title, event.Category, strings.Join(event.Tags, ","), event.Description)
+	}
+
+	return tw.Flush()
+}
+
+// Рисует ASCII сетку месяца, начиная неделю с понедельника.
+// Дни с событиями помечаются количеством событий, а сами события перечисляются под сеткой
+func renderMonthGrid(w io.Writer, events []client.Event, date time.Time) error {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	perDay := make(map[int]int)
+	for _, event := range events {
+		if event.Date.Year() == first.Year() && event.Date.Month() == first.Month() {
+			perDay[event.Date.Day()]++
+		}
+	}
+
+	// Номер дня, звёздочка и до трёх знаков количества событий
+	const cellWidth = 7
+	border := "+" + strings.Repeat(strings.Repeat("-", cellWidth)+"+", 7)
+
+	var b strings.Builder
+
+	title := first.Format("January 2006")
+	fmt.Fprintf(&b, "%*s\n", (len(border)+len(title))/2, title)
+	b.WriteString(border + "\n|")
+	for _, name := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		fmt.Fprintf(&b, " %-*s|", cellWidth-1, name)
+	}
+	b.WriteString("\n" + border + "\n")
+
+	// Смещение первого дня месяца от понедельника
+	offset := (int(first.Weekday()) + 6) % 7
+
+	for cell := 0; cell < offset+daysInMonth; cell += 7 {
+		b.WriteString("|")
+
+		for col := 0; col < 7; col++ {
+			day := cell + col - offset + 1
+
+			switch {
+			case day < 1 || day > daysInMonth:
+				b.WriteString(strings.Repeat(" ", cellWidth))
+			case perDay[day] > 0:
+				fmt.Fprintf(&b, "%3d*%-*s", day, cellWidth-4, eventCount(perDay[day]))
+			default:
+				fmt.Fprintf(&b, "%3d%*s", day, cellWidth-3, "")
+			}
+
+			b.WriteString("|")
+		}
+
+		b.WriteString("\n" + border + "\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	return renderTable(w, events)
+}
+
+// Количество событий в ячейке сетки. Большие значения сокращаются, чтобы не сдвигать границы ячеек
+func eventCount(n int) string {
+	if n > 99 {
+		return "99+"
+	}
+
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/pkg/client"
+)
+
+func TestRenderEvents(t *testing.T) {
+	date := time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC)
+
+	events := []client.Event{
+		{ID: 2, UserID: 3, Date: date.AddDate(0, 0, 1), Title: "Retro", Category: "work", Tags: []string{"team", "sprint"}},
+		{ID: 1, UserID: 3, Date: date, Description: "standup"},
+		{Date: date, Title: "Holiday", ReadOnly: true},
+	}
+
+	// Таблица упорядочена по дате и ID, у праздников нет ID и пользователя
+	expected := "ID  USER  DATE        TITLE    CATEGORY  TAGS         DESCRIPTION\n" +
+		"-   -     2019-09-09  Holiday                         \n" +
+		"1   3     2019-09-09                                  standup\n" +
+		"2   3     2019-09-10  Retro    work      team,sprint  \n"
+
+	table := &bytes.Buffer{}
+	if err := renderEvents(table, outputTable, append([]client.Event(nil), events...), date); err != nil {
+		t.Fatalf("renderEvents(table) error = %v", err)
+	}
+	if table.String() != expected {
+		t.Errorf("renderEvents(table) =\n%s\nexpected\n%s", table, expected)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := renderEvents(buf, outputJSON, append([]client.Event(nil), events...), date); err != nil {
+		t.Fatalf("renderEvents(json) error = %v", err)
+	}
+
+	decoded := []client.Event{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("renderEvents(json) = %s, not JSON: %v", buf, err)
+	}
+	if len(decoded) != 3 || decoded[0].Title != "Holiday" || decoded[2].ID != 2 {
+		t.Errorf("renderEvents(json) = %+v, expected events sorted by date and ID", decoded)
+	}
+}
+
+func TestRenderMonthGrid(t *testing.T) {
+	date := time.Date(2019, 9, 15, 0, 0, 0, 0, time.UTC)
+
+	// Каждая строка сетки одной ширины, независимо от количества событий в дне
+	tests := []struct {
+		name   string
+		counts map[int]int
+		// Подстроки ожидаемых ячеек
+		cells []string
+	}{
+		{name: "empty month", cells: []string{"|  1    |", "| 30    |"}},
+		{name: "single digit", counts: map[int]int{9: 3}, cells: []string{"|  9*3  |"}},
+		{name: "two digits", counts: map[int]int{10: 42}, cells: []string{"| 10*42 |"}},
+		{name: "hundred events", counts: map[int]int{2: 100, 30: 150}, cells: []string{"|  2*99+|", "| 30*99+|"}},
+	}
+
+	for _, test := range tests {
+		events := []client.Event{}
+		for day, n := range test.counts {
+			for i := 0; i < n; i++ {
+				events = append(events, client.Event{ID: len(events) + 1, UserID: 1, Date: time.Date(2019, 9, day, 0, 0, 0, 0, time.UTC)})
+			}
+		}
+		// События других месяцев в сетке не считаются
+		events = append(events, client.Event{ID: len(events) + 1, UserID: 1, Date: time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)})
+
+		buf := &bytes.Buffer{}
+		if err := renderMonthGrid(buf, events, date); err != nil {
+			t.Fatalf("%s: renderMonthGrid() error = %v", test.name, err)
+		}
+
+		grid, _, _ := strings.Cut(buf.String(), "\n\n")
+		lines := strings.Split(grid, "\n")
+
+		if strings.TrimSpace(lines[0]) != "September 2019" {
+			t.Errorf("%s: title = %q, expected September 2019", test.name, lines[0])
+		}
+		// Сентябрь 2019 начинается в воскресенье и занимает шесть недель
+		if rows := (len(lines) - 3) / 2; rows != 6 {
+			t.Errorf("%s: grid has %d weeks, expected 6", test.name, rows)
+		}
+		if !strings.HasPrefix(lines[4], "|"+strings.Repeat("       |", 6)+"  1    |") {
+			t.Errorf("%s: first week = %q, expected 1 on Sunday", test.name, lines[4])
+		}
+
+		for i, line := range lines[1:] {
+			if len(line) != len(lines[1]) {
+				t.Errorf("%s: line %d %q has width %d, expected %d", test.name, i+1, line, len(line), len(lines[1]))
+			}
+		}
+
+		for _, cell := range test.cells {
+			if !strings.Contains(grid, cell) {
+				t.Errorf("%s: grid does not contain %q:\n%s", test.name, cell, grid)
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/pkg/client"
+)
+
+// Операции одной итерации нагрузки в порядке выполнения
+var loadOperations = []string{"create", "day", "week", "month", "update", "delete"}
+
+// Параметры генератора нагрузки
+type loadOptions struct {
+	concurrency int
+	duration    time.Duration
+	// Ограничение на количество итераций всех воркеров, 0 - до окончания duration
+	iterations int64
+	userID     int
+}
+
+// Задержки и ошибки по операциям
+type loadStats struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newLoadStats() *loadStats {
+	return &loadStats{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+func (s *loadStats) record(operation string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies[operation] = append(s.latencies[operation], latency)
+	if err != nil {
+		s.errors[operation]++
+	}
+}
+
+func runLoadTest(args []string) error {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	target := flags.String("target", "http://localhost:8080", "адрес работающего сервера")
+	tenant := flags.String("tenant", "", "арендатор (заголовок X-Tenant-ID)")
+	token := flags.String("token", "", "токен арендатора")
+	opts := loadOptions{}
+	flags.IntVar(&opts.concurrency, "concurrency", 8, "количество параллельных воркеров")
+	flags.DurationVar(&opts.duration, "duration", 10*time.Second, "длительность нагрузки")
+	flags.Int64Var(&opts.iterations, "iterations", 0, "сколько итераций выполнить (0 - до окончания -duration)")
+	flags.IntVar(&opts.userID, "user", 1, "user_id создаваемых событий")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.concurrency <= 0 || opts.duration <= 0 || opts.iterations < 0 {
+		return fmt.Errorf("-concurrency and -duration must be positive, -iterations must not be negative")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.concurrency
+	c := client.NewClient(*target, &http.Client{Transport: transport, Timeout: 30 * time.Second})
+
+	ctx := context.Background()
+	if *tenant != "" {
+		ctx = client.WithTenant(ctx, *tenant)
+	}
+	if *token != "" {
+		ctx = client.WithToken(ctx, *token)
+	}
+
+	fmt.Fprintf(os.Stderr, "load test of %s: %d workers for %s\n", *target, opts.concurrency, opts.duration)
+
+	stats, elapsed := generateLoad(ctx, c, opts)
+
+	return stats.report(os.Stdout, elapsed)
+}
+
+// Запускает воркеров, каждый из которых в цикле создаёт событие, читает списки, изменяет и удаляет его
+func generateLoad(ctx context.Context, c *client.Client, opts loadOptions) (*loadStats, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	stats := newLoadStats()
+	started := time.Now()
+	iterations := atomic.Int64{}
+
+	wg := sync.WaitGroup{}
+
+	for worker := 0; worker < opts.concurrency; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := 0; ctx.Err() == nil; i++ {
+				if opts.iterations > 0 && iterations.Add(1) > opts.iterations {
+					return
+				}
+
+				date := time.Date(2019, 9, 1+(worker+i)%28, 0, 0, 0, 0, time.UTC)
+				loadIteration(ctx, c, stats, opts.userID, date)
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+
+	return stats, time.Since(started)
+}
+
+func loadIteration(ctx context.Context, c *client.Client, stats *loadStats, userID int, date time.Time) {
+	measure := func(operation string, call func() error) error {
+		started := time.Now()
+		err := call()
+		// Запросы, прерванные окончанием нагрузки, не учитываются
+		if ctx.Err() == nil {
+			stats.record(operation, time.Since(started), err)
+		}
+		return err
+	}
+
+	event := client.Event{}
+	err := measure("create", func() (err error) {
+		event, err = c.CreateEvent(ctx, client.EventInput{UserID: userID, Date: date, Title: "load test"})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	_ = measure("day", func() error {
+		_, err := c.GetEventsForDay(ctx, date, client.EventFilter{})
+		return err
+	})
+	_ = measure("week", func() error {
+		_, err := c.GetEventsForWeek(ctx, date, client.EventFilter{})
+		return err
+	})
+	_ = measure("month", func() error {
+		_, err := c.GetEventsForMonth(ctx, date, client.EventFilter{})
+		return err
+	})
+	_ = measure("update", func() error {
+		_, err := c.UpdateEvent(ctx, client.EventInput{ID: event.ID, UserID: userID, Date: date, Title: "load test", Description: "updated"})
+		return err
+	})
+	_ = measure("delete", func() error {
+		_, err := c.DeleteEvent(ctx, event.ID)
+		return err
+	})
+}
+
+// Выводит по каждой операции количество запросов, ошибки, пропускную способность и перцентили задержки
+func (s *loadStats) report(w io.Writer, elapsed time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tERRORS\tRPS\tP50\tP90\tP99\tMAX\t")
+
+	all := make([]time.Duration, 0)
+	failed := 0
+
+	for _, operation := range loadOperations {
+		latencies := s.latencies[operation]
+		all = append(all, latencies...)
+		failed += s.errors[operation]
+
+		writeLoadRow(tw, operation, latencies, s.errors[operation], elapsed)
+	}
+
+	writeLoadRow(tw, "total", all, failed, elapsed)
+
+	return tw.Flush()
+}
+
+func writeLoadRow(w io.Writer, operation string, latencies []time.Duration, failed int, elapsed time.Duration) {
+	latencies = slices.Clone(latencies)
+	slices.Sort(latencies)
+
+	rps := 0.0
+	if elapsed > 0 {
+		rps = float64(len(latencies)) / elapsed.Seconds()
+	}
+
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", operation, len(latencies), failed, rps,
+		percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), percentile(latencies, 100))
+}
+
+// Перцентиль p (0-100] отсортированных задержек методом ближайшего ранга
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+
+	return sorted[rank].Round(time.Microsecond)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/pkg/client"
+)
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	for p, expected := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(latencies, p); got != expected {
+			t.Errorf("percentile(%v) = %s, expected %s", p, got, expected)
+		}
+	}
+
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile() of no latencies = %s, expected 0", got)
+	}
+	if got := percentile([]time.Duration{time.Second}, 1); got != time.Second {
+		t.Errorf("percentile() of single latency = %s, expected 1s", got)
+	}
+}
+
+func TestGenerateLoad(t *testing.T) {
+	router := http.NewServeMux()
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarBuilder.NewApplication(context.Background())))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := client.NewClient(server.URL, server.Client())
+
+	stats, elapsed := generateLoad(context.Background(), c, loadOptions{concurrency: 4, duration: time.Minute, iterations: 20, userID: 1})
+
+	for _, operation := range loadOperations {
+		if len(stats.latencies[operation]) != 20 || stats.errors[operation] != 0 {
+			t.Errorf("%s: %d requests and %d errors, expected 20 requests without errors", operation, len(stats.latencies[operation]), stats.errors[operation])
+		}
+	}
+
+	out := bytes.Buffer{}
+	if err := stats.report(&out, elapsed); err != nil {
+		t.Fatalf("report() error = %v", err)
+	}
+
+	for _, column := range []string{"OPERATION", "P99", "create", "total"} {
+		if !strings.Contains(out.String(), column) {
+			t.Errorf("report() = %s, expected %q", out.String(), column)
+		}
+	}
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/adapters"
 	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
 	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
 )
@@ -42,6 +50,21 @@ type HttpServer struct {
 
 type Application struct {
 	httpServer *http.Server
+	// Сколько ждать после снятия готовности, прежде чем останавливать сервер
+	drainTimeout time.Duration
+	// Файл с событиями, которые загружаются в хранилище перед запуском
+	importPath string
+	// Если задан, сервер работает по TLS (и HTTP/2)
+	tlsConfig *tls.Config
+	// Адрес TCP транспорта JSON-RPC; пустой адрес отключает его
+	rpcAddr string
+	// Начало недели и рабочее время по умолчанию
+	calendarConfig calendarBuilder.Config
+	// Файлы производственного календаря
+	holidayPaths []string
+	// Каталог журнала и снимков событий; пустой каталог - события только в памяти
+	dataDir    string
+	walOptions adapters.WalOptions
 }
 
 func (a *Application) Run(addr string, debug bool) error {
@@ -49,7 +72,30 @@ func (a *Application) Run(addr string, debug bool) error {
 
 	ctx := context.Background()
 
-	calendarApp := calendarBuilder.NewApplication(ctx)
+	if a.dataDir != "" {
+		eventRepository, err := openEventLog(a.dataDir, a.walOptions)
+		if err != nil {
+			return err
+		}
+		defer closeEventLog(eventRepository)
+
+		a.calendarConfig.EventRepository = eventRepository
+		a.calendarConfig.AttachmentRepository = eventRepository.Attachments()
+		a.calendarConfig.TenantRepository = eventRepository.Tenants()
+	}
+
+	calendarApp := calendarBuilder.NewApplicationWithConfig(ctx, a.calendarConfig)
+
+	if err := importHolidays(ctx, calendarApp, a.holidayPaths); err != nil {
+		return err
+	}
+
+	if a.importPath != "" {
+		if err := importFile(ctx, calendarApp, a.importPath, ""); err != nil {
+			return err
+		}
+	}
+
 	calendarHttpHandler := calendarPorts.NewHttpCalendarHandler(calendarApp)
 	calendarPorts.CustomRegisterHandlers(router, calendarHttpHandler)
 
@@ -59,35 +105,247 @@ func (a *Application) Run(addr string, debug bool) error {
 		ReadTimeout:    180 * time.Second,
 		WriteTimeout:   180 * time.Second,
 		MaxHeaderBytes: 1 << 20,
+		TLSConfig:      a.tlsConfig,
 	}
 
+	// Подписываемся на сигналы до запуска серверов, чтобы сигнал во время запуска тоже выводил сервер из балансировки
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
 	log.Println("Server is running...")
 
+	// Ошибка запуска сервера передаётся в Run, а не роняет процесс. Ошибки HTTP и JSON-RPC
+	// отправляются без блокировки, даже если Run уже вернулся по первой из них
+	errCh := make(chan error, 2)
+
 	go func() {
-		if err := a.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			panic(err)
+		var err error
+		if a.tlsConfig != nil {
+			// Сертификаты уже загружены в TLSConfig
+			err = a.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = a.httpServer.ListenAndServe()
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
 		}
 	}()
 
-	doneCh := make(chan struct{})
+	// JSON-RPC поверх TCP использует те же сценарии, что и HTTP
+	rpcCtx, stopRPC := context.WithCancel(ctx)
+	defer stopRPC()
+
+	if a.rpcAddr != "" {
+		listener, err := listenRPC(a.rpcAddr, a.tlsConfig)
+		if err != nil {
+			// HTTP сервер уже запущен и без остановки продолжил бы принимать запросы
+			return errors.Join(err, a.shutdownHTTP())
+		}
+
+		log.Printf("JSON-RPC is listening on %s\n", listener.Addr())
+
+		go func() {
+			if err := calendarPorts.NewJsonRpcCalendarHandler(calendarApp).ServeTCP(rpcCtx, listener); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		stopRPC()
+		return errors.Join(err, a.shutdownHTTP())
+	case sig := <-quit:
+		log.Printf("Received %s, draining server...\n", sig)
+	}
+
+	// Снимаем готовность и даём балансировщику время перестать слать запросы.
+	// Повторный сигнал останавливает сервер, не дожидаясь конца вывода из балансировки
+	calendarHttpHandler.SetReady(false)
+
+	drain := time.NewTimer(a.drainTimeout)
+	defer drain.Stop()
+
+	select {
+	case <-drain.C:
+	case sig := <-quit:
+		log.Printf("Received %s, skipping drain\n", sig)
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
 	log.Println("Shutting down server...")
 
-	close(doneCh)
+	stopRPC()
 
+	return a.shutdownHTTP()
+}
+
+// Останавливает HTTP сервер, давая текущим запросам до 5 секунд на завершение
+func (a *Application) shutdownHTTP() error {
 	ctx, shutdown := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdown()
 
 	return a.httpServer.Shutdown(ctx)
 }
 
+// Открывает журнал событий в каталоге dataDir и восстанавливает из него кэш
+func openEventLog(dataDir string, options adapters.WalOptions) (*adapters.WalEventRepository, error) {
+	eventRepository, err := adapters.OpenWalEventRepository(adapters.NewCacheEventRepository(calendarBuilder.EventCacheSize), dataDir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	recovery := eventRepository.Recovery()
+	log.Printf("Events recovered from %s: snapshot at %d, %d log records replayed, %d bytes of damaged tail dropped\n",
+		dataDir, recovery.SnapshotSeq, recovery.Replayed, recovery.TruncatedBytes)
+
+	return eventRepository, nil
+}
+
+// Закрывает журнал событий: делает снимок и сбрасывает данные на диск
+func closeEventLog(eventRepository *adapters.WalEventRepository) {
+	if err := eventRepository.Close(); err != nil {
+		log.Printf("Failed to close event log: %v\n", err)
+	}
+}
+
+// Открывает TCP порт JSON-RPC. С TLS конфигурацией сервера соединения шифруются и при -client-ca
+// требуют клиентский сертификат, как и HTTP
+func listenRPC(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		return listener, nil
+	}
+
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
 func main() {
-	app := &Application{}
-	err := app.Run(":8080", false)
+	// Без подкоманды запускаем HTTP сервер
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "export":
+		err = runExport(args)
+	case "import":
+		err = runImport(args)
+	case "gen-certs":
+		err = runGenCerts(args)
+	case "loadtest":
+		err = runLoadTest(args)
+	default:
+		err = fmt.Errorf("unknown command %q, expected serve, export, import, gen-certs or loadtest", command)
+	}
+
 	if err != nil {
-		panic(err)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+}
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "адрес HTTP сервера")
+	drain := flags.Duration("drain", 5*time.Second, "время на вывод из балансировки перед остановкой")
+	importPath := flags.String("import", "", "файл CSV или NDJSON с событиями для загрузки при старте")
+	rpcAddr := flags.String("rpc-addr", "", "адрес TCP транспорта JSON-RPC 2.0 (например, :8081)")
+	tlsOpts := tlsOptions{}
+	flags.StringVar(&tlsOpts.certFile, "tls-cert", "", "PEM файл сертификата сервера")
+	flags.StringVar(&tlsOpts.keyFile, "tls-key", "", "PEM файл ключа сервера")
+	flags.BoolVar(&tlsOpts.selfSigned, "tls-self-signed", false, "сгенерировать самоподписанный сертификат для разработки")
+	flags.StringVar(&tlsOpts.clientCAFile, "client-ca", "", "PEM файл CA для проверки клиентских сертификатов (включает mTLS)")
+	scheduleOpts := scheduleOptions{}
+	flags.StringVar(&scheduleOpts.weekStart, "week-start", "monday", "день начала недели")
+	flags.StringVar(&scheduleOpts.workingHours, "working-hours", "09:00-18:00", "рабочее время по умолчанию")
+	flags.StringVar(&scheduleOpts.workingDays, "working-days", "mon,tue,wed,thu,fri", "рабочие дни недели по умолчанию")
+	flags.StringVar(&scheduleOpts.holidays, "holidays", "", "файлы производственного календаря (.xml или .csv) через запятую")
+	attachmentsDir := flags.String("attachments-dir", "", "каталог для файлов вложений (по умолчанию attachments в -data-dir, без него - во временном каталоге)")
+	attachmentMaxSize := flags.Int64("attachment-max-size", calendarBuilder.DefaultConfig().AttachmentLimits.MaxSize, "максимальный размер вложения в байтах")
+	adminToken := flags.String("admin-token", "", "токен администратора для /admin/* (по умолчанию $CALENDAR_ADMIN_TOKEN)")
+	requireTenantToken := flags.Bool("require-tenant-token", false, "определять арендатора только по токену, а не по заголовку X-Tenant-ID")
+	defaultTenantMaxEvents := flags.Int("default-tenant-max-events", 0, "ограничение на количество событий арендатора по умолчанию, 0 - без ограничения")
+	dataDir := flags.String("data-dir", "", "каталог журнала и снимков событий (по умолчанию события только в памяти)")
+	walSync := flags.String("wal-sync", string(adapters.DefaultWalOptions().Sync), "когда сбрасывать журнал на диск: always, interval или never")
+	walSyncInterval := flags.Duration("wal-sync-interval", adapters.DefaultWalOptions().SyncInterval, "период fsync журнала для -wal-sync=interval")
+	snapshotInterval := flags.Duration("snapshot-interval", adapters.DefaultWalOptions().SnapshotInterval, "период снимков с очисткой журнала, 0 - только при остановке")
+	idempotencyTTL := flags.Duration("idempotency-ttl", calendarBuilder.DefaultConfig().IdempotencyTTL, "сколько хранить ответ на запрос с заголовком Idempotency-Key")
+	if err := flags.Parse(args); err != nil {
+		return err
 	}
+
+	calendarConfig, err := scheduleOpts.config()
+	if err != nil {
+		return err
+	}
+	if *attachmentMaxSize <= 0 {
+		return fmt.Errorf("-attachment-max-size must be positive")
+	}
+	if *defaultTenantMaxEvents < 0 {
+		return fmt.Errorf("-default-tenant-max-events must not be negative")
+	}
+	if *idempotencyTTL <= 0 {
+		return fmt.Errorf("-idempotency-ttl must be positive")
+	}
+	walSyncPolicy, err := adapters.ParseWalSyncPolicy(*walSync)
+	if err != nil {
+		return err
+	}
+	if *walSyncInterval <= 0 || *snapshotInterval < 0 {
+		return fmt.Errorf("-wal-sync-interval must be positive and -snapshot-interval must not be negative")
+	}
+	// Вложения хранятся рядом с событиями, а не во временном каталоге, который ОС может очистить
+	switch {
+	case *attachmentsDir != "":
+		calendarConfig.AttachmentsDir = *attachmentsDir
+	case *dataDir != "":
+		calendarConfig.AttachmentsDir = filepath.Join(*dataDir, "attachments")
+	}
+	calendarConfig.AttachmentLimits.MaxSize = *attachmentMaxSize
+	calendarConfig.IdempotencyTTL = *idempotencyTTL
+	// Токен из переменной окружения не попадает в список процессов
+	calendarConfig.AdminToken = *adminToken
+	if calendarConfig.AdminToken == "" {
+		calendarConfig.AdminToken = os.Getenv("CALENDAR_ADMIN_TOKEN")
+	}
+	calendarConfig.RequireTenantToken = *requireTenantToken
+	calendarConfig.DefaultTenantMaxEvents = *defaultTenantMaxEvents
+
+	app := &Application{
+		drainTimeout:   *drain,
+		importPath:     *importPath,
+		rpcAddr:        *rpcAddr,
+		calendarConfig: calendarConfig,
+		holidayPaths:   scheduleOpts.holidayPaths(),
+		dataDir:        *dataDir,
+		walOptions: adapters.WalOptions{
+			Sync:             walSyncPolicy,
+			SyncInterval:     *walSyncInterval,
+			SnapshotInterval: *snapshotInterval,
+		},
+	}
+
+	if tlsOpts.enabled() || tlsOpts.clientCAFile != "" {
+		tlsConfig, err := buildTLSConfig(tlsOpts)
+		if err != nil {
+			return err
+		}
+		app.tlsConfig = tlsConfig
+	}
+
+	return app.Run(*addr, false)
 }
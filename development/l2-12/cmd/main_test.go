@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+)
+
+func TestRunStopsHTTPWhenRPCListenFails(t *testing.T) {
+	// Порт JSON-RPC занят, поэтому запуск завершается ошибкой
+	rpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcListener.Close()
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpAddr := httpListener.Addr().String()
+	httpListener.Close()
+
+	app := &Application{rpcAddr: rpcListener.Addr().String(), calendarConfig: calendarBuilder.DefaultConfig()}
+
+	if err := app.Run(httpAddr, false); err == nil {
+		t.Fatalf("Run() with busy RPC port succeeded, expected error")
+	}
+
+	// Запущенный до ошибки HTTP сервер остановлен и не принимает соединения
+	time.Sleep(100 * time.Millisecond)
+
+	if conn, err := net.Dial("tcp", httpAddr); err == nil {
+		conn.Close()
+		t.Errorf("HTTP server is still listening on %s after Run() returned", httpAddr)
+	}
+}
+
+func TestRunDrainsOnSIGTERM(t *testing.T) {
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpAddr := httpListener.Addr().String()
+	httpListener.Close()
+
+	// Если сигнал придёт раньше подписки Run, он не завершит процесс теста
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	app := &Application{drainTimeout: 2 * time.Second, calendarConfig: calendarBuilder.DefaultConfig()}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run(httpAddr, false)
+	}()
+
+	// Ждёт, пока /readyz не ответит status, или возвращает false через 5 секунд
+	waitReadyz := func(status int) bool {
+		deadline := time.Now().Add(5 * time.Second)
+
+		for time.Now().Before(deadline) {
+			if resp, err := http.Get("http://" + httpAddr + "/readyz"); err == nil {
+				resp.Body.Close()
+				if resp.StatusCode == status {
+					return true
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		return false
+	}
+
+	if !waitReadyz(http.StatusOK) {
+		t.Fatalf("server on %s did not become ready", httpAddr)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// Готовность снимается сразу, а сервер ещё отвечает, пока балансировщик выводит его из работы
+	if !waitReadyz(http.StatusServiceUnavailable) {
+		t.Errorf("readyz did not report not ready after SIGTERM")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Run() did not return after drain")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	calendarAdapters "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/adapters"
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+// Настройки недели, рабочего времени и праздников из флагов serve
+type scheduleOptions struct {
+	weekStart    string
+	workingHours string
+	workingDays  string
+	holidays     string
+}
+
+func (o scheduleOptions) config() (calendarBuilder.Config, error) {
+	config := calendarBuilder.DefaultConfig()
+
+	weekStart, err := calendarPorts.ParseWeekday(o.weekStart)
+	if err != nil {
+		return config, fmt.Errorf("-week-start: %w", err)
+	}
+	config.WeekStart = weekStart
+
+	weekdays, err := calendarPorts.ParseWeekdays(splitList(o.workingDays))
+	if err != nil {
+		return config, fmt.Errorf("-working-days: %w", err)
+	}
+
+	config.WorkingHours, err = calendarPorts.ParseWorkingHours(o.workingHours, weekdays)
+	if err != nil {
+		return config, fmt.Errorf("-working-hours: %w", err)
+	}
+
+	return config, nil
+}
+
+func (o scheduleOptions) holidayPaths() []string {
+	return splitList(o.holidays)
+}
+
+// Загружает производственные календари. Более поздние файлы перекрывают те же даты из ранних
+func importHolidays(ctx context.Context, app *calendarBuilder.Application, paths []string) error {
+	for _, path := range paths {
+		holidays, err := calendarAdapters.LoadHolidayFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := app.ImportHolidays.Execute(ctx, holidays); err != nil {
+			return err
+		}
+
+		log.Printf("Imported %d calendar days from %s\n", len(holidays), path)
+	}
+
+	return nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
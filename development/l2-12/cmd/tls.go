@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+// Срок действия сертификатов, которые генерируются для разработки
+const devCertificateTTL = 365 * 24 * time.Hour
+
+// Хосты, на которые выписывается сертификат сервера для разработки
+var devCertificateHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+type tlsOptions struct {
+	certFile     string
+	keyFile      string
+	selfSigned   bool
+	clientCAFile string
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.certFile != "" || o.keyFile != "" || o.selfSigned
+}
+
+// Собирает конфигурацию TLS сервера. HTTP/2 включается через ALPN
+func buildTLSConfig(opts tlsOptions) (*tls.Config, error) {
+	var (
+		cert tls.Certificate
+		err  error
+	)
+
+	switch {
+	case opts.certFile != "" || opts.keyFile != "":
+		if opts.certFile == "" || opts.keyFile == "" {
+			return nil, errors.New("both -tls-cert and -tls-key are required")
+		}
+		cert, err = tls.LoadX509KeyPair(opts.certFile, opts.keyFile)
+	case opts.selfSigned:
+		cert, err = selfSignedCertificate(devCertificateHosts)
+		if err == nil {
+			log.Println("Using self-signed certificate for", strings.Join(devCertificateHosts, ", "))
+		}
+	default:
+		return nil, errors.New("TLS requires -tls-cert/-tls-key or -tls-self-signed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	// Взаимный TLS: клиент обязан предъявить сертификат, подписанный указанным CA
+	if opts.clientCAFile != "" {
+		caPEM, err := os.ReadFile(opts.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.clientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// Генерирует самоподписанный сертификат сервера в памяти
+func selfSignedCertificate(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template, err := certificateTemplate("calendar dev server")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	addHosts(template, hosts)
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// Центр сертификации для локальной проверки взаимного TLS
+type devCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newDevCA() (*devCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := certificateTemplate("calendar dev CA")
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &devCA{cert: cert, key: key}, nil
+}
+
+// Выписывает сертификат сервера для hosts
+func (ca *devCA) issueServer(hosts []string) (tls.Certificate, error) {
+	template, err := certificateTemplate("calendar server")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	addHosts(template, hosts)
+
+	return ca.issue(template)
+}
+
+// Выписывает клиентский сертификат, сопоставленный пользователю userID
+func (ca *devCA) issueClient(userID int) (tls.Certificate, error) {
+	template, err := certificateTemplate(calendarPorts.ClientCertCommonName(userID))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return ca.issue(template)
+}
+
+func (ca *devCA) issue(template *x509.Certificate) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"wbtech-l2 calendar"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(devCertificateTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, nil
+}
+
+func addHosts(template *x509.Certificate, hosts []string) {
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+}
+
+// Генерирует CA, сертификат сервера и клиентские сертификаты для локальной проверки mTLS
+func runGenCerts(args []string) error {
+	flags := flag.NewFlagSet("gen-certs", flag.ContinueOnError)
+	dir := flags.String("dir", "certs", "каталог для сертификатов")
+	users := flags.String("users", "1", "ID пользователей через запятую, для которых выписываются клиентские сертификаты")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		return err
+	}
+
+	ca, err := newDevCA()
+	if err != nil {
+		return err
+	}
+	if err := writePEM(filepath.Join(*dir, "ca.pem"), "CERTIFICATE", ca.cert.Raw); err != nil {
+		return err
+	}
+
+	server, err := ca.issueServer(devCertificateHosts)
+	if err != nil {
+		return err
+	}
+	if err := writeKeyPair(*dir, "server", server); err != nil {
+		return err
+	}
+
+	for _, field := range strings.Split(*users, ",") {
+		userID, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || userID <= 0 {
+			return fmt.Errorf("invalid user id %q", field)
+		}
+
+		client, err := ca.issueClient(userID)
+		if err != nil {
+			return err
+		}
+		if err := writeKeyPair(*dir, calendarPorts.ClientCertCommonName(userID), client); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Certificates written to %s\n", *dir)
+
+	return nil
+}
+
+func writeKeyPair(dir, name string, cert tls.Certificate) error {
+	if err := writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", cert.Certificate[0]); err != nil {
+		return err
+	}
+
+	key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		return err
+	}
+
+	return writePEM(filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", key)
+}
+
+func writePEM(path, blockType string, der []byte) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+}
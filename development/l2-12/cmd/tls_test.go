@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+func TestBuildTLSConfigSelfSigned(t *testing.T) {
+	config, err := buildTLSConfig(tlsOptions{selfSigned: true})
+	if err != nil {
+		t.Fatalf("buildTLSConfig() error = %v", err)
+	}
+
+	if len(config.Certificates) != 1 {
+		t.Fatalf("buildTLSConfig() certificates = %d, expected 1", len(config.Certificates))
+	}
+	if config.NextProtos[0] != "h2" {
+		t.Errorf("buildTLSConfig() NextProtos = %v, expected h2 first", config.NextProtos)
+	}
+	if config.ClientAuth != tls.NoClientCert {
+		t.Errorf("buildTLSConfig() ClientAuth = %v, expected no client certificates", config.ClientAuth)
+	}
+
+	if _, err := buildTLSConfig(tlsOptions{certFile: "server.pem"}); err == nil {
+		t.Errorf("buildTLSConfig() without key file succeeded, expected error")
+	}
+}
+
+func TestMutualTLSMapsCertificateToUser(t *testing.T) {
+	ca, err := newDevCA()
+	if err != nil {
+		t.Fatalf("newDevCA() error = %v", err)
+	}
+
+	serverCert, err := ca.issueServer(devCertificateHosts)
+	if err != nil {
+		t.Fatalf("issueServer() error = %v", err)
+	}
+
+	clientCert, err := ca.issueClient(7)
+	if err != nil {
+		t.Fatalf("issueClient() error = %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	router := http.NewServeMux()
+	calendarApp := calendarBuilder.NewApplication(context.Background())
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarApp))
+
+	server := httptest.NewUnstartedServer(router)
+	server.EnableHTTP2 = true
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			ForceAttemptHTTP2: true,
+			TLSClientConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: []tls.Certificate{clientCert},
+			},
+		},
+	}
+
+	post := func(form url.Values) *http.Response {
+		t.Helper()
+
+		resp, err := client.Post(server.URL+"/create_event", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatalf("POST /create_event error = %v", err)
+		}
+
+		return resp
+	}
+
+	// user_id берётся из сертификата
+	resp := post(url.Values{"date": {"2019-09-09"}, "description": {"standup"}})
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("response protocol = %s, expected HTTP/2", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /create_event status = %d, expected 200", resp.StatusCode)
+	}
+
+	body := struct {
+		Result struct{ UserID int } `json:"result"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	if body.Result.UserID != 7 {
+		t.Errorf("created event user = %d, expected 7", body.Result.UserID)
+	}
+
+	// Создать событие от имени другого пользователя нельзя
+	forbidden := post(url.Values{"user_id": {"8"}, "date": {"2019-09-09"}, "description": {"standup"}})
+	forbidden.Body.Close()
+
+	if forbidden.StatusCode != http.StatusForbidden {
+		t.Errorf("POST /create_event for another user status = %d, expected 403", forbidden.StatusCode)
+	}
+
+	// Сертификат без пользователя не даёт доступа к событиям, но пробы отвечают
+	template, err := certificateTemplate("monitoring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	probeCert, err := ca.issue(template)
+	if err != nil {
+		t.Fatalf("issue() error = %v", err)
+	}
+
+	probe := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{probeCert}}}}
+	for path, expected := range map[string]int{"/readyz": http.StatusOK, "/events_for_day?date=2019-09-09": http.StatusForbidden} {
+		resp, err := probe.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Errorf("GET %s with unmapped certificate status = %d, expected %d", path, resp.StatusCode, expected)
+		}
+	}
+
+	// Без клиентского сертификата TLS рукопожатие не проходит
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	if resp, err := anonymous.Get(server.URL + "/healthz"); err == nil {
+		resp.Body.Close()
+		t.Errorf("GET /healthz without client certificate succeeded, expected TLS error")
+	}
+}
+
+func TestJsonRpcOverTLSMapsCertificateToUser(t *testing.T) {
+	ca, err := newDevCA()
+	if err != nil {
+		t.Fatalf("newDevCA() error = %v", err)
+	}
+
+	serverCert, err := ca.issueServer(devCertificateHosts)
+	if err != nil {
+		t.Fatalf("issueServer() error = %v", err)
+	}
+
+	clientCert, err := ca.issueClient(7)
+	if err != nil {
+		t.Fatalf("issueClient() error = %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	listener, err := listenRPC("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("listenRPC() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calendarApp := calendarBuilder.NewApplication(context.Background())
+	go calendarPorts.NewJsonRpcCalendarHandler(calendarApp).ServeTCP(ctx, listener)
+
+	call := func(certificates []tls.Certificate, request string) (string, error) {
+		t.Helper()
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: pool, Certificates: certificates})
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write([]byte(request + "\n")); err != nil {
+			return "", err
+		}
+
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+
+	// user_id берётся из сертификата
+	response, err := call([]tls.Certificate{clientCert},
+		`{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"date":"2019-09-09","description":"tcp"},"id":1}`)
+	if err != nil || !strings.Contains(response, `"UserID":7`) {
+		t.Errorf("createEvent with client certificate = %q, %v, expected event of user 7", response, err)
+	}
+
+	// Без клиентского сертификата запрос не выполняется
+	response, err = call(nil, `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"date":"2019-09-09"},"id":2}`)
+	if err == nil {
+		t.Errorf("getEventsForDay without client certificate = %q, expected TLS error", response)
+	}
+}
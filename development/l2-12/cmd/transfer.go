@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/adapters"
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+// Выгружает содержимое хранилища событий в CSV или NDJSON
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := flags.String("out", "", "файл для выгрузки (по умолчанию STDOUT)")
+	format := flags.String("format", "", "формат выгрузки: csv или ndjson (по умолчанию по расширению -out)")
+	in := flags.String("in", "", "файл CSV или NDJSON, который загружается в хранилище перед выгрузкой")
+	dataDir := flags.String("data-dir", "", "каталог журнала и снимков событий остановленного сервера")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Без каталога данных хранилище пустое и выгрузка имеет смысл только для -in
+	if *dataDir == "" && *in == "" {
+		return fmt.Errorf("export needs -data-dir or -in")
+	}
+
+	ctx := context.Background()
+
+	calendarApp, closeStore, err := openTransferStore(ctx, *dataDir)
+	if err != nil {
+		return err
+	}
+
+	if *in != "" {
+		if err := importFile(ctx, calendarApp, *in, ""); err != nil {
+			return errors.Join(err, closeStore())
+		}
+	}
+
+	return errors.Join(exportFile(ctx, calendarApp, *out, *format), closeStore())
+}
+
+// Загружает события из CSV или NDJSON в хранилище с сохранением ID
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := flags.String("in", "", "файл для загрузки (по умолчанию STDIN, тогда обязателен -format)")
+	format := flags.String("format", "", "формат файла: csv или ndjson (по умолчанию по расширению -in)")
+	out := flags.String("out", "", "файл, в который выгружается хранилище после загрузки")
+	outFormat := flags.String("out-format", "", "формат выгрузки -out: csv или ndjson")
+	dataDir := flags.String("data-dir", "", "каталог журнала и снимков событий остановленного сервера, в который загружаются события")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Без каталога данных загруженные события пропали бы вместе с процессом
+	if *dataDir == "" && *out == "" {
+		return fmt.Errorf("import needs -data-dir or -out")
+	}
+
+	ctx := context.Background()
+
+	calendarApp, closeStore, err := openTransferStore(ctx, *dataDir)
+	if err != nil {
+		return err
+	}
+
+	// Строки без ошибок уже загружены и сохраняются в каталоге данных даже при ошибках в других строках
+	err = importFile(ctx, calendarApp, *in, *format)
+
+	if err == nil && *out != "" {
+		err = exportFile(ctx, calendarApp, *out, *outFormat)
+	}
+
+	return errors.Join(err, closeStore())
+}
+
+// Открывает хранилище событий для выгрузки и загрузки: журнал в dataDir или, без него, хранилище в памяти.
+// Сервер с тем же каталогом должен быть остановлен, иначе его журнал и журнал команды перепишут друг друга
+func openTransferStore(ctx context.Context, dataDir string) (*calendarBuilder.Application, func() error, error) {
+	if dataDir == "" {
+		return calendarBuilder.NewApplication(ctx), func() error { return nil }, nil
+	}
+
+	// Снимок при закрытии сбрасывает всё на диск, поэтому fsync после каждой записи не нужен
+	options := adapters.DefaultWalOptions()
+	options.Sync = adapters.WalSyncNever
+	options.SnapshotInterval = 0
+
+	eventRepository, err := openEventLog(dataDir, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := calendarBuilder.DefaultConfig()
+	config.EventRepository = eventRepository
+
+	return calendarBuilder.NewApplicationWithConfig(ctx, config), eventRepository.Close, nil
+}
+
+// Загружает файл path (или STDIN при пустом path) в хранилище.
+// Ошибки отдельных строк печатаются в лог, а итоговая ошибка возвращается, если хотя бы одна строка не загружена
+func importFile(ctx context.Context, calendarApp *calendarBuilder.Application, path, format string) error {
+	var r io.Reader = os.Stdin
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	format, err := resolveFormat(path, format)
+	if err != nil {
+		return err
+	}
+
+	imported, lineErrors, err := calendarPorts.ImportEvents(ctx, calendarApp, r, format)
+	if err != nil {
+		return err
+	}
+
+	for _, lineErr := range lineErrors {
+		log.Printf("import %s: %v\n", displayPath(path), lineErr)
+	}
+
+	log.Printf("Imported %d events from %s\n", imported, displayPath(path))
+
+	if len(lineErrors) > 0 {
+		return fmt.Errorf("import %s: %d invalid lines", displayPath(path), len(lineErrors))
+	}
+
+	return nil
+}
+
+// Выгружает хранилище в файл path (или STDOUT при пустом path)
+func exportFile(ctx context.Context, calendarApp *calendarBuilder.Application, path, format string) error {
+	format, err := resolveFormat(path, format)
+	if err != nil {
+		return err
+	}
+
+	var (
+		w    io.Writer = os.Stdout
+		file *os.File
+	)
+
+	if path != "" {
+		file, err = os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		w = file
+	}
+
+	exported, err := calendarPorts.ExportEvents(ctx, calendarApp, w, format)
+	// Без успешного Close выгрузка могла не попасть в файл целиком
+	if file != nil {
+		err = errors.Join(err, file.Close())
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d events to %s\n", exported, displayPath(path))
+
+	return nil
+}
+
+func resolveFormat(path, format string) (string, error) {
+	if format != "" {
+		return format, nil
+	}
+
+	if path == "" {
+		return "", fmt.Errorf("-format is required when reading STDIN or writing STDOUT")
+	}
+
+	return calendarPorts.TransferFormatFromPath(path)
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "standard stream"
+	}
+
+	return path
+}
@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type CacheAttachmentRepository struct {
+	// Вложения по ID арендатора и ID события
+	namespaces map[string]map[int][]domain.Attachment
+	mu         *sync.RWMutex
+}
+
+func NewCacheAttachmentRepository() *CacheAttachmentRepository {
+	return &CacheAttachmentRepository{
+		namespaces: make(map[string]map[int][]domain.Attachment),
+		mu:         &sync.RWMutex{},
+	}
+}
+
+// Возвращает вложения арендатора из контекста. Вызывается под блокировкой
+func (r *CacheAttachmentRepository) namespace(ctx context.Context) map[int][]domain.Attachment {
+	tenantID := domain.TenantFromContext(ctx)
+
+	cache, ok := r.namespaces[tenantID]
+	if !ok {
+		cache = make(map[int][]domain.Attachment)
+		r.namespaces[tenantID] = cache
+	}
+
+	return cache
+}
+
+func (r *CacheAttachmentRepository) AddAttachment(ctx context.Context, attachment domain.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cache := r.namespace(ctx)
+
+	for _, v := range cache[attachment.EventID] {
+		if v.ID == attachment.ID {
+			return fmt.Errorf("%w: attachment %s already exists", domain.ErrConflict, attachment.ID)
+		}
+	}
+
+	cache[attachment.EventID] = append(cache[attachment.EventID], attachment)
+
+	return nil
+}
+
+func (r *CacheAttachmentRepository) GetAttachment(ctx context.Context, eventID int, attachmentID string) (domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.namespaces[domain.TenantFromContext(ctx)][eventID] {
+		if v.ID == attachmentID {
+			return v, nil
+		}
+	}
+
+	return domain.Attachment{}, fmt.Errorf("%w: attachment %s of event %d", domain.ErrNotFound, attachmentID, eventID)
+}
+
+func (r *CacheAttachmentRepository) ListAttachments(ctx context.Context, eventID int) ([]domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachments := r.namespaces[domain.TenantFromContext(ctx)][eventID]
+
+	return append(make([]domain.Attachment, 0, len(attachments)), attachments...), nil
+}
+
+func (r *CacheAttachmentRepository) DeleteAttachment(ctx context.Context, eventID int, attachmentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cache := r.namespace(ctx)
+	attachments := cache[eventID]
+
+	i := slices.IndexFunc(attachments, func(v domain.Attachment) bool { return v.ID == attachmentID })
+	if i < 0 {
+		return fmt.Errorf("%w: attachment %s of event %d", domain.ErrNotFound, attachmentID, eventID)
+	}
+
+	attachments = slices.Delete(attachments, i, i+1)
+	if len(attachments) == 0 {
+		delete(cache, eventID)
+	} else {
+		cache[eventID] = attachments
+	}
+
+	return nil
+}
+
+func (r *CacheAttachmentRepository) DeleteNamespace(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.namespaces, domain.TenantFromContext(ctx))
+
+	return nil
+}
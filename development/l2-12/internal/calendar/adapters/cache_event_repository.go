@@ -2,57 +2,114 @@ package adapters
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
 )
 
-type CacheEventRepository struct {
+// События одного арендатора со своей нумерацией ID
+type eventNamespace struct {
 	cache         map[int]domain.Event
 	autoIncrement int
-	maxSize       int
-	mu            *sync.RWMutex
+}
+
+type CacheEventRepository struct {
+	// Пространства событий по ID арендатора из контекста
+	namespaces map[string]*eventNamespace
+	maxSize    int
+	mu         *sync.RWMutex
 }
 
 func NewCacheEventRepository(maxSize int) *CacheEventRepository {
 	return &CacheEventRepository{
-		cache:         make(map[int]domain.Event, maxSize),
-		autoIncrement: 1,
-		maxSize:       maxSize,
-		mu:            &sync.RWMutex{},
+		namespaces: make(map[string]*eventNamespace),
+		maxSize:    maxSize,
+		mu:         &sync.RWMutex{},
+	}
+}
+
+// Возвращает пространство арендатора из контекста. Если create, отсутствующее пространство создаётся,
+// иначе вместо него возвращается пустое. Вызывается под блокировкой (для create - под блокировкой на запись)
+func (r *CacheEventRepository) namespace(ctx context.Context, create bool) *eventNamespace {
+	tenantID := domain.TenantFromContext(ctx)
+
+	if ns, ok := r.namespaces[tenantID]; ok {
+		return ns
+	}
+
+	if !create {
+		return &eventNamespace{autoIncrement: 1}
 	}
+
+	ns := &eventNamespace{cache: make(map[int]domain.Event, r.maxSize), autoIncrement: 1}
+	r.namespaces[tenantID] = ns
+
+	return ns
 }
 
 func (r *CacheEventRepository) CreateEvent(ctx context.Context, domainEvent domain.Event) (int, error) {
+	return r.CreateEventWithLimit(ctx, domainEvent, 0)
+}
+
+func (r *CacheEventRepository) CreateEventWithLimit(ctx context.Context, domainEvent domain.Event, limit int) (int, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.autoIncrement == r.maxSize {
-		r.autoIncrement = 1
+	ns := r.namespace(ctx, true)
+
+	// Событие с существующим ID заменяется без вытеснения других
+	if _, ok := ns.cache[domainEvent.ID]; ok {
+		ns.cache[domainEvent.ID] = domainEvent
+		return domainEvent.ID, nil
 	}
 
-	if len(r.cache) == r.maxSize {
-		delete(r.cache, r.autoIncrement)
+	if err := checkEventLimit(ctx, ns, limit); err != nil {
+		return 0, err
 	}
 
-	if _, ok := r.cache[domainEvent.ID]; !ok {
-		domainEvent.ID = r.autoIncrement
-		r.autoIncrement++
+	if ns.autoIncrement == r.maxSize {
+		ns.autoIncrement = 1
 	}
 
-	r.cache[domainEvent.ID] = domainEvent
+	if len(ns.cache) == r.maxSize {
+		delete(ns.cache, ns.autoIncrement)
+	}
+
+	domainEvent.ID = ns.autoIncrement
+	ns.autoIncrement++
+
+	ns.cache[domainEvent.ID] = domainEvent
 
 	return domainEvent.ID, nil
 }
 
+// Проверяет ограничение на количество событий арендатора. Вызывается под блокировкой
+func checkEventLimit(ctx context.Context, ns *eventNamespace, limit int) error {
+	if limit > 0 && len(ns.cache) >= limit {
+		return fmt.Errorf("%w: tenant %q reached the limit of %d events", domain.ErrConflict, domain.TenantFromContext(ctx), limit)
+	}
+
+	return nil
+}
+
+// Проверяет ограничение на количество событий до записи в журнал
+func (r *CacheEventRepository) checkEventLimit(ctx context.Context, limit int) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return checkEventLimit(ctx, r.namespace(ctx, false), limit)
+}
+
 func (r *CacheEventRepository) GetEventByID(ctx context.Context, eventID int) (domain.Event, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	if event, ok := r.cache[eventID]; !ok {
-		return domain.Event{}, fmt.Errorf("Error: can't find event\n")
+	if event, ok := r.namespace(ctx, false).cache[eventID]; !ok {
+		return domain.Event{}, fmt.Errorf("%w: event %d", domain.ErrNotFound, eventID)
 	} else {
 		return event, nil
 	}
@@ -79,7 +136,7 @@ func (r *CacheEventRepository) DeleteEvent(ctx context.Context, eventID int) err
 	}
 
 	r.mu.Lock()
-	delete(r.cache, eventID)
+	delete(r.namespace(ctx, true).cache, eventID)
 	r.mu.Unlock()
 
 	return nil
@@ -91,7 +148,7 @@ func (r *CacheEventRepository) GetEventsForDay(ctx context.Context, date time.Ti
 
 	eventsForDay := make([]domain.Event, 0, 5)
 
-	for _, v := range r.cache {
+	for _, v := range r.namespace(ctx, false).cache {
 		if v.Date.Year() == date.Year() && v.Date.Month() == date.Month() && v.Date.Day() == date.Day() {
 			eventsForDay = append(eventsForDay, v)
 		}
@@ -101,40 +158,142 @@ func (r *CacheEventRepository) GetEventsForDay(ctx context.Context, date time.Ti
 }
 
 func (r *CacheEventRepository) GetEventsForWeek(ctx context.Context, date time.Time) ([]domain.Event, error) {
+	// Неделя по умолчанию начинается с понедельника
+	from, to := domain.WeekRange(date, time.Monday)
+	return r.GetEventsForPeriod(ctx, from, to)
+}
+
+func (r *CacheEventRepository) GetEventsForMonth(ctx context.Context, date time.Time) ([]domain.Event, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	eventsForWeek := make([]domain.Event, 0, 10)
+	eventsForMonth := make([]domain.Event, 0, 20)
 
-	weekday := int(date.Weekday())
-	if weekday == 0 {
-		weekday = 7
+	for _, v := range r.namespace(ctx, false).cache {
+		if v.Date.Year() == date.Year() && v.Date.Month() == date.Month() {
+			eventsForMonth = append(eventsForMonth, v)
+		}
 	}
 
-	// Определяем пограничные дни недели для корректной работы функций time.After() и time.Before()
-	startOfWeek := date.AddDate(0, 0, -weekday)
-	endOfWeek := startOfWeek.AddDate(0, 0, 8)
+	return eventsForMonth, nil
+}
 
-	for _, v := range r.cache {
-		if v.Date.After(startOfWeek) && v.Date.Before(endOfWeek) {
-			eventsForWeek = append(eventsForWeek, v)
+func (r *CacheEventRepository) GetEventsForPeriod(ctx context.Context, from, to time.Time) ([]domain.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]domain.Event, 0, 10)
+
+	for _, v := range r.namespace(ctx, false).cache {
+		if !v.Date.Before(from) && v.Date.Before(to) {
+			events = append(events, v)
 		}
 	}
 
-	return eventsForWeek, nil
+	return events, nil
 }
 
-func (r *CacheEventRepository) GetEventsForMonth(ctx context.Context, date time.Time) ([]domain.Event, error) {
+func (r *CacheEventRepository) ListEvents(ctx context.Context) ([]domain.Event, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	eventsForMonth := make([]domain.Event, 0, 20)
+	ns := r.namespace(ctx, false)
 
-	for _, v := range r.cache {
-		if v.Date.Year() == date.Year() && v.Date.Month() == date.Month() {
-			eventsForMonth = append(eventsForMonth, v)
+	events := make([]domain.Event, 0, len(ns.cache))
+
+	for _, v := range ns.cache {
+		events = append(events, v)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+func (r *CacheEventRepository) RestoreEvent(ctx context.Context, domainEvent domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// ID выдаются по кругу в диапазоне [1, maxSize), поэтому другие ID кэш хранить не может
+	if domainEvent.ID < 1 || domainEvent.ID >= r.maxSize {
+		return fmt.Errorf("%w: event id %d is out of range [1, %d)", domain.ErrValidation, domainEvent.ID, r.maxSize)
+	}
+
+	ns := r.namespace(ctx, true)
+
+	if _, ok := ns.cache[domainEvent.ID]; !ok && len(ns.cache) == r.maxSize {
+		return fmt.Errorf("%w: event cache is full", domain.ErrConflict)
+	}
+
+	ns.cache[domainEvent.ID] = domainEvent
+
+	// Новые события не должны перезаписывать восстановленные
+	if domainEvent.ID >= ns.autoIncrement {
+		ns.autoIncrement = domainEvent.ID + 1
+	}
+
+	return nil
+}
+
+func (r *CacheEventRepository) CountEvents(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.namespace(ctx, false).cache), nil
+}
+
+func (r *CacheEventRepository) DeleteNamespace(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.namespaces, domain.TenantFromContext(ctx))
+
+	return nil
+}
+
+// Ping проверяет, что кэш не заблокирован зависшей операцией дольше, чем позволяет ctx,
+// и что его содержимое согласовано: ID событий совпадают с ключами и лежат в диапазоне [1, maxSize)
+func (r *CacheEventRepository) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		r.mu.RLock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+	case <-ctx.Done():
+		// Блокировка снимается, когда её всё-таки удастся получить
+		go func() {
+			<-locked
+			r.mu.RUnlock()
+		}()
+		return fmt.Errorf("event cache is locked: %w", ctx.Err())
+	}
+	defer r.mu.RUnlock()
+
+	if r.namespaces == nil {
+		return errors.New("event cache is not initialized")
+	}
+
+	for tenantID, ns := range r.namespaces {
+		if len(ns.cache) >= r.maxSize || ns.autoIncrement < 1 || ns.autoIncrement > r.maxSize {
+			return fmt.Errorf("event cache of tenant %q is inconsistent: %d events, next id %d, max size %d",
+				tenantID, len(ns.cache), ns.autoIncrement, r.maxSize)
+		}
+
+		for id, event := range ns.cache {
+			if id != event.ID || id < 1 || id >= r.maxSize {
+				return fmt.Errorf("event cache of tenant %q is inconsistent: event %d is stored as %d", tenantID, event.ID, id)
+			}
 		}
 	}
 
-	return eventsForMonth, nil
+	return nil
 }
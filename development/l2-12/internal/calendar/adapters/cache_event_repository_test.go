@@ -0,0 +1,210 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Параллельная нагрузка на все методы хранилища. Имеет смысл прежде всего под go test -race
+func TestCacheEventRepositoryConcurrent(t *testing.T) {
+	const (
+		maxSize    = 50
+		workers    = 8
+		iterations = 300
+	)
+
+	repository := NewCacheEventRepository(maxSize)
+	date := time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC)
+
+	// Каждый арендатор пишет события своего пользователя, чтобы проверить, что пространства не смешиваются
+	tenants := map[string]int{"team-a": 1, "team-b": 2}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, workers*len(tenants))
+
+	for tenantID, userID := range tenants {
+		ctx := domain.WithTenant(context.Background(), tenantID)
+
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+
+			go func(worker int) {
+				defer wg.Done()
+
+				for i := 0; i < iterations; i++ {
+					day := date.AddDate(0, 0, (worker+i)%28)
+
+					id, err := repository.CreateEvent(ctx, domain.Event{UserID: userID, Date: day, Description: "load"})
+					if err != nil {
+						errs <- err
+						return
+					}
+
+					// Событие могли вытеснить или удалить другие горутины, поэтому ErrNotFound допустим
+					event := domain.Event{ID: id, UserID: userID, Date: day, Description: "updated"}
+					if err := repository.UpdateEvent(ctx, event); err != nil && !errors.Is(err, domain.ErrNotFound) {
+						errs <- err
+						return
+					}
+
+					switch i % 4 {
+					case 0:
+						_, err = repository.GetEventsForDay(ctx, day)
+					case 1:
+						_, err = repository.GetEventsForWeek(ctx, day)
+					case 2:
+						_, err = repository.GetEventsForMonth(ctx, day)
+					case 3:
+						err = repository.DeleteEvent(ctx, id)
+					}
+					if err != nil && !errors.Is(err, domain.ErrNotFound) {
+						errs <- err
+						return
+					}
+				}
+			}(worker)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent workload error = %v", err)
+	}
+
+	for tenantID, userID := range tenants {
+		ctx := domain.WithTenant(context.Background(), tenantID)
+
+		events, err := repository.ListEvents(ctx)
+		if err != nil {
+			t.Fatalf("ListEvents() error = %v", err)
+		}
+
+		if len(events) > maxSize {
+			t.Errorf("tenant %s: %d events, expected at most %d", tenantID, len(events), maxSize)
+		}
+
+		for _, event := range events {
+			if event.UserID != userID {
+				t.Errorf("tenant %s: event %+v of another tenant", tenantID, event)
+			}
+			if event.ID < 1 || event.ID >= maxSize {
+				t.Errorf("tenant %s: event id %d is out of range [1, %d)", tenantID, event.ID, maxSize)
+			}
+		}
+
+		count, err := repository.CountEvents(ctx)
+		if err != nil || count != len(events) {
+			t.Errorf("tenant %s: CountEvents() = %d, %v, expected %d", tenantID, count, err, len(events))
+		}
+	}
+}
+
+// Параллельные запросы не могут превысить ограничение арендатора на количество событий
+func TestCacheEventRepositoryEventLimit(t *testing.T) {
+	const (
+		limit   = 5
+		workers = 20
+	)
+
+	repository := NewCacheEventRepository(50)
+	ctx := domain.WithTenant(context.Background(), "team-a")
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, workers)
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := repository.CreateEventWithLimit(ctx, domain.Event{UserID: 1, Description: "limited"}, limit); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	rejected := 0
+	for err := range errs {
+		if !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("CreateEventWithLimit() error = %v, expected %v", err, domain.ErrConflict)
+		}
+		rejected++
+	}
+
+	count, err := repository.CountEvents(ctx)
+	if err != nil || count != limit || rejected != workers-limit {
+		t.Errorf("CountEvents() = %d, %v with %d rejected, expected %d and %d", count, err, rejected, limit, workers-limit)
+	}
+
+	// Изменение существующего события ограничением не считается
+	if err := repository.UpdateEvent(ctx, domain.Event{ID: 1, UserID: 1, Description: "updated"}); err != nil {
+		t.Errorf("UpdateEvent() at the limit error = %v", err)
+	}
+}
+
+func TestCacheEventRepositoryPing(t *testing.T) {
+	repository := NewCacheEventRepository(10)
+	ctx := domain.WithTenant(context.Background(), "team-a")
+
+	if _, err := repository.CreateEvent(ctx, domain.Event{UserID: 1, Description: "standup"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if err := repository.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := repository.Ping(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Ping(cancelled) error = %v, expected %v", err, context.Canceled)
+	}
+
+	// Зависшая операция держит блокировку: проверка не ждёт её дольше ctx
+	repository.mu.Lock()
+
+	timeout, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := repository.Ping(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ping() with held lock error = %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	repository.mu.Unlock()
+
+	// Блокировка, полученная после таймаута, освобождается и не мешает записи
+	if _, err := repository.CreateEvent(ctx, domain.Event{UserID: 1, Description: "retro"}); err != nil {
+		t.Fatalf("CreateEvent() after timed out Ping error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(ns *eventNamespace)
+	}{
+		{name: "event stored under another id", corrupt: func(ns *eventNamespace) { ns.cache[5] = domain.Event{ID: 6} }},
+		{name: "id out of range", corrupt: func(ns *eventNamespace) { ns.cache[10] = domain.Event{ID: 10} }},
+		{name: "next id out of range", corrupt: func(ns *eventNamespace) { ns.autoIncrement = 0 }},
+	}
+
+	for _, test := range tests {
+		repository := NewCacheEventRepository(10)
+		if _, err := repository.CreateEvent(ctx, domain.Event{UserID: 1, Description: "standup"}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+
+		test.corrupt(repository.namespaces["team-a"])
+
+		if err := repository.Ping(context.Background()); err == nil {
+			t.Errorf("%s: Ping() succeeded, expected inconsistent cache error", test.name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type idempotencyEntry struct {
+	fingerprint string
+	// Ответ ещё не сохранён: запрос с этим ключом выполняется
+	pending   bool
+	response  domain.IdempotentResponse
+	expiresAt time.Time
+}
+
+type CacheIdempotencyStore struct {
+	entries map[string]idempotencyEntry
+	ttl     time.Duration
+	now     func() time.Time
+	mu      *sync.Mutex
+}
+
+// NewCacheIdempotencyStore хранит ответы в памяти в течение ttl после первого запроса
+func NewCacheIdempotencyStore(ttl time.Duration) *CacheIdempotencyStore {
+	return &CacheIdempotencyStore{
+		entries: make(map[string]idempotencyEntry),
+		ttl:     ttl,
+		now:     time.Now,
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (s *CacheIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string) (domain.IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.removeExpired(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		s.entries[key] = idempotencyEntry{fingerprint: fingerprint, pending: true, expiresAt: now.Add(s.ttl)}
+		return domain.IdempotentResponse{}, false, nil
+	}
+
+	if entry.pending {
+		return domain.IdempotentResponse{}, false, fmt.Errorf("%w: request with idempotency key %q is in progress", domain.ErrConflict, key)
+	}
+
+	response := entry.response
+	response.Header = cloneHeader(response.Header)
+
+	return response, true, nil
+}
+
+func (s *CacheIdempotencyStore) Complete(ctx context.Context, key string, response domain.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.pending {
+		return fmt.Errorf("%w: idempotency key %q is not reserved", domain.ErrConflict, key)
+	}
+
+	response.Header = cloneHeader(response.Header)
+	entry.pending = false
+	entry.response = response
+
+	s.entries[key] = entry
+
+	return nil
+}
+
+func (s *CacheIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.pending {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// Удаляет ключи, окно которых закончилось. Вызывается под блокировкой
+func (s *CacheIdempotencyStore) removeExpired(now time.Time) {
+	maps.DeleteFunc(s.entries, func(key string, entry idempotencyEntry) bool {
+		return !now.Before(entry.expiresAt)
+	})
+}
+
+// Копия заголовков, чтобы сохранённый ответ не менялся вместе с ответом обработчика
+func cloneHeader(header map[string][]string) map[string][]string {
+	if header == nil {
+		return nil
+	}
+
+	clone := make(map[string][]string, len(header))
+	for name, values := range header {
+		clone[name] = slices.Clone(values)
+	}
+
+	return clone
+}
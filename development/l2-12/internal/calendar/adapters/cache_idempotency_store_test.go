@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func TestCacheIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewCacheIdempotencyStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	if _, done, err := store.Reserve(ctx, "key", "a"); err != nil || done {
+		t.Fatalf("Reserve() = %v, %v, expected a new reservation", done, err)
+	}
+
+	// Пока первый запрос выполняется, повтор получает конфликт
+	if _, _, err := store.Reserve(ctx, "key", "a"); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("Reserve() of pending key error = %v, expected ErrConflict", err)
+	}
+
+	if err := store.Complete(ctx, "key", domain.IdempotentResponse{Fingerprint: "a", StatusCode: 200, Body: []byte("ok")}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	response, done, err := store.Reserve(ctx, "key", "a")
+	if err != nil || !done || response.StatusCode != 200 || string(response.Body) != "ok" {
+		t.Fatalf("Reserve() = %+v, %v, %v, expected the saved response", response, done, err)
+	}
+
+	// После окна ключ можно использовать заново
+	now = now.Add(time.Hour)
+	if _, done, err := store.Reserve(ctx, "key", "b"); err != nil || done {
+		t.Fatalf("Reserve() after ttl = %v, %v, expected a new reservation", done, err)
+	}
+
+	// Освобождённый ключ не хранит ответ
+	if err := store.Release(ctx, "key"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, done, err := store.Reserve(ctx, "key", "c"); err != nil || done {
+		t.Fatalf("Reserve() after release = %v, %v, expected a new reservation", done, err)
+	}
+}
@@ -0,0 +1,100 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Формат ключа дня в карте праздников
+const holidayKeyLayout = "2006-01-02"
+
+type CacheScheduleRepository struct {
+	defaultHours domain.WorkingHours
+	// Рабочее время по ID арендатора и ID пользователя
+	workingHours map[string]map[int]domain.WorkingHours
+	holidays     map[string]domain.Holiday
+	mu           *sync.RWMutex
+}
+
+func NewCacheScheduleRepository(defaultHours domain.WorkingHours) *CacheScheduleRepository {
+	return &CacheScheduleRepository{
+		defaultHours: defaultHours,
+		workingHours: make(map[string]map[int]domain.WorkingHours),
+		holidays:     make(map[string]domain.Holiday),
+		mu:           &sync.RWMutex{},
+	}
+}
+
+func (r *CacheScheduleRepository) GetWorkingHours(ctx context.Context, userID int) (domain.WorkingHours, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hours, ok := r.workingHours[domain.TenantFromContext(ctx)][userID]
+	if !ok {
+		hours = r.defaultHours
+	}
+
+	hours.Weekdays = slices.Clone(hours.Weekdays)
+
+	return hours, nil
+}
+
+func (r *CacheScheduleRepository) SetWorkingHours(ctx context.Context, userID int, hours domain.WorkingHours) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := domain.TenantFromContext(ctx)
+	if r.workingHours[tenantID] == nil {
+		r.workingHours[tenantID] = make(map[int]domain.WorkingHours)
+	}
+
+	hours.Weekdays = slices.Clone(hours.Weekdays)
+	r.workingHours[tenantID][userID] = hours
+
+	return nil
+}
+
+func (r *CacheScheduleRepository) DeleteNamespace(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.workingHours, domain.TenantFromContext(ctx))
+
+	return nil
+}
+
+func (r *CacheScheduleRepository) GetHolidays(ctx context.Context, from, to time.Time) ([]domain.Holiday, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	holidays := make([]domain.Holiday, 0)
+
+	for _, v := range r.holidays {
+		if !v.Date.Before(from) && v.Date.Before(to) {
+			holidays = append(holidays, v)
+		}
+	}
+
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+
+	return holidays, nil
+}
+
+func (r *CacheScheduleRepository) AddHolidays(ctx context.Context, holidays []domain.Holiday) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, holiday := range holidays {
+		holiday.Date = domain.StartOfDay(holiday.Date)
+		r.holidays[holiday.Date.Format(holidayKeyLayout)] = holiday
+	}
+
+	return nil
+}
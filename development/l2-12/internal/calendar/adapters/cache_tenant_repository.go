@@ -0,0 +1,91 @@
+package adapters
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type CacheTenantRepository struct {
+	cache map[string]domain.Tenant
+	mu    *sync.RWMutex
+}
+
+func NewCacheTenantRepository() *CacheTenantRepository {
+	return &CacheTenantRepository{
+		cache: make(map[string]domain.Tenant),
+		mu:    &sync.RWMutex{},
+	}
+}
+
+func (r *CacheTenantRepository) CreateTenant(ctx context.Context, tenant domain.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.cache[tenant.ID]; ok {
+		return fmt.Errorf("%w: tenant %q already exists", domain.ErrConflict, tenant.ID)
+	}
+
+	r.cache[tenant.ID] = tenant
+
+	return nil
+}
+
+func (r *CacheTenantRepository) GetTenant(ctx context.Context, tenantID string) (domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, ok := r.cache[tenantID]
+	if !ok {
+		return domain.Tenant{}, fmt.Errorf("%w: tenant %q", domain.ErrNotFound, tenantID)
+	}
+
+	return tenant, nil
+}
+
+func (r *CacheTenantRepository) GetTenantByToken(ctx context.Context, token string) (domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tenant := range r.cache {
+		if tenant.Token != "" && subtle.ConstantTimeCompare([]byte(tenant.Token), []byte(token)) == 1 {
+			return tenant, nil
+		}
+	}
+
+	return domain.Tenant{}, fmt.Errorf("%w: unknown tenant token", domain.ErrNotFound)
+}
+
+func (r *CacheTenantRepository) ListTenants(ctx context.Context) ([]domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]domain.Tenant, 0, len(r.cache))
+
+	for _, v := range r.cache {
+		tenants = append(tenants, v)
+	}
+
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+
+	return tenants, nil
+}
+
+func (r *CacheTenantRepository) DeleteTenant(ctx context.Context, tenantID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.cache[tenantID]; !ok {
+		return fmt.Errorf("%w: tenant %q", domain.ErrNotFound, tenantID)
+	}
+
+	delete(r.cache, tenantID)
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package adapters
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Производственный календарь в формате xmlcalendar.ru:
+//
+//	<calendar year="2024" country="ru">
+//	  <holidays><holiday id="1" title="Новогодние каникулы"/></holidays>
+//	  <days><day d="01.01" t="1" h="1"/><day d="04.27" t="3" f="11.02"/></days>
+//	</calendar>
+//
+// Тип дня t: 1 - выходной, 2 - сокращённый рабочий день, 3 - рабочий день (перенос)
+type xmlHolidayCalendar struct {
+	Year     int `xml:"year,attr"`
+	Holidays []struct {
+		ID    string `xml:"id,attr"`
+		Title string `xml:"title,attr"`
+	} `xml:"holidays>holiday"`
+	Days []struct {
+		Day       string `xml:"d,attr"`
+		Type      int    `xml:"t,attr"`
+		HolidayID string `xml:"h,attr"`
+	} `xml:"days>day"`
+}
+
+// Названия особых дней, для которых в календаре нет праздника
+var defaultDayNames = map[domain.DayKind]string{
+	domain.DayOff:     "Выходной (перенос)",
+	domain.ShortDay:   "Сокращённый рабочий день",
+	domain.WorkingDay: "Рабочий день (перенос)",
+}
+
+// Значения колонки kind в CSV файле
+var csvDayKinds = map[string]domain.DayKind{
+	"":        domain.DayOff,
+	"off":     domain.DayOff,
+	"short":   domain.ShortDay,
+	"working": domain.WorkingDay,
+}
+
+// LoadHolidayFile читает производственный календарь. Формат определяется по расширению:
+// .xml - формат xmlcalendar.ru, иначе CSV со строками date,name[,kind] (kind: off, short, working)
+func LoadHolidayFile(path string) ([]domain.Holiday, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var holidays []domain.Holiday
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		holidays, err = parseHolidayXML(file)
+	} else {
+		holidays, err = parseHolidayCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return holidays, nil
+}
+
+func parseHolidayXML(r io.Reader) ([]domain.Holiday, error) {
+	calendar := xmlHolidayCalendar{}
+	if err := xml.NewDecoder(r).Decode(&calendar); err != nil {
+		return nil, err
+	}
+
+	if calendar.Year == 0 {
+		return nil, errors.New("calendar year is missing")
+	}
+
+	titles := make(map[string]string, len(calendar.Holidays))
+	for _, holiday := range calendar.Holidays {
+		titles[holiday.ID] = holiday.Title
+	}
+
+	holidays := make([]domain.Holiday, 0, len(calendar.Days))
+
+	for _, day := range calendar.Days {
+		monthDay, err := time.Parse("01.02", day.Day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day %q", day.Day)
+		}
+
+		kind := domain.DayKind(day.Type)
+		if kind < domain.DayOff || kind > domain.WorkingDay {
+			return nil, fmt.Errorf("day %s: unknown type %d", day.Day, day.Type)
+		}
+
+		name := titles[day.HolidayID]
+		if name == "" {
+			name = defaultDayNames[kind]
+		}
+
+		holidays = append(holidays, domain.Holiday{
+			Date: time.Date(calendar.Year, monthDay.Month(), monthDay.Day(), 0, 0, 0, 0, time.UTC),
+			Name: name,
+			Kind: kind,
+		})
+	}
+
+	return holidays, nil
+}
+
+func parseHolidayCSV(r io.Reader) ([]domain.Holiday, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	holidays := make([]domain.Holiday, 0)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		// Необязательная строка заголовка
+		if line == 1 && record[0] == "date" {
+			continue
+		}
+
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected date,name[,kind]", line)
+		}
+
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, record[0])
+		}
+
+		kindName := ""
+		if len(record) == 3 {
+			kindName = strings.ToLower(strings.TrimSpace(record[2]))
+		}
+
+		kind, ok := csvDayKinds[kindName]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown kind %q", line, record[2])
+		}
+
+		name := strings.TrimSpace(record[1])
+		if name == "" {
+			name = defaultDayNames[kind]
+		}
+
+		holidays = append(holidays, domain.Holiday{Date: date, Name: name, Kind: kind})
+	}
+
+	return holidays, nil
+}
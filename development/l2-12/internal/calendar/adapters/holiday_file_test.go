@@ -0,0 +1,98 @@
+package adapters
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func TestParseHolidayXML(t *testing.T) {
+	const calendar = `<?xml version="1.0" encoding="UTF-8"?>
+<calendar year="2024" lang="ru" date="2024.01.01" country="ru">
+  <holidays>
+    <holiday id="1" title="Новогодние каникулы"/>
+    <holiday id="2" title="День защитника Отечества"/>
+  </holidays>
+  <days>
+    <day d="01.01" t="1" h="1"/>
+    <day d="02.22" t="2" h="2"/>
+    <day d="04.27" t="3" f="11.02"/>
+    <day d="04.29" t="1" f="04.27"/>
+  </days>
+</calendar>`
+
+	holidays, err := parseHolidayXML(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatalf("parseHolidayXML() error = %v", err)
+	}
+
+	expected := []domain.Holiday{
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Name: "Новогодние каникулы", Kind: domain.DayOff},
+		{Date: time.Date(2024, 2, 22, 0, 0, 0, 0, time.UTC), Name: "День защитника Отечества", Kind: domain.ShortDay},
+		{Date: time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC), Name: "Рабочий день (перенос)", Kind: domain.WorkingDay},
+		{Date: time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), Name: "Выходной (перенос)", Kind: domain.DayOff},
+	}
+
+	if len(holidays) != len(expected) {
+		t.Fatalf("parseHolidayXML() = %+v, expected %d days", holidays, len(expected))
+	}
+	for i := range expected {
+		if holidays[i] != expected[i] {
+			t.Errorf("parseHolidayXML() day %d = %+v, expected %+v", i, holidays[i], expected[i])
+		}
+	}
+}
+
+func TestParseHolidayCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []domain.Holiday
+		err      string
+	}{
+		{
+			name:  "header, comments and kinds",
+			input: "date,name,kind\n# праздники\n2024-01-01,Новый год\n2024-02-22, ,short\n2024-04-27,Перенос,working\n",
+			expected: []domain.Holiday{
+				{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Name: "Новый год", Kind: domain.DayOff},
+				{Date: time.Date(2024, 2, 22, 0, 0, 0, 0, time.UTC), Name: "Сокращённый рабочий день", Kind: domain.ShortDay},
+				{Date: time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC), Name: "Перенос", Kind: domain.WorkingDay},
+			},
+		},
+		{
+			name:  "invalid date",
+			input: "2024-13-01,Нет такого месяца\n",
+			err:   `line 1: invalid date "2024-13-01"`,
+		},
+		{
+			name:  "unknown kind",
+			input: "2024-01-01,Новый год,off\n2024-01-02,Каникулы,vacation\n",
+			err:   `line 2: unknown kind "vacation"`,
+		},
+	}
+
+	for _, test := range tests {
+		holidays, err := parseHolidayCSV(strings.NewReader(test.input))
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: parseHolidayCSV() error = %v, expected %q", test.name, err, test.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: parseHolidayCSV() error = %v", test.name, err)
+		}
+		if len(holidays) != len(test.expected) {
+			t.Fatalf("%s: parseHolidayCSV() = %+v, expected %+v", test.name, holidays, test.expected)
+		}
+		for i := range test.expected {
+			if holidays[i] != test.expected[i] {
+				t.Errorf("%s: parseHolidayCSV() day %d = %+v, expected %+v", test.name, i, holidays[i], test.expected[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// LocalBlobStorage хранит содержимое файлов в каталоге на локальном диске. Ключ - относительный путь
+type LocalBlobStorage struct {
+	root string
+}
+
+func NewLocalBlobStorage(root string) *LocalBlobStorage {
+	return &LocalBlobStorage{root: root}
+}
+
+func (s *LocalBlobStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатели не видели недописанное содержимое
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return size, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return size, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return size, err
+	}
+
+	return size, os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalBlobStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: blob %s", domain.ErrNotFound, key)
+	}
+
+	return file, err
+}
+
+func (s *LocalBlobStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// Пустые каталоги события и арендатора больше не нужны; непустой каталог os.Remove не удалит
+	for dir := filepath.Dir(path); dir != filepath.Clean(s.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Переводит ключ в путь внутри root, не выпуская за его пределы
+func (s *LocalBlobStorage) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(key) || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: invalid blob key %q", domain.ErrValidation, key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
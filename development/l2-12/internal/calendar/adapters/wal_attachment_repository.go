@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// WalAttachmentRepository - описания вложений, изменения которых пишутся в журнал событий.
+// Так после перезапуска у восстановленных событий остаются их вложения
+type WalAttachmentRepository struct {
+	*CacheAttachmentRepository
+
+	wal *WalEventRepository
+}
+
+// Attachments возвращает хранилище описаний вложений, которое пишет в этот журнал
+func (r *WalEventRepository) Attachments() *WalAttachmentRepository {
+	return &WalAttachmentRepository{CacheAttachmentRepository: r.attachments, wal: r}
+}
+
+func (r *WalAttachmentRepository) AddAttachment(ctx context.Context, attachment domain.Attachment) error {
+	r.wal.mu.Lock()
+	defer r.wal.mu.Unlock()
+
+	// Заведомо неудачные операции в журнал не пишутся
+	if _, err := r.CacheAttachmentRepository.GetAttachment(ctx, attachment.EventID, attachment.ID); err == nil {
+		return fmt.Errorf("%w: attachment %s already exists", domain.ErrConflict, attachment.ID)
+	}
+
+	if err := r.wal.append(walRecord{Op: walAttach, Tenant: domain.TenantFromContext(ctx), Attachment: &attachment}); err != nil {
+		return err
+	}
+
+	return r.CacheAttachmentRepository.AddAttachment(ctx, attachment)
+}
+
+func (r *WalAttachmentRepository) DeleteAttachment(ctx context.Context, eventID int, attachmentID string) error {
+	r.wal.mu.Lock()
+	defer r.wal.mu.Unlock()
+
+	if _, err := r.CacheAttachmentRepository.GetAttachment(ctx, eventID, attachmentID); err != nil {
+		return err
+	}
+
+	if err := r.wal.append(walRecord{Op: walDetach, Tenant: domain.TenantFromContext(ctx), EventID: eventID, AttachmentID: attachmentID}); err != nil {
+		return err
+	}
+
+	return r.CacheAttachmentRepository.DeleteAttachment(ctx, eventID, attachmentID)
+}
+
+func (r *WalAttachmentRepository) DeleteNamespace(ctx context.Context) error {
+	r.wal.mu.Lock()
+	defer r.wal.mu.Unlock()
+
+	if err := r.wal.append(walRecord{Op: walDropAttachments, Tenant: domain.TenantFromContext(ctx)}); err != nil {
+		return err
+	}
+
+	return r.CacheAttachmentRepository.DeleteNamespace(ctx)
+}
+
+// Описания вложений всех арендаторов для снимка
+func (r *CacheAttachmentRepository) snapshotNamespaces() map[string][]domain.Attachment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string][]domain.Attachment, len(r.namespaces))
+
+	for tenantID, cache := range r.namespaces {
+		for _, attachments := range cache {
+			snapshot[tenantID] = append(snapshot[tenantID], attachments...)
+		}
+	}
+
+	return snapshot
+}
+
+// Заменяет описания вложений снимком. Порядок вложений одного события сохраняется
+func (r *CacheAttachmentRepository) loadNamespaces(snapshot map[string][]domain.Attachment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.namespaces = make(map[string]map[int][]domain.Attachment, len(snapshot))
+
+	for tenantID, attachments := range snapshot {
+		cache := make(map[int][]domain.Attachment)
+		for _, attachment := range attachments {
+			cache[attachment.EventID] = append(cache[attachment.EventID], attachment)
+		}
+
+		r.namespaces[tenantID] = cache
+	}
+}
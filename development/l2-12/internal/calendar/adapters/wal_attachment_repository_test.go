@@ -0,0 +1,77 @@
+package adapters
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func listAttachments(t *testing.T, ctx context.Context, repository domain.AttachmentRepository, eventID int) []domain.Attachment {
+	t.Helper()
+
+	attachments, err := repository.ListAttachments(ctx, eventID)
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+
+	return attachments
+}
+
+func TestWalAttachmentRepositoryRecovery(t *testing.T) {
+	dir := t.TempDir()
+	ctx := domain.WithTenant(context.Background(), "team-a")
+	createdAt := time.Date(2019, 9, 9, 10, 0, 0, 0, time.UTC)
+
+	attachment := func(id string, eventID int) domain.Attachment {
+		return domain.Attachment{ID: id, TenantID: "team-a", EventID: eventID, Name: id + ".txt", ContentType: "text/plain", Size: 3, CreatedAt: createdAt}
+	}
+
+	repository := openTestWal(t, dir, 10)
+	attachments := repository.Attachments()
+
+	for _, v := range []domain.Attachment{attachment("a", 1), attachment("b", 1), attachment("c", 2)} {
+		if err := attachments.AddAttachment(ctx, v); err != nil {
+			t.Fatalf("AddAttachment() error = %v", err)
+		}
+	}
+	if err := attachments.AddAttachment(ctx, attachment("a", 1)); err == nil {
+		t.Fatalf("AddAttachment() of existing attachment succeeded")
+	}
+
+	// Часть операций попадает в снимок, остальные восстанавливаются из журнала
+	if err := repository.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if err := attachments.AddAttachment(ctx, attachment("d", 1)); err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+	if err := attachments.DeleteAttachment(ctx, 1, "b"); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	if err := attachments.DeleteNamespace(domain.WithTenant(context.Background(), "team-b")); err != nil {
+		t.Fatalf("DeleteNamespace() error = %v", err)
+	}
+
+	crashWal(t, repository)
+
+	recovered := openTestWal(t, dir, 10)
+	defer recovered.Close()
+
+	expected := map[int][]domain.Attachment{
+		1: {attachment("a", 1), attachment("d", 1)},
+		2: {attachment("c", 2)},
+	}
+
+	for eventID, want := range expected {
+		if got := listAttachments(t, ctx, recovered.Attachments(), eventID); !reflect.DeepEqual(got, want) {
+			t.Errorf("attachments of event %d = %+v, expected %+v", eventID, got, want)
+		}
+	}
+
+	if recovery := recovered.Recovery(); recovery.Replayed != 3 {
+		t.Errorf("Recovery() = %+v, expected 3 replayed records", recovery)
+	}
+}
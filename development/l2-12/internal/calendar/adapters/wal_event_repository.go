@@ -0,0 +1,656 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+const (
+	walFileName      = "events.wal"
+	snapshotFileName = "events.snapshot"
+	// Заголовок записи журнала: длина и CRC32 содержимого
+	walHeaderSize = 8
+	// Записи длиннее считаются повреждёнными
+	walMaxRecordSize = 16 << 20
+)
+
+// WalSyncPolicy определяет, когда журнал сбрасывается на диск
+type WalSyncPolicy string
+
+const (
+	// fsync после каждой записи: подтверждённые операции переживают отключение питания
+	WalSyncAlways WalSyncPolicy = "always"
+	// fsync раз в SyncInterval: при отключении питания теряются операции последнего интервала
+	WalSyncInterval WalSyncPolicy = "interval"
+	// fsync только при снимке и закрытии: данные переживают падение процесса, но не ОС
+	WalSyncNever WalSyncPolicy = "never"
+)
+
+func ParseWalSyncPolicy(value string) (WalSyncPolicy, error) {
+	switch policy := WalSyncPolicy(value); policy {
+	case WalSyncAlways, WalSyncInterval, WalSyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: unknown wal sync policy %q, expected always, interval or never", domain.ErrValidation, value)
+	}
+}
+
+// WalOptions - настройки журнала и снимков
+type WalOptions struct {
+	Sync WalSyncPolicy
+	// Период fsync для WalSyncInterval
+	SyncInterval time.Duration
+	// Период снимков, после которых журнал очищается. 0 - снимок только при закрытии
+	SnapshotInterval time.Duration
+}
+
+func DefaultWalOptions() WalOptions {
+	return WalOptions{
+		Sync:             WalSyncInterval,
+		SyncInterval:     time.Second,
+		SnapshotInterval: 5 * time.Minute,
+	}
+}
+
+// WalRecovery - итог восстановления при открытии хранилища
+type WalRecovery struct {
+	// Номер последней операции в загруженном снимке
+	SnapshotSeq uint64
+	// Сколько операций журнала применено поверх снимка
+	Replayed int
+	// Сколько байт недописанного или повреждённого хвоста журнала отброшено
+	TruncatedBytes int64
+}
+
+type walOp string
+
+const (
+	walCreate  walOp = "create"
+	walUpdate  walOp = "update"
+	walDelete  walOp = "delete"
+	walRestore walOp = "restore"
+	// Удаление всех событий арендатора
+	walDrop walOp = "drop"
+	// Операции с описаниями вложений
+	walAttach          walOp = "attach"
+	walDetach          walOp = "detach"
+	walDropAttachments walOp = "drop_attachments"
+	// Операции с арендаторами
+	walCreateTenant walOp = "create_tenant"
+	walDeleteTenant walOp = "delete_tenant"
+)
+
+type walRecord struct {
+	Seq     uint64       `json:"seq"`
+	Op      walOp        `json:"op"`
+	Tenant  string       `json:"tenant"`
+	Event   domain.Event `json:"event"`
+	EventID int          `json:"event_id,omitempty"`
+	// Описание вложения для walAttach, ID вложения для walDetach
+	Attachment   *domain.Attachment `json:"attachment,omitempty"`
+	AttachmentID string             `json:"attachment_id,omitempty"`
+	// Создаваемый арендатор для walCreateTenant
+	TenantInfo *domain.Tenant `json:"tenant_info,omitempty"`
+}
+
+type walSnapshot struct {
+	Seq        uint64                            `json:"seq"`
+	CreatedAt  time.Time                         `json:"created_at"`
+	Namespaces map[string]eventNamespaceSnapshot `json:"namespaces"`
+	// Описания вложений по ID арендатора в порядке загрузки
+	Attachments map[string][]domain.Attachment `json:"attachments,omitempty"`
+	// Арендаторы, кроме арендатора по умолчанию
+	Tenants []domain.Tenant `json:"tenants,omitempty"`
+}
+
+type eventNamespaceSnapshot struct {
+	NextID int            `json:"next_id"`
+	Events []domain.Event `json:"events"`
+}
+
+// WalEventRepository - CacheEventRepository, операции изменения которого пишутся в журнал (write-ahead log)
+// в каталоге dir. Журнал периодически сворачивается в снимок, при открытии снимок и журнал загружаются обратно.
+// Чтение обслуживается кэшем без обращения к диску. В тот же журнал пишутся описания вложений и арендаторы,
+// см. Attachments и Tenants
+type WalEventRepository struct {
+	*CacheEventRepository
+	attachments *CacheAttachmentRepository
+	tenants     *CacheTenantRepository
+
+	dir     string
+	options WalOptions
+	file    *os.File
+	// Номер последней записанной операции
+	seq uint64
+	// Сколько операций записано после последнего снимка
+	pending int
+	// В журнале есть записи без fsync
+	dirty bool
+	// Ошибка записи, после которой журнал не принимает операций
+	err      error
+	recovery WalRecovery
+
+	// Сериализует изменения, чтобы порядок в журнале совпадал с порядком применения
+	mu   *sync.Mutex
+	stop chan struct{}
+	wg   *sync.WaitGroup
+
+	// Журнал закрывается один раз, повторный Close возвращает ошибку первого
+	closeOnce *sync.Once
+	closeErr  error
+}
+
+// OpenWalEventRepository загружает в cache снимок и журнал из dir и начинает писать в журнал новые операции.
+// Недописанный при падении хвост журнала отбрасывается
+func OpenWalEventRepository(cache *CacheEventRepository, dir string, options WalOptions) (*WalEventRepository, error) {
+	if _, err := ParseWalSyncPolicy(string(options.Sync)); err != nil {
+		return nil, err
+	}
+	if options.Sync == WalSyncInterval && options.SyncInterval <= 0 {
+		return nil, fmt.Errorf("%w: wal sync interval must be positive", domain.ErrValidation)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	r := &WalEventRepository{
+		CacheEventRepository: cache,
+		attachments:          NewCacheAttachmentRepository(),
+		tenants:              NewCacheTenantRepository(),
+		dir:                  dir,
+		options:              options,
+		mu:                   &sync.Mutex{},
+		stop:                 make(chan struct{}),
+		wg:                   &sync.WaitGroup{},
+		closeOnce:            &sync.Once{},
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r.file = file
+
+	if err := r.replay(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if options.Sync == WalSyncInterval {
+		r.runEvery(options.SyncInterval, r.Sync)
+	}
+	if options.SnapshotInterval > 0 {
+		r.runEvery(options.SnapshotInterval, r.Snapshot)
+	}
+
+	return r, nil
+}
+
+// Recovery возвращает итог восстановления при открытии
+func (r *WalEventRepository) Recovery() WalRecovery {
+	return r.recovery
+}
+
+func (r *WalEventRepository) CreateEvent(ctx context.Context, event domain.Event) (int, error) {
+	return r.CreateEventWithLimit(ctx, event, 0)
+}
+
+func (r *WalEventRepository) CreateEventWithLimit(ctx context.Context, event domain.Event, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// При восстановлении ограничения нет, поэтому событие сверх него не должно попасть в журнал
+	if err := r.CacheEventRepository.checkEventLimit(ctx, limit); err != nil {
+		return 0, err
+	}
+
+	if err := r.append(walRecord{Op: walCreate, Tenant: domain.TenantFromContext(ctx), Event: event}); err != nil {
+		return 0, err
+	}
+
+	return r.CacheEventRepository.CreateEvent(ctx, event)
+}
+
+func (r *WalEventRepository) UpdateEvent(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Заведомо неудачные операции в журнал не пишутся
+	if _, err := r.CacheEventRepository.GetEventByID(ctx, event.ID); err != nil {
+		return err
+	}
+
+	if err := r.append(walRecord{Op: walUpdate, Tenant: domain.TenantFromContext(ctx), Event: event}); err != nil {
+		return err
+	}
+
+	return r.CacheEventRepository.UpdateEvent(ctx, event)
+}
+
+func (r *WalEventRepository) DeleteEvent(ctx context.Context, eventID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.CacheEventRepository.GetEventByID(ctx, eventID); err != nil {
+		return err
+	}
+
+	if err := r.append(walRecord{Op: walDelete, Tenant: domain.TenantFromContext(ctx), EventID: eventID}); err != nil {
+		return err
+	}
+
+	return r.CacheEventRepository.DeleteEvent(ctx, eventID)
+}
+
+func (r *WalEventRepository) RestoreEvent(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Ошибки восстановления воспроизводятся при повторе журнала так же, как при первом выполнении
+	if err := r.append(walRecord{Op: walRestore, Tenant: domain.TenantFromContext(ctx), Event: event}); err != nil {
+		return err
+	}
+
+	return r.CacheEventRepository.RestoreEvent(ctx, event)
+}
+
+func (r *WalEventRepository) DeleteNamespace(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.append(walRecord{Op: walDrop, Tenant: domain.TenantFromContext(ctx)}); err != nil {
+		return err
+	}
+
+	return r.CacheEventRepository.DeleteNamespace(ctx)
+}
+
+func (r *WalEventRepository) Ping(ctx context.Context) error {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return r.CacheEventRepository.Ping(ctx)
+}
+
+// Sync сбрасывает журнал на диск
+func (r *WalEventRepository) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sync()
+}
+
+// Snapshot сохраняет состояние кэша в снимок и очищает журнал. Изменения на время снимка блокируются
+func (r *WalEventRepository) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if r.pending == 0 {
+		return nil
+	}
+
+	snapshot := walSnapshot{
+		Seq:         r.seq,
+		CreatedAt:   time.Now().UTC(),
+		Namespaces:  r.CacheEventRepository.snapshotNamespaces(),
+		Attachments: r.attachments.snapshotNamespaces(),
+		Tenants:     r.tenants.snapshotTenants(),
+	}
+
+	if err := writeFileAtomic(filepath.Join(r.dir, snapshotFileName), snapshot); err != nil {
+		return err
+	}
+
+	// Если процесс упадёт до очистки, записи журнала с номерами из снимка будут пропущены при восстановлении
+	if err := r.file.Truncate(0); err != nil {
+		return r.fail(err)
+	}
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return r.fail(err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return r.fail(err)
+	}
+
+	r.pending = 0
+	r.dirty = false
+
+	return nil
+}
+
+// Close делает снимок и закрывает журнал
+func (r *WalEventRepository) Close() error {
+	r.closeOnce.Do(func() {
+		r.closeErr = r.close()
+	})
+
+	return r.closeErr
+}
+
+func (r *WalEventRepository) close() error {
+	close(r.stop)
+	r.wg.Wait()
+
+	snapshotErr := r.Snapshot()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	syncErr := r.sync()
+	if r.err == nil {
+		r.err = errors.New("event log is closed")
+	}
+
+	return errors.Join(snapshotErr, syncErr, r.file.Close())
+}
+
+// Дописывает запись в конец журнала. Вызывается под блокировкой
+func (r *WalEventRepository) append(record walRecord) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	record.Seq = r.seq + 1
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[walHeaderSize:], payload)
+
+	offset, err := r.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	if _, err := r.file.Write(frame); err != nil {
+		// Частично записанная запись обрезается, чтобы следующие не оказались за мусором
+		if truncErr := r.file.Truncate(offset); truncErr != nil {
+			return r.fail(errors.Join(err, truncErr))
+		}
+		return err
+	}
+
+	r.seq = record.Seq
+	r.pending++
+	r.dirty = true
+
+	if r.options.Sync == WalSyncAlways {
+		return r.sync()
+	}
+
+	return nil
+}
+
+// Вызывается под блокировкой
+func (r *WalEventRepository) sync() error {
+	if !r.dirty || r.err != nil {
+		return r.err
+	}
+
+	if err := r.file.Sync(); err != nil {
+		return r.fail(err)
+	}
+
+	r.dirty = false
+
+	return nil
+}
+
+// Запоминает ошибку диска: после неё состояние журнала неизвестно и новые операции отклоняются
+func (r *WalEventRepository) fail(err error) error {
+	r.err = fmt.Errorf("event log is unavailable: %w", err)
+	return r.err
+}
+
+func (r *WalEventRepository) runEvery(interval time.Duration, task func() error) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				// Ошибка запоминается в r.err и видна через Ping и следующие операции
+				_ = task()
+			}
+		}
+	}()
+}
+
+func (r *WalEventRepository) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(r.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snapshot := walSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		// Снимок пишется атомарно, поэтому повреждённый снимок - не последствие падения, а ошибка
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	r.CacheEventRepository.loadNamespaces(snapshot.Namespaces)
+	r.attachments.loadNamespaces(snapshot.Attachments)
+	r.tenants.loadTenants(snapshot.Tenants)
+	r.seq = snapshot.Seq
+	r.recovery.SnapshotSeq = snapshot.Seq
+
+	return nil
+}
+
+// Применяет записи журнала новее снимка и обрезает недописанный хвост
+func (r *WalEventRepository) replay() error {
+	reader := bufio.NewReader(r.file)
+	offset := int64(0)
+
+	for {
+		record, size, err := readWalRecord(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Хвост, записанный не полностью или с ошибкой, отбрасывается вместе со всем, что после него
+			end, seekErr := r.file.Seek(0, io.SeekEnd)
+			if seekErr != nil {
+				return seekErr
+			}
+			if err := r.file.Truncate(offset); err != nil {
+				return err
+			}
+			if err := r.file.Sync(); err != nil {
+				return err
+			}
+			r.recovery.TruncatedBytes = end - offset
+			break
+		}
+
+		offset += size
+
+		if record.Seq <= r.seq {
+			// Запись уже вошла в снимок
+			continue
+		}
+
+		r.apply(record)
+		r.seq = record.Seq
+		r.pending++
+		r.recovery.Replayed++
+	}
+
+	_, err := r.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+// Повторяет операцию над кэшем. Операции, которые завершились ошибкой при записи, так же завершаются ошибкой
+// и при повторе, не меняя состояния, поэтому ошибки игнорируются
+func (r *WalEventRepository) apply(record walRecord) {
+	ctx := domain.WithTenant(context.Background(), record.Tenant)
+	cache := r.CacheEventRepository
+
+	switch record.Op {
+	case walCreate:
+		_, _ = cache.CreateEvent(ctx, record.Event)
+	case walUpdate:
+		_ = cache.UpdateEvent(ctx, record.Event)
+	case walDelete:
+		_ = cache.DeleteEvent(ctx, record.EventID)
+	case walRestore:
+		_ = cache.RestoreEvent(ctx, record.Event)
+	case walDrop:
+		_ = cache.DeleteNamespace(ctx)
+	case walAttach:
+		if record.Attachment != nil {
+			_ = r.attachments.AddAttachment(ctx, *record.Attachment)
+		}
+	case walDetach:
+		_ = r.attachments.DeleteAttachment(ctx, record.EventID, record.AttachmentID)
+	case walDropAttachments:
+		_ = r.attachments.DeleteNamespace(ctx)
+	case walCreateTenant:
+		if record.TenantInfo != nil {
+			_ = r.tenants.CreateTenant(ctx, *record.TenantInfo)
+		}
+	case walDeleteTenant:
+		_ = r.tenants.DeleteTenant(ctx, record.Tenant)
+	}
+}
+
+// Читает одну запись и возвращает её размер в журнале. io.EOF означает конец журнала на границе записи
+func readWalRecord(reader io.Reader) (walRecord, int64, error) {
+	record := walRecord{}
+	header := make([]byte, walHeaderSize)
+
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return record, 0, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size == 0 || size > walMaxRecordSize {
+		return record, 0, fmt.Errorf("invalid record size %d", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		// Заголовок записан, а содержимое нет
+		return record, 0, io.ErrUnexpectedEOF
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return record, 0, errors.New("record checksum mismatch")
+	}
+
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return record, 0, err
+	}
+
+	return record, int64(walHeaderSize + len(payload)), nil
+}
+
+// Записывает value во временный файл и переименовывает его в path, чтобы при падении остался старый или новый файл целиком
+func writeFileAtomic(path string, value any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(value); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Переименование сохраняется на диске только после fsync каталога
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// Состояние всех пространств для снимка
+func (r *CacheEventRepository) snapshotNamespaces() map[string]eventNamespaceSnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]eventNamespaceSnapshot, len(r.namespaces))
+
+	for tenantID, ns := range r.namespaces {
+		events := make([]domain.Event, 0, len(ns.cache))
+		for _, event := range ns.cache {
+			events = append(events, event)
+		}
+
+		snapshot[tenantID] = eventNamespaceSnapshot{NextID: ns.autoIncrement, Events: events}
+	}
+
+	return snapshot
+}
+
+// Заменяет содержимое кэша снимком, сохраняя счётчики ID
+func (r *CacheEventRepository) loadNamespaces(snapshot map[string]eventNamespaceSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.namespaces = make(map[string]*eventNamespace, len(snapshot))
+
+	for tenantID, nsSnapshot := range snapshot {
+		ns := &eventNamespace{cache: make(map[int]domain.Event, r.maxSize), autoIncrement: nsSnapshot.NextID}
+		for _, event := range nsSnapshot.Events {
+			ns.cache[event.ID] = event
+		}
+
+		r.namespaces[tenantID] = ns
+	}
+}
@@ -0,0 +1,294 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func openTestWal(t *testing.T, dir string, maxSize int) *WalEventRepository {
+	t.Helper()
+
+	repository, err := OpenWalEventRepository(NewCacheEventRepository(maxSize), dir, WalOptions{Sync: WalSyncAlways})
+	if err != nil {
+		t.Fatalf("OpenWalEventRepository() error = %v", err)
+	}
+
+	return repository
+}
+
+// Имитирует падение процесса: журнал закрывается без снимка
+func crashWal(t *testing.T, repository *WalEventRepository) {
+	t.Helper()
+
+	if err := repository.file.Close(); err != nil {
+		t.Fatalf("close wal error = %v", err)
+	}
+}
+
+func listTenantEvents(t *testing.T, repository domain.Repository, tenantID string) []domain.Event {
+	t.Helper()
+
+	events, err := repository.ListEvents(domain.WithTenant(context.Background(), tenantID))
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+
+	return events
+}
+
+// Выполняет create/update/delete в двух арендаторах и возвращает ожидаемое содержимое
+func writeWalWorkload(t *testing.T, repository domain.Repository) map[string][]domain.Event {
+	t.Helper()
+
+	date := time.Date(2019, 9, 9, 10, 0, 0, 0, time.UTC)
+	teamA := domain.WithTenant(context.Background(), "team-a")
+	teamB := domain.WithTenant(context.Background(), "team-b")
+
+	for i := 0; i < 3; i++ {
+		if _, err := repository.CreateEvent(teamA, domain.Event{UserID: 1, Date: date.AddDate(0, 0, i), Title: "standup", Tags: []string{"work"}}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+	if _, err := repository.CreateEvent(teamB, domain.Event{UserID: 2, Date: date, Title: "review"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if err := repository.UpdateEvent(teamA, domain.Event{ID: 2, UserID: 1, Date: date, Title: "retro"}); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if err := repository.DeleteEvent(teamA, 1); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	return map[string][]domain.Event{
+		"team-a": {
+			{ID: 2, UserID: 1, Date: date, Title: "retro"},
+			{ID: 3, UserID: 1, Date: date.AddDate(0, 0, 2), Title: "standup", Tags: []string{"work"}},
+		},
+		"team-b": {
+			{ID: 1, UserID: 2, Date: date, Title: "review"},
+		},
+	}
+}
+
+func assertTenantEvents(t *testing.T, repository domain.Repository, expected map[string][]domain.Event) {
+	t.Helper()
+
+	for tenantID, events := range expected {
+		if got := listTenantEvents(t, repository, tenantID); !reflect.DeepEqual(got, events) {
+			t.Errorf("tenant %s events = %+v, expected %+v", tenantID, got, events)
+		}
+	}
+}
+
+func TestWalEventRepositoryRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	repository := openTestWal(t, dir, 10)
+	expected := writeWalWorkload(t, repository)
+	crashWal(t, repository)
+
+	recovered := openTestWal(t, dir, 10)
+	defer recovered.Close()
+
+	assertTenantEvents(t, recovered, expected)
+
+	if recovery := recovered.Recovery(); recovery.Replayed != 6 || recovery.TruncatedBytes != 0 {
+		t.Errorf("Recovery() = %+v, expected 6 replayed records and no truncation", recovery)
+	}
+
+	// Счётчик ID продолжается с того же места
+	id, err := recovered.CreateEvent(domain.WithTenant(context.Background(), "team-a"), domain.Event{UserID: 1})
+	if err != nil || id != 4 {
+		t.Errorf("CreateEvent() after recovery = %d, %v, expected id 4", id, err)
+	}
+}
+
+func TestWalEventRepositoryTruncatedTail(t *testing.T) {
+	dir := t.TempDir()
+
+	repository := openTestWal(t, dir, 10)
+	expected := writeWalWorkload(t, repository)
+
+	full, err := os.Stat(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Последняя запись журнала, которую повредит падение
+	if _, err := repository.CreateEvent(domain.WithTenant(context.Background(), "team-b"), domain.Event{UserID: 2, Title: "lost"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	crashWal(t, repository)
+
+	withTail, err := os.Stat(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastRecord := withTail.Size() - full.Size()
+
+	for _, cut := range []int64{1, lastRecord / 2, lastRecord - walHeaderSize, lastRecord - 1} {
+		t.Run("", func(t *testing.T) {
+			caseDir := t.TempDir()
+			copyWal(t, filepath.Join(dir, walFileName), filepath.Join(caseDir, walFileName), withTail.Size()-cut)
+
+			recovered := openTestWal(t, caseDir, 10)
+
+			assertTenantEvents(t, recovered, expected)
+
+			if recovery := recovered.Recovery(); recovery.Replayed != 6 || recovery.TruncatedBytes != lastRecord-cut {
+				t.Errorf("Recovery() = %+v, expected 6 replayed records and %d truncated bytes", recovery, lastRecord-cut)
+			}
+
+			// После обрезки хвоста новые записи читаются при следующем восстановлении
+			teamB := domain.WithTenant(context.Background(), "team-b")
+			if _, err := recovered.CreateEvent(teamB, domain.Event{UserID: 2, Title: "planning"}); err != nil {
+				t.Fatalf("CreateEvent() error = %v", err)
+			}
+			crashWal(t, recovered)
+
+			reopened := openTestWal(t, caseDir, 10)
+			defer reopened.Close()
+
+			if events := listTenantEvents(t, reopened, "team-b"); len(events) != 2 || events[1].Title != "planning" {
+				t.Errorf("team-b events after reopen = %+v, expected review and planning", events)
+			}
+			if recovery := reopened.Recovery(); recovery.TruncatedBytes != 0 {
+				t.Errorf("Recovery() after reopen = %+v, expected no truncation", recovery)
+			}
+		})
+	}
+}
+
+func TestWalEventRepositoryCorruptedTail(t *testing.T) {
+	dir := t.TempDir()
+
+	repository := openTestWal(t, dir, 10)
+	expected := writeWalWorkload(t, repository)
+	if _, err := repository.CreateEvent(domain.WithTenant(context.Background(), "team-b"), domain.Event{UserID: 2, Title: "corrupted"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	crashWal(t, repository)
+
+	path := filepath.Join(dir, walFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := openTestWal(t, dir, 10)
+	defer recovered.Close()
+
+	assertTenantEvents(t, recovered, expected)
+
+	if recovery := recovered.Recovery(); recovery.TruncatedBytes == 0 {
+		t.Errorf("Recovery() = %+v, expected truncated tail", recovery)
+	}
+}
+
+func TestWalEventRepositorySnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := domain.WithTenant(context.Background(), "team-a")
+
+	// Размер кэша 4: ID выдаются по кругу, и счётчик должен пережить снимок
+	repository := openTestWal(t, dir, 4)
+	for i := 0; i < 5; i++ {
+		if _, err := repository.CreateEvent(ctx, domain.Event{UserID: 1, Title: "before"}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+
+	logBeforeSnapshot, err := os.ReadFile(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repository.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil || info.Size() != 0 {
+		t.Fatalf("wal after snapshot = %v, %v, expected empty file", info, err)
+	}
+
+	if _, err := repository.CreateEvent(ctx, domain.Event{UserID: 1, Title: "after"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if err := repository.DeleteNamespace(domain.WithTenant(context.Background(), "team-b")); err != nil {
+		t.Fatalf("DeleteNamespace() error = %v", err)
+	}
+
+	expected := map[string][]domain.Event{"team-a": listTenantEvents(t, repository, "team-a")}
+	crashWal(t, repository)
+
+	recovered := openTestWal(t, dir, 4)
+	assertTenantEvents(t, recovered, expected)
+
+	if recovery := recovered.Recovery(); recovery.SnapshotSeq != 5 || recovery.Replayed != 2 {
+		t.Errorf("Recovery() = %+v, expected snapshot at 5 and 2 replayed records", recovery)
+	}
+
+	// Кэш заполнен, поэтому новое событие вытесняет событие со следующим по кругу ID
+	if id, err := recovered.CreateEvent(ctx, domain.Event{UserID: 1, Title: "wrapped"}); err != nil || id != 1 {
+		t.Fatalf("CreateEvent() = %d, %v, expected id 1", id, err)
+	}
+	expected["team-a"] = listTenantEvents(t, recovered, "team-a")
+	if err := recovered.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// Повторный Close не паникует и возвращает результат первого
+	if err := recovered.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+
+	// Падение между записью снимка и очисткой журнала: старые записи не должны примениться повторно
+	if err := os.WriteFile(filepath.Join(dir, walFileName), logBeforeSnapshot, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestWal(t, dir, 4)
+	defer reopened.Close()
+
+	assertTenantEvents(t, reopened, expected)
+
+	if recovery := reopened.Recovery(); recovery.Replayed != 0 {
+		t.Errorf("Recovery() = %+v, expected records covered by snapshot to be skipped", recovery)
+	}
+}
+
+func TestParseWalSyncPolicy(t *testing.T) {
+	for _, value := range []string{"always", "interval", "never"} {
+		if policy, err := ParseWalSyncPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("ParseWalSyncPolicy(%q) = %q, %v", value, policy, err)
+		}
+	}
+
+	if _, err := ParseWalSyncPolicy("sometimes"); err == nil {
+		t.Errorf("ParseWalSyncPolicy(sometimes) error = nil, expected validation error")
+	}
+
+	if _, err := OpenWalEventRepository(NewCacheEventRepository(10), t.TempDir(), WalOptions{Sync: WalSyncInterval}); err == nil {
+		t.Errorf("OpenWalEventRepository() without sync interval error = nil, expected validation error")
+	}
+}
+
+func copyWal(t *testing.T, from, to string, size int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(to, data[:size], 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,98 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// WalTenantRepository - арендаторы, создание и удаление которых пишутся в журнал событий.
+// Так после перезапуска события и вложения из журнала остаются доступны своим арендаторам.
+// Арендатор по умолчанию создаётся из настроек при каждом запуске и в журнал не пишется
+type WalTenantRepository struct {
+	*CacheTenantRepository
+
+	wal *WalEventRepository
+}
+
+// Tenants возвращает хранилище арендаторов, которое пишет в этот журнал
+func (r *WalEventRepository) Tenants() *WalTenantRepository {
+	return &WalTenantRepository{CacheTenantRepository: r.tenants, wal: r}
+}
+
+func (r *WalTenantRepository) CreateTenant(ctx context.Context, tenant domain.Tenant) error {
+	if tenant.ID == domain.DefaultTenantID {
+		return r.CacheTenantRepository.CreateTenant(ctx, tenant)
+	}
+
+	r.wal.mu.Lock()
+	defer r.wal.mu.Unlock()
+
+	// Заведомо неудачные операции в журнал не пишутся
+	if _, err := r.CacheTenantRepository.GetTenant(ctx, tenant.ID); err == nil {
+		return fmt.Errorf("%w: tenant %q already exists", domain.ErrConflict, tenant.ID)
+	}
+
+	if err := r.wal.append(walRecord{Op: walCreateTenant, Tenant: tenant.ID, TenantInfo: &tenant}); err != nil {
+		return err
+	}
+
+	return r.CacheTenantRepository.CreateTenant(ctx, tenant)
+}
+
+func (r *WalTenantRepository) DeleteTenant(ctx context.Context, tenantID string) error {
+	if tenantID == domain.DefaultTenantID {
+		return r.CacheTenantRepository.DeleteTenant(ctx, tenantID)
+	}
+
+	r.wal.mu.Lock()
+	defer r.wal.mu.Unlock()
+
+	if _, err := r.CacheTenantRepository.GetTenant(ctx, tenantID); err != nil {
+		return err
+	}
+
+	if err := r.wal.append(walRecord{Op: walDeleteTenant, Tenant: tenantID}); err != nil {
+		return err
+	}
+
+	return r.CacheTenantRepository.DeleteTenant(ctx, tenantID)
+}
+
+// Арендаторы для снимка, кроме арендатора по умолчанию
+func (r *CacheTenantRepository) snapshotTenants() []domain.Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]domain.Tenant, 0, len(r.cache))
+
+	for _, tenant := range r.cache {
+		if tenant.ID != domain.DefaultTenantID {
+			tenants = append(tenants, tenant)
+		}
+	}
+
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+
+	return tenants
+}
+
+// Заменяет арендаторов снимком. Арендатор по умолчанию остаётся из настроек
+func (r *CacheTenantRepository) loadTenants(tenants []domain.Tenant) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tenantID := range r.cache {
+		if tenantID != domain.DefaultTenantID {
+			delete(r.cache, tenantID)
+		}
+	}
+
+	for _, tenant := range tenants {
+		r.cache[tenant.ID] = tenant
+	}
+}
@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func TestWalTenantRepositoryRecovery(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	createdAt := time.Date(2019, 9, 9, 10, 0, 0, 0, time.UTC)
+
+	tenant := func(id string, maxEvents int) domain.Tenant {
+		return domain.Tenant{ID: id, Name: "Team " + id, MaxEvents: maxEvents, Token: id + "-token", CreatedAt: createdAt}
+	}
+
+	repository := openTestWal(t, dir, 10)
+	tenants := repository.Tenants()
+
+	for _, v := range []domain.Tenant{tenant(domain.DefaultTenantID, 0), tenant("team-a", 5), tenant("team-b", 0)} {
+		if err := tenants.CreateTenant(ctx, v); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+	if err := tenants.CreateTenant(ctx, tenant("team-a", 1)); err == nil {
+		t.Fatalf("CreateTenant() of existing tenant succeeded")
+	}
+
+	// Часть операций попадает в снимок, остальные восстанавливаются из журнала
+	if err := repository.Snapshot(); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if err := tenants.CreateTenant(ctx, tenant("team-c", 0)); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if err := tenants.DeleteTenant(ctx, "team-b"); err != nil {
+		t.Fatalf("DeleteTenant() error = %v", err)
+	}
+	if err := tenants.DeleteTenant(ctx, "team-b"); err == nil {
+		t.Fatalf("DeleteTenant() of deleted tenant succeeded")
+	}
+
+	crashWal(t, repository)
+
+	recovered := openTestWal(t, dir, 10)
+	defer recovered.Close()
+
+	// Арендатор по умолчанию не восстанавливается: его создаёт приложение из своих настроек
+	got, err := recovered.Tenants().ListTenants(ctx)
+	if err != nil {
+		t.Fatalf("ListTenants() error = %v", err)
+	}
+	if expected := []domain.Tenant{tenant("team-a", 5), tenant("team-c", 0)}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("tenants = %+v, expected %+v", got, expected)
+	}
+
+	if recovery := recovered.Recovery(); recovery.Replayed != 2 {
+		t.Errorf("Recovery() = %+v, expected 2 replayed records", recovery)
+	}
+}
@@ -2,12 +2,65 @@ package builder
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/adapters"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
 	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/usecase"
 )
 
+// Сколько событий хранит каждый арендатор в памяти. Старые события вытесняются новыми
+const EventCacheSize = 200
+
+// Config - настройки календаря, общие для всех пользователей
+type Config struct {
+	// День, с которого начинается неделя
+	WeekStart time.Weekday
+	// Рабочее время пользователей, которые не задали своё
+	WorkingHours domain.WorkingHours
+	// Хранилище событий. Если не задано, события хранятся только в памяти и теряются при перезапуске
+	EventRepository domain.Repository
+	// Хранилище описаний вложений. Если не задано, описания хранятся только в памяти
+	AttachmentRepository domain.AttachmentRepository
+	// Хранилище арендаторов. Если не задано, арендаторы хранятся только в памяти
+	TenantRepository domain.TenantRepository
+	// Хранилище содержимого вложений. Если не задано, файлы хранятся в каталоге AttachmentsDir
+	BlobStorage    domain.BlobStorage
+	AttachmentsDir string
+	// Ограничения на размер и тип вложений
+	AttachmentLimits domain.AttachmentLimits
+	// Хранилище ответов по заголовку Idempotency-Key. Если не задано, ответы хранятся в памяти IdempotencyTTL
+	IdempotencyStore domain.IdempotencyStore
+	IdempotencyTTL   time.Duration
+	// Токен администратора для управления арендаторами. Пустой токен отключает административные методы
+	AdminToken string
+	// Требовать токен для всех арендаторов, кроме арендатора по умолчанию; иначе достаточно заголовка с ID
+	RequireTenantToken bool
+	// Ограничение на количество событий арендатора по умолчанию, 0 - без ограничения
+	DefaultTenantMaxEvents int
+}
+
+// DefaultConfig - неделя с понедельника, пятидневка с 9:00 до 18:00, вложения во временном каталоге
+// и сутки на повтор запроса с тем же ключом идемпотентности
+func DefaultConfig() Config {
+	return Config{
+		WeekStart:        time.Monday,
+		WorkingHours:     domain.DefaultWorkingHours(),
+		AttachmentsDir:   filepath.Join(os.TempDir(), "calendar-attachments"),
+		AttachmentLimits: domain.DefaultAttachmentLimits(),
+		IdempotencyTTL:   24 * time.Hour,
+	}
+}
+
 type Application struct {
+	WeekStart        time.Weekday
+	AttachmentLimits domain.AttachmentLimits
+	// Ответы на создание и изменение событий по ключам идемпотентности
+	IdempotencyStore domain.IdempotencyStore
+	AdminToken       string
+
 	CreateEvent       *usecase.CreateEventUseCase
 	UpdateEvent       *usecase.UpdateEventUseCase
 	DeleteEvent       *usecase.DeleteEventUseCase
@@ -15,18 +68,86 @@ type Application struct {
 	GetEventsForDay   *usecase.GetEventsForDayUseCase
 	GetEventsForWeek  *usecase.GetEventsForWeekUseCase
 	GetEventsForMonth *usecase.GetEventsForMonthUseCase
+	ListEvents        *usecase.ListEventsUseCase
+	RestoreEvent      *usecase.RestoreEventUseCase
+	CheckHealth       *usecase.CheckHealthUseCase
+	GetWorkingHours   *usecase.GetWorkingHoursUseCase
+	SetWorkingHours   *usecase.SetWorkingHoursUseCase
+	GetAvailability   *usecase.GetAvailabilityUseCase
+	ImportHolidays    *usecase.ImportHolidaysUseCase
+	UploadAttachment  *usecase.UploadAttachmentUseCase
+	ListAttachments   *usecase.ListAttachmentsUseCase
+	OpenAttachment    *usecase.OpenAttachmentUseCase
+	ResolveTenant     *usecase.ResolveTenantUseCase
+	CreateTenant      *usecase.CreateTenantUseCase
+	ListTenants       *usecase.ListTenantsUseCase
+	DeleteTenant      *usecase.DeleteTenantUseCase
 }
 
 func NewApplication(ctx context.Context) *Application {
-	eventRepository := adapters.NewCacheEventRepository(200)
+	return NewApplicationWithConfig(ctx, DefaultConfig())
+}
+
+func NewApplicationWithConfig(ctx context.Context, config Config) *Application {
+	eventRepository := config.EventRepository
+	if eventRepository == nil {
+		eventRepository = adapters.NewCacheEventRepository(EventCacheSize)
+	}
+
+	scheduleRepository := adapters.NewCacheScheduleRepository(config.WorkingHours)
+	attachmentRepository := config.AttachmentRepository
+	if attachmentRepository == nil {
+		attachmentRepository = adapters.NewCacheAttachmentRepository()
+	}
+	tenantRepository := config.TenantRepository
+	if tenantRepository == nil {
+		tenantRepository = adapters.NewCacheTenantRepository()
+	}
+
+	// Арендатор по умолчанию обслуживает запросы без арендатора и существует всегда
+	_ = tenantRepository.CreateTenant(ctx, domain.Tenant{
+		ID:        domain.DefaultTenantID,
+		Name:      "Default",
+		MaxEvents: config.DefaultTenantMaxEvents,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	blobStorage := config.BlobStorage
+	if blobStorage == nil {
+		blobStorage = adapters.NewLocalBlobStorage(config.AttachmentsDir)
+	}
+
+	idempotencyStore := config.IdempotencyStore
+	if idempotencyStore == nil {
+		idempotencyStore = adapters.NewCacheIdempotencyStore(config.IdempotencyTTL)
+	}
 
 	return &Application{
-		CreateEvent:       usecase.NewCreateEventUseCase(eventRepository),
+		WeekStart:        config.WeekStart,
+		AttachmentLimits: config.AttachmentLimits,
+		IdempotencyStore: idempotencyStore,
+		AdminToken:       config.AdminToken,
+
+		CreateEvent:       usecase.NewCreateEventUseCase(eventRepository, tenantRepository, attachmentRepository, blobStorage),
 		UpdateEvent:       usecase.NewUpdateEventUseCase(eventRepository),
-		DeleteEvent:       usecase.NewDeleteEventUseCase(eventRepository),
+		DeleteEvent:       usecase.NewDeleteEventUseCase(eventRepository, attachmentRepository, blobStorage),
 		GetEventByID:      usecase.NewGetEventByIDUseCase(eventRepository),
-		GetEventsForDay:   usecase.NewGetEventsForDayUseCase(eventRepository),
-		GetEventsForWeek:  usecase.NewGetEventsForWeekUseCase(eventRepository),
-		GetEventsForMonth: usecase.NewGetEventsForMonthUseCase(eventRepository),
+		GetEventsForDay:   usecase.NewGetEventsForDayUseCase(eventRepository, scheduleRepository),
+		GetEventsForWeek:  usecase.NewGetEventsForWeekUseCase(eventRepository, scheduleRepository, config.WeekStart),
+		GetEventsForMonth: usecase.NewGetEventsForMonthUseCase(eventRepository, scheduleRepository),
+		ListEvents:        usecase.NewListEventsUseCase(eventRepository),
+		RestoreEvent:      usecase.NewRestoreEventUseCase(eventRepository),
+		CheckHealth:       usecase.NewCheckHealthUseCase(eventRepository),
+		GetWorkingHours:   usecase.NewGetWorkingHoursUseCase(scheduleRepository),
+		SetWorkingHours:   usecase.NewSetWorkingHoursUseCase(scheduleRepository),
+		GetAvailability:   usecase.NewGetAvailabilityUseCase(eventRepository, scheduleRepository),
+		ImportHolidays:    usecase.NewImportHolidaysUseCase(scheduleRepository),
+		UploadAttachment:  usecase.NewUploadAttachmentUseCase(eventRepository, attachmentRepository, blobStorage, config.AttachmentLimits),
+		ListAttachments:   usecase.NewListAttachmentsUseCase(eventRepository, attachmentRepository),
+		OpenAttachment:    usecase.NewOpenAttachmentUseCase(attachmentRepository, blobStorage),
+		ResolveTenant:     usecase.NewResolveTenantUseCase(tenantRepository, config.RequireTenantToken),
+		CreateTenant:      usecase.NewCreateTenantUseCase(tenantRepository),
+		ListTenants:       usecase.NewListTenantsUseCase(tenantRepository),
+		DeleteTenant:      usecase.NewDeleteTenantUseCase(tenantRepository, eventRepository, attachmentRepository, scheduleRepository, blobStorage),
 	}
 }
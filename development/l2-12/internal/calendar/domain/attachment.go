@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attachment - файл, прикреплённый к событию. Содержимое хранится в BlobStorage под ключом BlobKey
+type Attachment struct {
+	ID string
+	// Арендатор события. Вложения разных арендаторов хранятся в разных каталогах
+	TenantID    string
+	EventID     int
+	Name        string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// BlobKey возвращает ключ содержимого вложения в хранилище файлов
+func (a Attachment) BlobKey() string {
+	return a.TenantID + "/" + strconv.Itoa(a.EventID) + "/" + a.ID
+}
+
+// AttachmentLimits - ограничения на загружаемые файлы
+type AttachmentLimits struct {
+	// Максимальный размер одного файла в байтах
+	MaxSize int64
+	// Допустимые MIME типы. Запись вида "image/*" разрешает все подтипы
+	AllowedTypes []string
+}
+
+// DefaultAttachmentLimits - до 10 МБ, документы, презентации, текст и изображения
+func DefaultAttachmentLimits() AttachmentLimits {
+	return AttachmentLimits{
+		MaxSize: 10 << 20,
+		AllowedTypes: []string{
+			"application/pdf",
+			"application/msword",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.ms-powerpoint",
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+			"application/vnd.oasis.opendocument.text",
+			"application/vnd.oasis.opendocument.presentation",
+			"text/plain",
+			"text/markdown",
+			"text/csv",
+			"image/*",
+		},
+	}
+}
+
+// Allows сообщает, можно ли загрузить файл с типом contentType
+func (l AttachmentLimits) Allows(contentType string) bool {
+	// Параметры вроде "; charset=utf-8" не влияют на тип
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+
+	for _, allowed := range l.AllowedTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(contentType, prefix) {
+				return true
+			}
+		} else if contentType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BlobStorage хранит содержимое файлов по ключу
+type BlobStorage interface {
+	// Put сохраняет содержимое r под ключом key и возвращает количество записанных байт
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет содержимое. Удаление отсутствующего ключа не считается ошибкой
+	Delete(ctx context.Context, key string) error
+}
+
+// AttachmentRepository хранит описания вложений событий в пространстве арендатора из контекста
+type AttachmentRepository interface {
+	AddAttachment(ctx context.Context, attachment Attachment) error
+	GetAttachment(ctx context.Context, eventID int, attachmentID string) (Attachment, error)
+	// ListAttachments возвращает вложения события в порядке загрузки
+	ListAttachments(ctx context.Context, eventID int) ([]Attachment, error)
+	DeleteAttachment(ctx context.Context, eventID int, attachmentID string) error
+	// DeleteNamespace удаляет описания всех вложений арендатора из контекста
+	DeleteNamespace(ctx context.Context) error
+}
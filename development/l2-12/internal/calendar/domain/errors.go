@@ -0,0 +1,18 @@
+package domain
+
+import "errors"
+
+// Ошибки доменной области. Хранилища и сценарии оборачивают их через fmt.Errorf("%w: ...")
+// и проверяются через errors.Is, а транспорты переводят их в свои коды ответа
+var (
+	// Событие или другой объект не найден
+	ErrNotFound = errors.New("not found")
+	// Операция противоречит текущему состоянию хранилища
+	ErrConflict = errors.New("conflict")
+	// Данные не проходят проверку бизнес-правил
+	ErrValidation = errors.New("validation failed")
+	// Операция запрещена для текущего пользователя
+	ErrForbidden = errors.New("forbidden")
+	// Данные превышают допустимый размер
+	ErrTooLarge = errors.New("too large")
+)
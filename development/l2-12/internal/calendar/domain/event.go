@@ -1,10 +1,60 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type Event struct {
 	ID          int
 	UserID      int
 	Date        time.Time
 	Description string
+	Title       string
+	Location    string
+	Category    string
+	Tags        []string
+	Color       string
+	// События только для чтения (праздники) не хранятся в репозитории и не изменяются через API
+	ReadOnly bool
+}
+
+// HasTag сообщает, отмечено ли событие тегом tag
+func (e Event) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
+}
+
+// EventFilter - отбор событий в списках за день, неделю и месяц. Пустые поля не ограничивают выборку
+type EventFilter struct {
+	Tag      string
+	Category string
+}
+
+func (f EventFilter) Match(event Event) bool {
+	if f.Category != "" && event.Category != f.Category {
+		return false
+	}
+
+	if f.Tag != "" && !event.HasTag(f.Tag) {
+		return false
+	}
+
+	return true
+}
+
+// Apply оставляет в списке только подходящие под фильтр события
+func (f EventFilter) Apply(events []Event) []Event {
+	if f == (EventFilter{}) {
+		return events
+	}
+
+	filtered := make([]Event, 0, len(events))
+
+	for _, event := range events {
+		if f.Match(event) {
+			filtered = append(filtered, event)
+		}
+	}
+
+	return filtered
 }
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotentResponse - первый ответ на запрос с ключом идемпотентности, который повторяется клиенту при ретраях
+type IdempotentResponse struct {
+	// Отпечаток запроса (метод, путь и тело). Повтор с тем же ключом и другим телом отклоняется
+	Fingerprint string
+	StatusCode  int
+	// Заголовки ответа: имя в каноническом виде и его значения
+	Header    map[string][]string
+	Body      []byte
+	CreatedAt time.Time
+}
+
+// IdempotencyStore хранит ответы по ключам идемпотентности в течение заданного окна
+type IdempotencyStore interface {
+	// Reserve занимает ключ за запросом с отпечатком fingerprint.
+	// Если ключ уже завершён, возвращает сохранённый ответ и true.
+	// Если запрос с этим ключом ещё выполняется, возвращает ErrConflict
+	Reserve(ctx context.Context, key, fingerprint string) (IdempotentResponse, bool, error)
+	// Complete сохраняет ответ для занятого ключа
+	Complete(ctx context.Context, key string, response IdempotentResponse) error
+	// Release освобождает ключ без ответа, чтобы запрос можно было повторить
+	Release(ctx context.Context, key string) error
+}
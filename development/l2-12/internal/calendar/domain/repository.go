@@ -5,12 +5,29 @@ import (
 	"time"
 )
 
+// Repository хранит события. Каждый арендатор (TenantFromContext) работает в своём пространстве
+// с собственной нумерацией ID
 type Repository interface {
 	CreateEvent(ctx context.Context, event Event) (int, error)
+	// CreateEventWithLimit создаёт событие, только если у арендатора меньше limit событий, иначе возвращает
+	// ErrConflict. 0 - без ограничения. Ограничение проверяется под той же блокировкой, что и создание
+	CreateEventWithLimit(ctx context.Context, event Event, limit int) (int, error)
 	UpdateEvent(ctx context.Context, event Event) error
 	DeleteEvent(ctx context.Context, eventID int) error
 	GetEventByID(ctx context.Context, eventID int) (Event, error)
 	GetEventsForDay(ctx context.Context, date time.Time) ([]Event, error)
 	GetEventsForWeek(ctx context.Context, date time.Time) ([]Event, error)
 	GetEventsForMonth(ctx context.Context, date time.Time) ([]Event, error)
+	// GetEventsForPeriod возвращает события в диапазоне [from, to)
+	GetEventsForPeriod(ctx context.Context, from, to time.Time) ([]Event, error)
+	// ListEvents возвращает все события хранилища, упорядоченные по ID
+	ListEvents(ctx context.Context) ([]Event, error)
+	// RestoreEvent сохраняет событие с уже назначенным ID (используется при импорте)
+	RestoreEvent(ctx context.Context, event Event) error
+	// CountEvents возвращает количество событий арендатора
+	CountEvents(ctx context.Context) (int, error)
+	// DeleteNamespace удаляет все события арендатора из контекста
+	DeleteNamespace(ctx context.Context) error
+	// Ping проверяет, что хранилище доступно и готово обслуживать запросы
+	Ping(ctx context.Context) error
 }
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Категория, под которой праздники показываются в списках событий
+const HolidayCategory = "holiday"
+
+// DayKind - вид особого дня производственного календаря
+type DayKind int
+
+const (
+	// Праздничный или перенесённый выходной
+	DayOff DayKind = iota + 1
+	// Предпраздничный день, сокращённый на час
+	ShortDay
+	// Выходной, перенесённый в рабочий день
+	WorkingDay
+)
+
+// Holiday - особый день производственного календаря
+type Holiday struct {
+	Date time.Time
+	Name string
+	Kind DayKind
+}
+
+// Event представляет выходной день как событие только для чтения
+func (h Holiday) Event() Event {
+	return Event{
+		Date:     h.Date,
+		Title:    h.Name,
+		Category: HolidayCategory,
+		ReadOnly: true,
+	}
+}
+
+// WorkingHours - рабочее время пользователя: начало и конец дня как смещения от полуночи и рабочие дни недели
+type WorkingHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Weekdays []time.Weekday
+}
+
+// DefaultWorkingHours - пятидневка с 9:00 до 18:00
+func DefaultWorkingHours() WorkingHours {
+	return WorkingHours{
+		Start:    9 * time.Hour,
+		End:      18 * time.Hour,
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+}
+
+func (h WorkingHours) Validate() error {
+	if h.Start < 0 || h.End > 24*time.Hour || h.Start >= h.End {
+		return fmt.Errorf("%w: working hours must be within a day and start before end", ErrValidation)
+	}
+
+	for _, weekday := range h.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return fmt.Errorf("%w: invalid weekday %d", ErrValidation, weekday)
+		}
+	}
+
+	return nil
+}
+
+// Availability - рабочее время пользователя в конкретный день
+type Availability struct {
+	Date    time.Time
+	Working bool
+	// Границы рабочего времени, нулевые для нерабочего дня
+	Start time.Time
+	End   time.Time
+	// Название праздника или особого дня из производственного календаря
+	Holiday string
+	// Количество событий пользователя в этот день
+	Events int
+}
+
+// DayAvailability считает рабочее время в день date с учётом производственного календаря.
+// holiday может быть nil, если день обычный
+func DayAvailability(date time.Time, hours WorkingHours, holiday *Holiday) Availability {
+	day := StartOfDay(date)
+	availability := Availability{Date: day}
+
+	working := slices.Contains(hours.Weekdays, day.Weekday())
+	end := hours.End
+
+	if holiday != nil {
+		availability.Holiday = holiday.Name
+
+		switch holiday.Kind {
+		case DayOff:
+			working = false
+		case WorkingDay:
+			working = true
+		case ShortDay:
+			end -= time.Hour
+		}
+	}
+
+	if working && end > hours.Start {
+		availability.Working = true
+		availability.Start = day.Add(hours.Start)
+		availability.End = day.Add(end)
+	}
+
+	return availability
+}
+
+// StartOfDay возвращает полночь дня date
+func StartOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+// WeekRange возвращает границы [from, to) недели, в которую входит date, если неделя начинается с weekStart
+func WeekRange(date time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+	from := StartOfDay(date).AddDate(0, 0, -offset)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// MonthRange возвращает границы [from, to) месяца, в который входит date
+func MonthRange(date time.Time) (time.Time, time.Time) {
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 1, 0)
+}
+
+// ScheduleRepository хранит рабочее время пользователей и производственный календарь
+type ScheduleRepository interface {
+	// GetWorkingHours возвращает рабочее время пользователя арендатора из контекста или рабочее время по умолчанию
+	GetWorkingHours(ctx context.Context, userID int) (WorkingHours, error)
+	SetWorkingHours(ctx context.Context, userID int, hours WorkingHours) error
+	// DeleteNamespace удаляет рабочее время пользователей арендатора из контекста. Праздники общие для всех арендаторов
+	DeleteNamespace(ctx context.Context) error
+	// GetHolidays возвращает особые дни в диапазоне [from, to), упорядоченные по дате
+	GetHolidays(ctx context.Context, from, to time.Time) ([]Holiday, error)
+	// AddHolidays добавляет особые дни, заменяя уже известные на те же даты
+	AddHolidays(ctx context.Context, holidays []Holiday) error
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultTenantID - арендатор запросов, в которых арендатор не указан. Его нельзя удалить
+const DefaultTenantID = "default"
+
+// Tenant - команда со своим пространством событий, вложений и рабочего времени
+type Tenant struct {
+	ID   string
+	Name string
+	// Максимальное количество событий арендатора, 0 - без ограничения
+	MaxEvents int
+	// Токен доступа, по которому запрос относится к арендатору
+	Token     string
+	CreatedAt time.Time
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Validate проверяет ID арендатора и ограничения
+func (t Tenant) Validate() error {
+	if !tenantIDPattern.MatchString(t.ID) {
+		return fmt.Errorf("%w: tenant id %q must contain only lowercase letters, digits and dashes", ErrValidation, t.ID)
+	}
+
+	if t.MaxEvents < 0 {
+		return fmt.Errorf("%w: max events must not be negative", ErrValidation)
+	}
+
+	return nil
+}
+
+type tenantContextKey struct{}
+
+// WithTenant возвращает контекст запроса арендатора tenantID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext возвращает арендатора запроса. Без арендатора в контексте используется DefaultTenantID
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+
+	return DefaultTenantID
+}
+
+// TenantRepository хранит арендаторов
+type TenantRepository interface {
+	CreateTenant(ctx context.Context, tenant Tenant) error
+	GetTenant(ctx context.Context, tenantID string) (Tenant, error)
+	GetTenantByToken(ctx context.Context, token string) (Tenant, error)
+	// ListTenants возвращает арендаторов, упорядоченных по ID
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	DeleteTenant(ctx context.Context, tenantID string) error
+}
@@ -0,0 +1,205 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Сколько файлов можно загрузить одним запросом
+const maxAttachmentsPerRequest = 10
+
+type jsonAttachment struct {
+	ID          string    `json:"attachment_id"`
+	EventID     int       `json:"event_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newJsonAttachment(attachment domain.Attachment) jsonAttachment {
+	return jsonAttachment{
+		ID:          attachment.ID,
+		EventID:     attachment.EventID,
+		Name:        attachment.Name,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+// UploadAttachment принимает multipart/form-data с полями event_id и file (можно несколько).
+// event_id передаётся в query или отдельным полем перед файлами
+func (h HttpCalendarHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodPost {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	// Тело не должно быть больше, чем все файлы запроса по лимиту, с запасом на заголовки частей
+	maxBody := maxAttachmentsPerRequest*(h.app.AttachmentLimits.MaxSize+1) + 1<<20
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		// Если тело не multipart, возвращаем HTTP 400
+		h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	eventID := 0
+	if value := r.URL.Query().Get("event_id"); value != "" {
+		if eventID, err = strconv.Atoi(value); err != nil {
+			h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+	}
+
+	uploaded := make([]jsonAttachment, 0, 1)
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Повреждённое multipart тело - ошибка входных данных
+			h.mapUploadError(w, fmt.Errorf("%w: %w", errInvalidInput, err))
+			return
+		}
+
+		switch {
+		case part.FileName() == "" && part.FormName() == "event_id":
+			value, err := io.ReadAll(io.LimitReader(part, 32))
+			if err == nil {
+				eventID, err = strconv.Atoi(string(value))
+			}
+			if err != nil {
+				h.mapToResponse(w, http.StatusBadRequest, nil, "invalid event_id")
+				return
+			}
+		case part.FileName() != "" && part.FormName() == "file":
+			if len(uploaded) == maxAttachmentsPerRequest {
+				h.mapErrorToResponse(w, fmt.Errorf("%w: at most %d files per request", domain.ErrTooLarge, maxAttachmentsPerRequest))
+				return
+			}
+
+			attachment, err := h.service.uploadAttachment(r.Context(), eventID, part.FileName(), attachmentContentType(part), part)
+			if err != nil {
+				// Уже загруженные файлы запроса остаются прикреплёнными
+				h.mapUploadError(w, err)
+				return
+			}
+
+			uploaded = append(uploaded, newJsonAttachment(attachment))
+		}
+
+		part.Close()
+	}
+
+	if len(uploaded) == 0 {
+		h.mapToResponse(w, http.StatusBadRequest, nil, "file is required")
+		return
+	}
+
+	h.mapToResponse(w, http.StatusOK, uploaded, "")
+}
+
+// ListAttachments возвращает вложения события: ?event_id=1
+func (h HttpCalendarHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.URL.Query().Get("event_id"))
+	if err != nil {
+		// Если ошибка валидации входных данных, возвращаем HTTP 400
+		h.mapToResponse(w, http.StatusBadRequest, nil, "invalid event_id")
+		return
+	}
+
+	attachments, err := h.service.attachments(r.Context(), eventID)
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	result := make([]jsonAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		result = append(result, newJsonAttachment(attachment))
+	}
+
+	h.mapToResponse(w, http.StatusOK, result, "")
+}
+
+// DownloadAttachment отдаёт содержимое вложения: ?event_id=1&attachment_id=...
+func (h HttpCalendarHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.URL.Query().Get("event_id"))
+	if err != nil {
+		// Если ошибка валидации входных данных, возвращаем HTTP 400
+		h.mapToResponse(w, http.StatusBadRequest, nil, "invalid event_id")
+		return
+	}
+
+	attachment, content, err := h.service.openAttachment(r.Context(), eventID, r.URL.Query().Get("attachment_id"))
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+	defer content.Close()
+
+	// Файлы пользователей всегда скачиваются, а не открываются в браузере
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("download attachment %s: %v\n", attachment.ID, err)
+	}
+}
+
+// Превышение лимита тела запроса возвращается как HTTP 413, остальные ошибки - по их типу
+func (h HttpCalendarHandler) mapUploadError(w http.ResponseWriter, err error) {
+	maxBytesErr := &http.MaxBytesError{}
+	if errors.As(err, &maxBytesErr) {
+		err = fmt.Errorf("%w: request body exceeds %d bytes", domain.ErrTooLarge, maxBytesErr.Limit)
+	}
+
+	h.mapErrorToResponse(w, err)
+}
+
+// MIME тип файла: из заголовка части, а если он не указан - по расширению имени
+func attachmentContentType(part *multipart.Part) string {
+	contentType := part.Header.Get("Content-Type")
+	if contentType != "" && contentType != "application/octet-stream" {
+		return contentType
+	}
+
+	if byExtension := mime.TypeByExtension(filepath.Ext(part.FileName())); byExtension != "" {
+		return byExtension
+	}
+
+	return "application/octet-stream"
+}
@@ -0,0 +1,110 @@
+package ports
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type contextKey int
+
+const userIDContextKey contextKey = iota
+
+// Префикс Common Name клиентского сертификата, за которым следует ID пользователя
+const clientCertUserPrefix = "user-"
+
+// UserIDFromContext возвращает ID пользователя, подтверждённый клиентским сертификатом
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDContextKey).(int)
+	return userID, ok
+}
+
+// UserIDFromCertificate извлекает ID пользователя из Common Name сертификата.
+// Допустимые форматы CN: "user-42" и "42"
+func UserIDFromCertificate(cert *x509.Certificate) (int, error) {
+	commonName := strings.TrimPrefix(cert.Subject.CommonName, clientCertUserPrefix)
+
+	userID, err := strconv.Atoi(commonName)
+	if err != nil || userID <= 0 {
+		return 0, fmt.Errorf("client certificate %q is not mapped to a user", cert.Subject.CommonName)
+	}
+
+	return userID, nil
+}
+
+// ClientCertCommonName возвращает CN клиентского сертификата для пользователя userID
+func ClientCertCommonName(userID int) string {
+	return clientCertUserPrefix + strconv.Itoa(userID)
+}
+
+func (h HttpCalendarHandler) MiddlewareClientCert(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Без TLS или без клиентского сертификата запрос обрабатывается анонимно
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			next(w, r)
+			return
+		}
+
+		userID, err := UserIDFromCertificate(r.TLS.PeerCertificates[0])
+		if err != nil {
+			// Если сертификат не сопоставлен пользователю, возвращаем HTTP 403
+			h.mapToResponse(w, http.StatusForbidden, nil, err.Error())
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userIDContextKey, userID)))
+	}
+}
+
+// Проверяет, что аутентифицированный пользователь работает только со своими событиями.
+// Если user_id не передан, он берётся из сертификата
+func authorizeEvent(ctx context.Context, event *domain.Event) bool {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	if event.UserID == 0 {
+		event.UserID = userID
+	}
+
+	return event.UserID == userID
+}
+
+// Проверяет, что аутентифицированный пользователь запрашивает свои данные.
+// Если user_id не передан, он берётся из сертификата
+func authorizeUser(ctx context.Context, userID *int) bool {
+	certUserID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	if *userID == 0 {
+		*userID = certUserID
+	}
+
+	return *userID == certUserID
+}
+
+// Оставляет в списке только события аутентифицированного пользователя и общие события (праздники)
+func ownEvents(ctx context.Context, events []domain.Event) []domain.Event {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return events
+	}
+
+	own := make([]domain.Event, 0, len(events))
+
+	for _, event := range events {
+		if event.UserID == userID || event.ReadOnly {
+			own = append(own, event)
+		}
+	}
+
+	return own
+}
@@ -0,0 +1,67 @@
+package ports
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Стабильные машиночитаемые коды ошибок, которые возвращаются в поле "code" рядом с "error"
+const (
+	ErrorCodeBadRequest          = "bad_request"
+	ErrorCodeValidation          = "validation_failed"
+	ErrorCodeNotFound            = "not_found"
+	ErrorCodeConflict            = "conflict"
+	ErrorCodeForbidden           = "forbidden"
+	ErrorCodeTooLarge            = "too_large"
+	ErrorCodeIdempotencyMismatch = "idempotency_mismatch"
+	ErrorCodeMethodNotAllowed    = "method_not_allowed"
+	ErrorCodeUnavailable         = "unavailable"
+	ErrorCodeInternal            = "internal"
+)
+
+// Переводит ошибку бизнес-логики в HTTP код и код ошибки.
+// Ошибки без доменного типа (например, сбой хранилища) считаются ошибками бизнес-логики и дают HTTP 503
+func mapError(err error) (int, string) {
+	switch {
+	case errors.Is(err, errInvalidInput):
+		return http.StatusBadRequest, ErrorCodeBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, ErrorCodeNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, ErrorCodeConflict
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest, ErrorCodeValidation
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, ErrorCodeForbidden
+	case errors.Is(err, domain.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, ErrorCodeTooLarge
+	default:
+		return http.StatusServiceUnavailable, ErrorCodeUnavailable
+	}
+}
+
+// Код ошибки по умолчанию для ответов, сформированных без доменной ошибки
+func errorCodeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrorCodeBadRequest
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusConflict:
+		return ErrorCodeConflict
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return ErrorCodeIdempotencyMismatch
+	case http.StatusMethodNotAllowed:
+		return ErrorCodeMethodNotAllowed
+	case http.StatusServiceUnavailable:
+		return ErrorCodeUnavailable
+	default:
+		return ErrorCodeInternal
+	}
+}
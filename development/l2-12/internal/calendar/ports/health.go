@@ -0,0 +1,63 @@
+package ports
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Сколько проверка хранилища может ждать, прежде чем инстанс будет признан неисправным
+const healthCheckTimeout = 2 * time.Second
+
+// SetReady переключает ответ /readyz. Перед остановкой сервера готовность снимается,
+// чтобы балансировщик успел вывести инстанс из ротации
+func (h HttpCalendarHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Healthz сообщает, жив ли процесс и доступно ли хранилище событий
+func (h HttpCalendarHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	if err := h.checkHealth(r.Context()); err != nil {
+		// Ошибка бизнес-логики переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	h.mapToResponse(w, http.StatusOK, "ok", "")
+}
+
+// Readyz сообщает, готов ли сервер принимать новые запросы
+func (h HttpCalendarHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	if !h.ready.Load() {
+		// Если сервер останавливается, возвращаем HTTP 503
+		h.mapToResponse(w, http.StatusServiceUnavailable, nil, "server is shutting down")
+		return
+	}
+
+	if err := h.checkHealth(r.Context()); err != nil {
+		// Ошибка бизнес-логики переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	h.mapToResponse(w, http.StatusOK, "ready", "")
+}
+
+func (h HttpCalendarHandler) checkHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	return h.app.CheckHealth.Execute(ctx)
+}
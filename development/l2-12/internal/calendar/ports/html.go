@@ -0,0 +1,456 @@
+package ports
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+//go:embed templates/*.html
+var htmlTemplatesFS embed.FS
+
+// Cookie с идентификатором сессии, к которому привязан CSRF токен форм
+const csrfCookieName = "calendar_csrf"
+
+// Ошибка проверки CSRF токена
+var errCSRF = errors.New("invalid or missing CSRF token")
+
+// День в сетке месяца или недели
+type htmlDay struct {
+	Date     time.Time
+	InPeriod bool
+	Today    bool
+	// В этот день праздничный выходной
+	Holiday bool
+	Events  []domain.Event
+}
+
+// Данные для шаблонов страниц
+type htmlPage struct {
+	Title     string
+	Date      time.Time
+	Prev      time.Time
+	Next      time.Time
+	Weeks     [][]htmlDay
+	Weekdays  []string
+	Events    []domain.Event
+	Event     domain.Event
+	Filter    domain.EventFilter
+	Action    string
+	CSRFToken string
+	Error     string
+}
+
+// HtmlCalendarHandler - HTML страницы календаря поверх тех же операций, что и JSON API
+type HtmlCalendarHandler struct {
+	service   calendarService
+	templates map[string]*template.Template
+	weekStart time.Weekday
+	// Ключ для подписи CSRF токенов, живёт в пределах процесса
+	csrfKey []byte
+}
+
+func NewHtmlCalendarHandler(app *builder.Application) HtmlCalendarHandler {
+	csrfKey := make([]byte, 32)
+	if _, err := rand.Read(csrfKey); err != nil {
+		panic(err)
+	}
+
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return t.Format(dateLayout) },
+		"join": strings.Join,
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, page := range []string{"month", "week", "day", "form"} {
+		templates[page] = template.Must(template.New(page).Funcs(funcs).ParseFS(htmlTemplatesFS, "templates/layout.html", "templates/"+page+".html"))
+	}
+
+	return HtmlCalendarHandler{
+		service:   newCalendarService(app),
+		templates: templates,
+		weekStart: app.WeekStart,
+		csrfKey:   csrfKey,
+	}
+}
+
+// Index перенаправляет на месяц с текущей датой
+func (h HtmlCalendarHandler) Index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ui/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, "/ui/month", http.StatusSeeOther)
+}
+
+func (h HtmlCalendarHandler) Month(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	first := time.Date(page.Date.Year(), page.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	page.Title = monthNames[first.Month()-1] + " " + strconv.Itoa(first.Year())
+	page.Prev = first.AddDate(0, -1, 0)
+	page.Next = first.AddDate(0, 1, 0)
+
+	events, err := h.service.eventsFor(r.Context(), periodMonth, first, page.Filter)
+	if err != nil {
+		h.renderError(w, page, err)
+		return
+	}
+
+	// Сетка начинается с первого дня недели, в которую входит первое число
+	start, _ := domain.WeekRange(first, h.weekStart)
+	for i := 0; i < 7; i++ {
+		page.Weekdays = append(page.Weekdays, weekdayNames[start.AddDate(0, 0, i).Weekday()])
+	}
+
+	for day := start; day.Before(page.Next) || day.Weekday() != h.weekStart; day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == h.weekStart {
+			page.Weeks = append(page.Weeks, make([]htmlDay, 0, 7))
+		}
+
+		cell := newHtmlDay(day, day.Month() == first.Month(), events)
+		page.Weeks[len(page.Weeks)-1] = append(page.Weeks[len(page.Weeks)-1], cell)
+	}
+
+	h.render(w, "month", http.StatusOK, page)
+}
+
+func (h HtmlCalendarHandler) Week(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	start, _ := domain.WeekRange(page.Date, h.weekStart)
+	page.Title = fmt.Sprintf("Неделя %s — %s", start.Format("02.01.2006"), start.AddDate(0, 0, 6).Format("02.01.2006"))
+	page.Prev = page.Date.AddDate(0, 0, -7)
+	page.Next = page.Date.AddDate(0, 0, 7)
+
+	events, err := h.service.eventsFor(r.Context(), periodWeek, page.Date, page.Filter)
+	if err != nil {
+		h.renderError(w, page, err)
+		return
+	}
+
+	week := make([]htmlDay, 0, 7)
+	for i := 0; i < 7; i++ {
+		week = append(week, newHtmlDay(start.AddDate(0, 0, i), true, events))
+	}
+	page.Weeks = [][]htmlDay{week}
+
+	h.render(w, "week", http.StatusOK, page)
+}
+
+func (h HtmlCalendarHandler) Day(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	page.Title = page.Date.Format("02.01.2006")
+	page.Prev = page.Date.AddDate(0, 0, -1)
+	page.Next = page.Date.AddDate(0, 0, 1)
+
+	events, err := h.service.eventsFor(r.Context(), periodDay, page.Date, page.Filter)
+	if err != nil {
+		h.renderError(w, page, err)
+		return
+	}
+	page.Events = events
+
+	h.render(w, "day", http.StatusOK, page)
+}
+
+// NewEvent показывает форму создания (GET) и создаёт событие (POST)
+func (h HtmlCalendarHandler) NewEvent(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	page.Title = "Новое событие"
+	page.Action = "/ui/events/new"
+
+	if r.Method == http.MethodGet {
+		if userID, ok := UserIDFromContext(r.Context()); ok {
+			page.Event.UserID = userID
+		}
+		h.render(w, "form", http.StatusOK, page)
+		return
+	}
+
+	event, err := h.parseEventForm(r)
+	// При ошибке форма показывается повторно с введёнными значениями, а не с пустым событием из ответа сервиса
+	page.Event = event
+	if err == nil {
+		event, err = h.service.createEvent(r.Context(), event)
+	}
+	if err != nil {
+		h.renderFormError(w, page, err)
+		return
+	}
+
+	http.Redirect(w, r, "/ui/day?date="+event.Date.Format(dateLayout), http.StatusSeeOther)
+}
+
+// EditEvent показывает форму изменения (GET) и обновляет событие (POST)
+func (h HtmlCalendarHandler) EditEvent(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	page.Title = "Изменить событие"
+	page.Action = "/ui/events/edit"
+
+	if r.Method == http.MethodGet {
+		eventID, err := strconv.Atoi(r.URL.Query().Get("event_id"))
+		if err != nil {
+			h.renderError(w, page, fmt.Errorf("%w: invalid event_id", errInvalidInput))
+			return
+		}
+
+		event, err := h.service.getEvent(r.Context(), eventID)
+		if err != nil {
+			h.renderError(w, page, err)
+			return
+		}
+
+		page.Event = event
+		page.Date = event.Date
+		h.render(w, "form", http.StatusOK, page)
+		return
+	}
+
+	event, err := h.parseEventForm(r)
+	page.Event = event
+	if err == nil {
+		event, err = h.service.updateEvent(r.Context(), event)
+	}
+	if err != nil {
+		h.renderFormError(w, page, err)
+		return
+	}
+
+	http.Redirect(w, r, "/ui/day?date="+event.Date.Format(dateLayout), http.StatusSeeOther)
+}
+
+// DeleteEvent удаляет событие (только POST)
+func (h HtmlCalendarHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.newPage(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventID, err := strconv.Atoi(r.PostForm.Get("event_id"))
+	if err != nil {
+		h.renderError(w, page, fmt.Errorf("%w: invalid event_id", errInvalidInput))
+		return
+	}
+
+	event, err := h.service.deleteEvent(r.Context(), eventID)
+	if err != nil {
+		h.renderError(w, page, err)
+		return
+	}
+
+	http.Redirect(w, r, "/ui/day?date="+event.Date.Format(dateLayout), http.StatusSeeOther)
+}
+
+// Общая подготовка страницы: метод, дата из query, CSRF cookie и проверка токена для POST
+func (h HtmlCalendarHandler) newPage(w http.ResponseWriter, r *http.Request) (htmlPage, bool) {
+	page := htmlPage{Date: time.Now().UTC().Truncate(24 * time.Hour)}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return page, false
+	}
+
+	if value := r.URL.Query().Get("date"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			h.renderError(w, page, fmt.Errorf("%w: invalid date %q", errInvalidInput, value))
+			return page, false
+		}
+		page.Date = date
+	}
+
+	page.Filter = domain.EventFilter{
+		Tag:      r.URL.Query().Get("tag"),
+		Category: r.URL.Query().Get("category"),
+	}
+
+	page.CSRFToken = h.csrfToken(w, r)
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			h.renderError(w, page, fmt.Errorf("%w: %v", errInvalidInput, err))
+			return page, false
+		}
+
+		if !h.validCSRF(r) {
+			h.renderError(w, page, errCSRF)
+			return page, false
+		}
+	}
+
+	return page, true
+}
+
+func (h HtmlCalendarHandler) parseEventForm(r *http.Request) (domain.Event, error) {
+	event := domain.Event{
+		Description: r.PostForm.Get("description"),
+		Title:       r.PostForm.Get("title"),
+		Location:    r.PostForm.Get("location"),
+		Category:    r.PostForm.Get("category"),
+		Tags:        splitTags(r.PostForm["tags"]),
+		Color:       r.PostForm.Get("color"),
+	}
+
+	var err error
+
+	if value := r.PostForm.Get("event_id"); value != "" {
+		if event.ID, err = strconv.Atoi(value); err != nil {
+			return event, fmt.Errorf("%w: invalid event_id", errInvalidInput)
+		}
+	}
+
+	if value := r.PostForm.Get("user_id"); value != "" {
+		if event.UserID, err = strconv.Atoi(value); err != nil {
+			return event, fmt.Errorf("%w: invalid user_id", errInvalidInput)
+		}
+	}
+
+	if value := r.PostForm.Get("date"); value != "" {
+		if event.Date, err = time.Parse(dateLayout, value); err != nil {
+			return event, fmt.Errorf("%w: invalid date", errInvalidInput)
+		}
+	}
+
+	return event, nil
+}
+
+// Возвращает CSRF токен для форм. Токен - подпись идентификатора сессии из cookie,
+// поэтому сторонний сайт не может его подобрать, даже отправив cookie вместе с запросом
+func (h HtmlCalendarHandler) csrfToken(w http.ResponseWriter, r *http.Request) string {
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil || cookie.Value == "" {
+		sessionID := make([]byte, 16)
+		if _, err := rand.Read(sessionID); err != nil {
+			panic(err)
+		}
+
+		cookie = &http.Cookie{
+			Name:     csrfCookieName,
+			Value:    base64.RawURLEncoding.EncodeToString(sessionID),
+			Path:     "/ui/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		}
+		http.SetCookie(w, cookie)
+		// Новая cookie должна учитываться и в текущем запросе
+		r.AddCookie(cookie)
+	}
+
+	return h.signCSRF(cookie.Value)
+}
+
+func (h HtmlCalendarHandler) validCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(csrfCookieName)
+	if err != nil {
+		return false
+	}
+
+	token := r.PostForm.Get("csrf_token")
+
+	return token != "" && hmac.Equal([]byte(token), []byte(h.signCSRF(cookie.Value)))
+}
+
+func (h HtmlCalendarHandler) signCSRF(sessionID string) string {
+	mac := hmac.New(sha256.New, h.csrfKey)
+	mac.Write([]byte(sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h HtmlCalendarHandler) render(w http.ResponseWriter, name string, statusCode int, page htmlPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := h.templates[name].ExecuteTemplate(w, "layout", page); err != nil {
+		log.Printf("render %s: %v\n", name, err)
+	}
+}
+
+// Показывает ошибку на странице дня с HTTP кодом по типу ошибки
+func (h HtmlCalendarHandler) renderError(w http.ResponseWriter, page htmlPage, err error) {
+	statusCode, _ := mapError(err)
+	if errors.Is(err, errCSRF) {
+		statusCode = http.StatusForbidden
+	}
+
+	page.Title = http.StatusText(statusCode)
+	page.Error = err.Error()
+
+	h.render(w, "day", statusCode, page)
+}
+
+// Показывает форму повторно с сообщением об ошибке
+func (h HtmlCalendarHandler) renderFormError(w http.ResponseWriter, page htmlPage, err error) {
+	statusCode, _ := mapError(err)
+
+	if !page.Event.Date.IsZero() {
+		page.Date = page.Event.Date
+	}
+	page.Error = err.Error()
+
+	h.render(w, "form", statusCode, page)
+}
+
+var weekdayNames = [...]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+func newHtmlDay(day time.Time, inPeriod bool, events []domain.Event) htmlDay {
+	cell := htmlDay{
+		Date:     day,
+		InPeriod: inPeriod,
+		Today:    day.Format(dateLayout) == time.Now().UTC().Format(dateLayout),
+	}
+
+	for _, event := range events {
+		if event.Date.Format(dateLayout) == day.Format(dateLayout) {
+			cell.Events = append(cell.Events, event)
+			cell.Holiday = cell.Holiday || event.ReadOnly
+		}
+	}
+
+	return cell
+}
@@ -0,0 +1,146 @@
+package ports
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+)
+
+var csrfTokenPattern = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// Открывает форму нового события и возвращает CSRF токен. Cookie сессии сохраняется в клиенте сервера
+func openHtmlSession(t *testing.T, client *http.Client, serverURL string) string {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Jar = jar
+
+	resp, err := client.Get(serverURL + "/ui/events/new?date=2019-09-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := csrfTokenPattern.FindSubmatch(body)
+	if resp.StatusCode != http.StatusOK || match == nil {
+		t.Fatalf("GET /ui/events/new = %d %s, expected form with csrf token", resp.StatusCode, body)
+	}
+
+	return string(match[1])
+}
+
+func TestHtmlEventForms(t *testing.T) {
+	server, _ := newTestServer(t, builder.DefaultConfig())
+	token := openHtmlSession(t, server.Client(), server.URL)
+
+	form := func(values url.Values) string {
+		values.Set("csrf_token", token)
+		return values.Encode()
+	}
+
+	runSteps(t, server, []httpStep{
+		{
+			name: "create", method: http.MethodPost, path: "/ui/events/new", contentType: formType,
+			body:   form(url.Values{"user_id": {"3"}, "date": {"2019-09-09"}, "title": {"Standup"}, "tags": {"team, sprint"}, "color": {"#FFAA00"}}),
+			status: http.StatusSeeOther,
+		},
+		{
+			name: "invalid form is shown again", method: http.MethodPost, path: "/ui/events/new", contentType: formType,
+			body:   form(url.Values{"user_id": {"3"}, "date": {"2019-09-09"}, "location": {"Room 1"}}),
+			status: http.StatusBadRequest, contains: []string{`class="error"`, "description or title are required", `value="Room 1"`},
+		},
+		{
+			name: "wrong csrf token", method: http.MethodPost, path: "/ui/events/new", contentType: formType,
+			body:   url.Values{"csrf_token": {"forged"}, "user_id": {"3"}, "date": {"2019-09-09"}, "title": {"Forged"}}.Encode(),
+			status: http.StatusForbidden, contains: []string{"invalid or missing CSRF token"},
+		},
+		{
+			name: "day", method: http.MethodGet, path: "/ui/day?date=2019-09-09",
+			status: http.StatusOK, contains: []string{"09.09.2019", "Standup", "team, sprint", "background: #ffaa00", `href="/ui/events/edit?event_id=1"`, `name="csrf_token" value="` + token + `"`},
+		},
+		{
+			name: "edit form", method: http.MethodGet, path: "/ui/events/edit?event_id=1",
+			status: http.StatusOK, contains: []string{`name="event_id" value="1"`, `name="title" value="Standup"`, `name="date" value="2019-09-09"`, `action="/ui/events/delete"`},
+		},
+		{
+			name: "edit", method: http.MethodPost, path: "/ui/events/edit", contentType: formType,
+			body:   form(url.Values{"event_id": {"1"}, "user_id": {"3"}, "date": {"2019-09-10"}, "title": {"Retro"}, "category": {"work"}}),
+			status: http.StatusSeeOther,
+		},
+		{
+			name: "edited event moved to another day", method: http.MethodGet, path: "/ui/day?date=2019-09-10",
+			status: http.StatusOK, contains: []string{"Retro", "work"},
+		},
+		{
+			name: "edit unknown event", method: http.MethodGet, path: "/ui/events/edit?event_id=42",
+			status: http.StatusNotFound, contains: []string{"event 42"},
+		},
+		{
+			name: "delete", method: http.MethodPost, path: "/ui/events/delete", contentType: formType,
+			body: form(url.Values{"event_id": {"1"}}), status: http.StatusSeeOther,
+		},
+		{
+			name: "deleted event", method: http.MethodGet, path: "/ui/day?date=2019-09-10",
+			status: http.StatusOK, contains: []string{"Событий нет."},
+		},
+		{
+			name: "delete with get", method: http.MethodGet, path: "/ui/events/delete?event_id=1",
+			status: http.StatusMethodNotAllowed,
+		},
+	})
+}
+
+func TestHtmlPeriodPages(t *testing.T) {
+	server, _ := newTestServer(t, builder.DefaultConfig())
+	token := openHtmlSession(t, server.Client(), server.URL)
+
+	create := func(date, title, tags string) httpStep {
+		return httpStep{
+			name: "create " + title, method: http.MethodPost, path: "/ui/events/new", contentType: formType,
+			body:   url.Values{"csrf_token": {token}, "user_id": {"1"}, "date": {date}, "title": {title}, "tags": {tags}}.Encode(),
+			status: http.StatusSeeOther,
+		}
+	}
+
+	runSteps(t, server, []httpStep{
+		create("2019-09-02", "Planning", "sprint"),
+		create("2019-09-11", "Review", "team"),
+		create("2019-10-01", "October", ""),
+		{
+			// Неделя начинается с понедельника, сетка захватывает последние дни августа
+			name: "month", method: http.MethodGet, path: "/ui/month?date=2019-09-15",
+			status: http.StatusOK,
+			contains: []string{"<h1>Сентябрь 2019</h1>", "<th>Пн</th>", `href="/ui/day?date=2019-08-26"`, "Planning", "Review",
+				`href="/ui/month?date=2019-08-01"`, `href="/ui/month?date=2019-10-01"`},
+		},
+		{
+			name: "month filtered by tag", method: http.MethodGet, path: "/ui/month?date=2019-09-01&tag=team",
+			status: http.StatusOK, contains: []string{"Review", `href="/ui/month?date=2019-10-01&tag=team"`},
+		},
+		{
+			name: "week", method: http.MethodGet, path: "/ui/week?date=2019-09-11",
+			status: http.StatusOK, contains: []string{"Неделя 09.09.2019 — 15.09.2019", "Review", `href="/ui/week?date=2019-09-04"`, `href="/ui/week?date=2019-09-18"`},
+		},
+		{
+			name: "day without events", method: http.MethodGet, path: "/ui/day?date=2019-09-03",
+			status: http.StatusOK, contains: []string{"<h1>03.09.2019</h1>", "Событий нет."},
+		},
+		{
+			name: "invalid date", method: http.MethodGet, path: "/ui/week?date=11.09.2019",
+			status: http.StatusBadRequest, contains: []string{"invalid date"},
+		},
+	})
+}
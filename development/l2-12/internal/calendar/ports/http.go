@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"runtime/debug"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
@@ -12,11 +14,17 @@ import (
 )
 
 type HttpCalendarHandler struct {
-	app *builder.Application
+	app     *builder.Application
+	service calendarService
+	// Готовность принимать трафик, общая для всех копий обработчика
+	ready *atomic.Bool
 }
 
 func NewHttpCalendarHandler(app *builder.Application) HttpCalendarHandler {
-	return HttpCalendarHandler{app: app}
+	ready := &atomic.Bool{}
+	ready.Store(true)
+
+	return HttpCalendarHandler{app: app, service: newCalendarService(app), ready: ready}
 }
 
 func (h HttpCalendarHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
@@ -34,22 +42,13 @@ func (h HttpCalendarHandler) CreateEvent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Проверка обязательных полей
-	if event.UserID == 0 || event.Date == (time.Time{}) || event.Description == "" {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
-
-	id, err := h.app.CreateEvent.Execute(r.Context(), event)
+	event, err := h.service.createEvent(r.Context(), event)
 	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
 		return
 	}
 
-	event.ID = id
-
 	h.mapToResponse(w, http.StatusOK, event, "")
 }
 
@@ -68,17 +67,10 @@ func (h HttpCalendarHandler) UpdateEvent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Проверка обязательных полей
-	if event.ID == 0 || event.UserID == 0 || event.Date == (time.Time{}) || event.Description == "" {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
-
-	err := h.app.UpdateEvent.Execute(r.Context(), event)
+	event, err := h.service.updateEvent(r.Context(), event)
 	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
 		return
 	}
 
@@ -100,24 +92,10 @@ func (h HttpCalendarHandler) DeleteEvent(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Проверка обязательных полей
-	if event.ID == 0 {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
-
-	event, err := h.app.GetEventByID.Execute(r.Context(), event.ID)
+	event, err := h.service.deleteEvent(r.Context(), event.ID)
 	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
-		return
-	}
-
-	err = h.app.DeleteEvent.Execute(r.Context(), event.ID)
-	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
 		return
 	}
 
@@ -125,70 +103,18 @@ func (h HttpCalendarHandler) DeleteEvent(w http.ResponseWriter, r *http.Request)
 }
 
 func (h HttpCalendarHandler) GetEventsForDay(w http.ResponseWriter, r *http.Request) {
-	// Проверка на соответствие метода запроса
-	if r.Method != http.MethodGet {
-		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
-		return
-	}
-
-	// Валиадции и парсинг параметров
-	event, statusCode, errMessage := h.validationAndParse(r)
-	if statusCode != 200 {
-		// Если ошибка во входных данных, возвращаем HTTP 400
-		h.mapToResponse(w, statusCode, nil, errMessage)
-		return
-	}
-
-	// Проверка обязательных полей
-	if event.Date == (time.Time{}) {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
-
-	events, err := h.app.GetEventsForDay.Execute(r.Context(), event.Date)
-	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
-		return
-	}
-
-	h.mapToResponse(w, http.StatusOK, events, "")
+	h.getEventsFor(w, r, periodDay)
 }
 
 func (h HttpCalendarHandler) GetEventsForWeek(w http.ResponseWriter, r *http.Request) {
-	// Проверка на соответствие метода запроса
-	if r.Method != http.MethodGet {
-		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
-		return
-	}
-
-	// Валиадции и парсинг параметров
-	event, statusCode, errMessage := h.validationAndParse(r)
-	if statusCode != 200 {
-		// Если ошибка во входных данных, возвращаем HTTP 400
-		h.mapToResponse(w, statusCode, nil, errMessage)
-		return
-	}
-
-	// Проверка обязательных полей
-	if event.Date == (time.Time{}) {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
-
-	events, err := h.app.GetEventsForWeek.Execute(r.Context(), event.Date)
-	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
-		return
-	}
-
-	h.mapToResponse(w, http.StatusOK, events, "")
+	h.getEventsFor(w, r, periodWeek)
 }
 
 func (h HttpCalendarHandler) GetEventsForMonth(w http.ResponseWriter, r *http.Request) {
+	h.getEventsFor(w, r, periodMonth)
+}
+
+func (h HttpCalendarHandler) getEventsFor(w http.ResponseWriter, r *http.Request, period string) {
 	// Проверка на соответствие метода запроса
 	if r.Method != http.MethodGet {
 		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
@@ -203,28 +129,60 @@ func (h HttpCalendarHandler) GetEventsForMonth(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Проверка обязательных полей
-	if event.Date == (time.Time{}) {
-		// Если обязательные входные данные отсутсвуют, возвращаем HTTP 400
-		h.mapToResponse(w, http.StatusBadRequest, nil, http.StatusText(http.StatusBadRequest))
-		return
-	}
+	// Отбор по тегу и категории: ?tag=work&category=meeting
+	filter := domain.EventFilter{Tag: r.Form.Get("tag"), Category: r.Form.Get("category")}
 
-	events, err := h.app.GetEventsForMonth.Execute(r.Context(), event.Date)
+	events, err := h.service.eventsFor(r.Context(), period, event.Date, filter)
 	if err != nil {
-		// Если ошибка в бизнес-логике, возвращаем HTTP 503
-		h.mapToResponse(w, http.StatusServiceUnavailable, nil, err.Error())
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
 		return
 	}
 
 	h.mapToResponse(w, http.StatusOK, events, "")
 }
 
+// Формат даты в form и query параметрах
+const dateLayout = "2006-01-02"
+
 type jsonEvent struct {
 	ID          int       `json:"event_id"`
 	UserID      int       `json:"user_id"`
 	Date        time.Time `json:"date"`
 	Description string    `json:"description"`
+	Title       string    `json:"title,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Color       string    `json:"color,omitempty"`
+}
+
+func newJsonEvent(event domain.Event) jsonEvent {
+	return jsonEvent{
+		ID:          event.ID,
+		UserID:      event.UserID,
+		Date:        event.Date,
+		Description: event.Description,
+		Title:       event.Title,
+		Location:    event.Location,
+		Category:    event.Category,
+		Tags:        event.Tags,
+		Color:       event.Color,
+	}
+}
+
+func (e jsonEvent) toDomain() domain.Event {
+	return domain.Event{
+		ID:          e.ID,
+		UserID:      e.UserID,
+		Date:        e.Date,
+		Description: e.Description,
+		Title:       e.Title,
+		Location:    e.Location,
+		Category:    e.Category,
+		Tags:        e.Tags,
+		Color:       e.Color,
+	}
 }
 
 // Валидация и парсинг параметров
@@ -255,7 +213,7 @@ func (h HttpCalendarHandler) validationAndParse(r *http.Request) (domain.Event,
 		}
 
 		if r.Form.Get("date") != "" {
-			event.Date, err = time.Parse("2006-01-02", r.Form.Get("date"))
+			event.Date, err = time.Parse(dateLayout, r.Form.Get("date"))
 			if err != nil {
 				// Если ошибка валидации входных данных, возвращаем HTTP 400
 				return domain.Event{}, http.StatusBadRequest, err.Error()
@@ -263,6 +221,11 @@ func (h HttpCalendarHandler) validationAndParse(r *http.Request) (domain.Event,
 		}
 
 		event.Description = r.Form.Get("description")
+		event.Title = r.Form.Get("title")
+		event.Location = r.Form.Get("location")
+		event.Category = r.Form.Get("category")
+		event.Tags = splitTags(r.Form["tags"])
+		event.Color = r.Form.Get("color")
 	} else if r.Header.Get("Content-Type") == "application/json" {
 		jEvent := jsonEvent{}
 
@@ -272,10 +235,7 @@ func (h HttpCalendarHandler) validationAndParse(r *http.Request) (domain.Event,
 			return domain.Event{}, http.StatusBadRequest, err.Error()
 		}
 
-		event.ID = jEvent.ID
-		event.UserID = jEvent.UserID
-		event.Date = jEvent.Date
-		event.Description = jEvent.Description
+		event = jEvent.toDomain()
 	} else {
 		// Если необходимые входные данные отсутсвуют, возвращаем HTTP 400
 		return domain.Event{}, http.StatusBadRequest, http.StatusText(http.StatusBadRequest)
@@ -286,6 +246,16 @@ func (h HttpCalendarHandler) validationAndParse(r *http.Request) (domain.Event,
 
 // Парсинг ответа
 func (h HttpCalendarHandler) mapToResponse(w http.ResponseWriter, statusCode int, data interface{}, errMessage string) {
+	h.writeResponse(w, statusCode, data, errorCodeForStatus(statusCode), errMessage)
+}
+
+// Парсинг ответа с ошибкой бизнес-логики
+func (h HttpCalendarHandler) mapErrorToResponse(w http.ResponseWriter, err error) {
+	statusCode, errCode := mapError(err)
+	h.writeResponse(w, statusCode, nil, errCode, err.Error())
+}
+
+func (h HttpCalendarHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, errCode, errMessage string) {
 	// Задаём JSON формат в Content-Type заголовка ответа
 	w.Header().Set("Content-Type", "application/json")
 
@@ -300,6 +270,7 @@ func (h HttpCalendarHandler) mapToResponse(w http.ResponseWriter, statusCode int
 		response["result"] = data
 	} else {
 		response["error"] = errMessage
+		response["code"] = errCode
 	}
 
 	// Преобразуем данные в JSON и записываем в тело ответа
@@ -318,11 +289,67 @@ func (h HttpCalendarHandler) MiddlewareLogger(next http.HandlerFunc) http.Handle
 	}
 }
 
+// MiddlewareRecover переводит панику обработчика в HTTP 500, не роняя сервер
+func (h HttpCalendarHandler) MiddlewareRecover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				// Обрыв ответа по http.ErrAbortHandler обрабатывает сам сервер
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				log.Printf("panic in %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				// Прочие ошибки сервера возвращаются как HTTP 500
+				h.mapToResponse(w, http.StatusInternalServerError, nil, http.StatusText(http.StatusInternalServerError))
+			}
+		}()
+
+		next(w, r)
+	}
+}
+
 func CustomRegisterHandlers(router *http.ServeMux, h HttpCalendarHandler) {
-	router.HandleFunc("/create_event", h.MiddlewareLogger(h.CreateEvent))
-	router.HandleFunc("/update_event", h.MiddlewareLogger(h.UpdateEvent))
-	router.HandleFunc("/delete_event", h.MiddlewareLogger(h.DeleteEvent))
-	router.HandleFunc("/events_for_day", h.MiddlewareLogger(h.GetEventsForDay))
-	router.HandleFunc("/events_for_week", h.MiddlewareLogger(h.GetEventsForWeek))
-	router.HandleFunc("/events_for_month", h.MiddlewareLogger(h.GetEventsForMonth))
+	handle := func(pattern string, handler http.HandlerFunc) {
+		router.HandleFunc(pattern, h.MiddlewareLogger(h.MiddlewareRecover(h.MiddlewareClientCert(h.MiddlewareTenant(handler)))))
+	}
+	// Административные методы работают вне арендаторов и требуют токен администратора
+	handleAdmin := func(pattern string, handler http.HandlerFunc) {
+		router.HandleFunc(pattern, h.MiddlewareLogger(h.MiddlewareRecover(h.MiddlewareAdmin(handler))))
+	}
+	// Пробы балансировщика и оркестратора не относятся к арендатору и пользователю,
+	// поэтому проходят без проверки заголовка арендатора и клиентского сертификата
+	handleProbe := func(pattern string, handler http.HandlerFunc) {
+		router.HandleFunc(pattern, h.MiddlewareLogger(h.MiddlewareRecover(handler)))
+	}
+
+	handle("/create_event", h.MiddlewareIdempotency(h.CreateEvent))
+	handle("/update_event", h.MiddlewareIdempotency(h.UpdateEvent))
+	handle("/delete_event", h.DeleteEvent)
+	handle("/events_for_day", h.GetEventsForDay)
+	handle("/events_for_week", h.GetEventsForWeek)
+	handle("/events_for_month", h.GetEventsForMonth)
+	handle("/upload_attachment", h.UploadAttachment)
+	handle("/attachments", h.ListAttachments)
+	handle("/download_attachment", h.DownloadAttachment)
+	handle("/availability", h.Availability)
+	handle("/working_hours", h.WorkingHours)
+	handle("/openapi.json", h.OpenAPI)
+	handle("/rpc", NewJsonRpcCalendarHandler(h.app).ServeHTTP)
+
+	handleProbe("/healthz", h.Healthz)
+	handleProbe("/readyz", h.Readyz)
+
+	handleAdmin("/admin/create_tenant", h.CreateTenant)
+	handleAdmin("/admin/tenants", h.ListTenants)
+	handleAdmin("/admin/delete_tenant", h.DeleteTenant)
+
+	html := NewHtmlCalendarHandler(h.app)
+	handle("/ui/", html.Index)
+	handle("/ui/month", html.Month)
+	handle("/ui/week", html.Week)
+	handle("/ui/day", html.Day)
+	handle("/ui/events/new", html.NewEvent)
+	handle("/ui/events/edit", html.EditEvent)
+	handle("/ui/events/delete", html.DeleteEvent)
 }
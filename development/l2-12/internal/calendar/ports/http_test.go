@@ -0,0 +1,421 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+)
+
+// Шаг сценария: запрос к серверу и ожидаемый ответ
+type httpStep struct {
+	name        string
+	method      string
+	path        string
+	contentType string
+	body        string
+	header      map[string]string
+	status      int
+	// Подстроки, которые должны быть в теле ответа
+	contains []string
+}
+
+func newTestServer(t *testing.T, config builder.Config) (*httptest.Server, HttpCalendarHandler) {
+	t.Helper()
+
+	h := NewHttpCalendarHandler(builder.NewApplicationWithConfig(context.Background(), config))
+
+	router := http.NewServeMux()
+	CustomRegisterHandlers(router, h)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server, h
+}
+
+func runSteps(t *testing.T, server *httptest.Server, steps []httpStep) {
+	t.Helper()
+
+	// Перенаправления проверяются как есть
+	httpClient := server.Client()
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for _, step := range steps {
+		req, err := http.NewRequest(step.method, server.URL+step.path, strings.NewReader(step.body))
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if step.contentType != "" {
+			req.Header.Set("Content-Type", step.contentType)
+		}
+		for name, value := range step.header {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: read body: %v", step.name, err)
+		}
+
+		if resp.StatusCode != step.status {
+			t.Errorf("%s: %s %s = %d %s, expected %d", step.name, step.method, step.path, resp.StatusCode, body, step.status)
+			continue
+		}
+
+		for _, part := range step.contains {
+			if !strings.Contains(string(body), part) {
+				t.Errorf("%s: body %s does not contain %s", step.name, body, part)
+			}
+		}
+	}
+}
+
+const (
+	formType = "application/x-www-form-urlencoded"
+	jsonType = "application/json"
+)
+
+func TestHttpRoutes(t *testing.T) {
+	config := builder.DefaultConfig()
+	config.AttachmentsDir = t.TempDir()
+	config.AdminToken = "admin-secret"
+
+	server, _ := newTestServer(t, config)
+
+	upload := "--b\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename=\"agenda.txt\"\r\n" +
+		"Content-Type: text/plain\r\n\r\n" +
+		"1. Roadmap\r\n" +
+		"--b--\r\n"
+
+	runSteps(t, server, []httpStep{
+		// Создание, изменение и удаление событий формой и JSON
+		{
+			name: "create event from form", method: http.MethodPost, path: "/create_event", contentType: formType,
+			body:   "user_id=3&date=2019-09-09&description=standup&tags=team,daily",
+			status: http.StatusOK, contains: []string{`"ID":1`, `"Description":"standup"`, `"Tags":["team","daily"]`},
+		},
+		{
+			name: "create event from json", method: http.MethodPost, path: "/create_event", contentType: jsonType,
+			body:   `{"user_id":3,"date":"2019-09-10T00:00:00Z","title":"Retro","category":"work"}`,
+			status: http.StatusOK, contains: []string{`"ID":2`, `"Title":"Retro"`},
+		},
+		{
+			name: "create event with invalid user_id", method: http.MethodPost, path: "/create_event", contentType: formType,
+			body:   "user_id=abc&date=2019-09-09&description=standup",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event with invalid date", method: http.MethodPost, path: "/create_event", contentType: formType,
+			body:   "user_id=3&date=09.09.2019&description=standup",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event with broken json", method: http.MethodPost, path: "/create_event", contentType: jsonType,
+			body:   `{"user_id":`,
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event without fields", method: http.MethodPost, path: "/create_event", contentType: formType,
+			body:   "user_id=3",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event without content type", method: http.MethodPost, path: "/create_event",
+			body:   "user_id=3&date=2019-09-09&description=standup",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event with invalid color", method: http.MethodPost, path: "/create_event", contentType: formType,
+			body:   "user_id=3&date=2019-09-09&description=standup&color=red",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "create event with get", method: http.MethodGet, path: "/create_event",
+			status: http.StatusMethodNotAllowed, contains: []string{`"code":"method_not_allowed"`},
+		},
+		{
+			name: "update event from form", method: http.MethodPost, path: "/update_event", contentType: formType,
+			body:   "event_id=1&user_id=3&date=2019-09-09&description=standup moved&tags=team,daily",
+			status: http.StatusOK, contains: []string{`"Description":"standup moved"`},
+		},
+		{
+			name: "update event from json", method: http.MethodPost, path: "/update_event", contentType: jsonType,
+			body:   `{"event_id":2,"user_id":3,"date":"2019-09-10T00:00:00Z","title":"Retro","category":"work","location":"Room 1"}`,
+			status: http.StatusOK, contains: []string{`"Location":"Room 1"`},
+		},
+		{
+			name: "update unknown event", method: http.MethodPost, path: "/update_event", contentType: formType,
+			body:   "event_id=42&user_id=3&date=2019-09-09&description=standup",
+			status: http.StatusNotFound, contains: []string{`"code":"not_found"`},
+		},
+		{
+			name: "update event without event_id", method: http.MethodPost, path: "/update_event", contentType: formType,
+			body:   "user_id=3&date=2019-09-09&description=standup",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+
+		// Списки событий
+		{
+			name: "events for day", method: http.MethodGet, path: "/events_for_day?date=2019-09-09",
+			status: http.StatusOK, contains: []string{`"standup moved"`},
+		},
+		{
+			name: "events for week filtered by category", method: http.MethodGet, path: "/events_for_week?date=2019-09-09&category=work",
+			status: http.StatusOK, contains: []string{`"Title":"Retro"`},
+		},
+		{
+			name: "events for month filtered by tag", method: http.MethodGet, path: "/events_for_month?date=2019-09-01&tag=daily",
+			status: http.StatusOK, contains: []string{`"standup moved"`},
+		},
+		{
+			name: "events for day with invalid date", method: http.MethodGet, path: "/events_for_day?date=tomorrow",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "events for week without date", method: http.MethodGet, path: "/events_for_week",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "events for month with post", method: http.MethodPost, path: "/events_for_month", contentType: formType,
+			body:   "date=2019-09-01",
+			status: http.StatusMethodNotAllowed, contains: []string{`"code":"method_not_allowed"`},
+		},
+
+		// Вложения
+		{
+			name: "upload attachment", method: http.MethodPost, path: "/upload_attachment?event_id=1", contentType: "multipart/form-data; boundary=b",
+			body:   upload,
+			status: http.StatusOK, contains: []string{`"name":"agenda.txt"`, `"size":10`},
+		},
+		{
+			name: "upload attachment without multipart", method: http.MethodPost, path: "/upload_attachment?event_id=1", contentType: formType,
+			body:   "file=agenda.txt",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "list attachments", method: http.MethodGet, path: "/attachments?event_id=1",
+			status: http.StatusOK, contains: []string{`"name":"agenda.txt"`},
+		},
+		{
+			name: "list attachments of unknown event", method: http.MethodGet, path: "/attachments?event_id=42",
+			status: http.StatusNotFound, contains: []string{`"code":"not_found"`},
+		},
+		{
+			name: "download unknown attachment", method: http.MethodGet, path: "/download_attachment?event_id=1&attachment_id=nope",
+			status: http.StatusNotFound, contains: []string{`"code":"not_found"`},
+		},
+
+		// Рабочее время
+		{
+			name: "set working hours from form", method: http.MethodPost, path: "/working_hours", contentType: formType,
+			body:   "user_id=3&start=10:00&end=19:00&weekdays=mon,tue",
+			status: http.StatusOK, contains: []string{`"start":"10:00"`, `"weekdays":["mon","tue"]`},
+		},
+		{
+			name: "set working hours from json", method: http.MethodPost, path: "/working_hours", contentType: jsonType,
+			body:   `{"user_id":4,"start":"08:00","end":"17:00","weekdays":["fri"]}`,
+			status: http.StatusOK, contains: []string{`"end":"17:00"`},
+		},
+		{
+			name: "set working hours with invalid time", method: http.MethodPost, path: "/working_hours", contentType: formType,
+			body:   "user_id=3&start=25:00&end=19:00",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "get working hours", method: http.MethodGet, path: "/working_hours?user_id=3",
+			status: http.StatusOK, contains: []string{`"start":"10:00"`},
+		},
+		{
+			name: "availability for week", method: http.MethodGet, path: "/availability?user_id=3&date=2019-09-09&period=week",
+			status: http.StatusOK, contains: []string{`"date":"2019-09-09","working":true,"start":"10:00","end":"19:00","events":1`},
+		},
+		{
+			name: "availability with unknown period", method: http.MethodGet, path: "/availability?user_id=3&date=2019-09-09&period=year",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+
+		// JSON-RPC, HTML и служебные методы
+		{
+			name: "json-rpc", method: http.MethodPost, path: "/rpc", contentType: jsonType,
+			body:   `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"date":"2019-09-09"},"id":1}`,
+			status: http.StatusOK, contains: []string{`"standup moved"`},
+		},
+		{
+			name: "html index redirects to month", method: http.MethodGet, path: "/ui/",
+			status: http.StatusSeeOther,
+		},
+		{
+			name: "html month", method: http.MethodGet, path: "/ui/month?date=2019-09-01",
+			status: http.StatusOK, contains: []string{"standup moved"},
+		},
+		{
+			name: "html delete without csrf token", method: http.MethodPost, path: "/ui/events/delete", contentType: formType,
+			body:   "event_id=1",
+			status: http.StatusForbidden,
+		},
+		{
+			name: "openapi", method: http.MethodGet, path: "/openapi.json",
+			status: http.StatusOK, contains: []string{`"openapi"`},
+		},
+		{
+			name: "healthz", method: http.MethodGet, path: "/healthz",
+			status: http.StatusOK, contains: []string{`"result":"ok"`},
+		},
+		{
+			name: "readyz", method: http.MethodGet, path: "/readyz",
+			status: http.StatusOK, contains: []string{`"result":"ready"`},
+		},
+		{
+			name: "readyz with unknown tenant", method: http.MethodGet, path: "/readyz", header: map[string]string{"X-Tenant-ID": "unknown"},
+			status: http.StatusOK, contains: []string{`"result":"ready"`},
+		},
+		{
+			name: "admin without token", method: http.MethodGet, path: "/admin/tenants",
+			status: http.StatusForbidden, contains: []string{`"code":"forbidden"`},
+		},
+		{
+			name: "admin tenants", method: http.MethodGet, path: "/admin/tenants", header: map[string]string{"Authorization": "Bearer admin-secret"},
+			status: http.StatusOK, contains: []string{`"tenant_id":"default"`},
+		},
+
+		// Удаление события вместе с вложениями
+		{
+			name: "delete event", method: http.MethodPost, path: "/delete_event", contentType: formType,
+			body:   "event_id=1",
+			status: http.StatusOK, contains: []string{`"ID":1`},
+		},
+		{
+			name: "delete deleted event", method: http.MethodPost, path: "/delete_event", contentType: formType,
+			body:   "event_id=1",
+			status: http.StatusNotFound, contains: []string{`"code":"not_found"`},
+		},
+		{
+			name: "delete event with invalid id", method: http.MethodPost, path: "/delete_event", contentType: formType,
+			body:   "event_id=one",
+			status: http.StatusBadRequest, contains: []string{`"code":"bad_request"`},
+		},
+		{
+			name: "attachments of deleted event", method: http.MethodGet, path: "/attachments?event_id=1",
+			status: http.StatusNotFound, contains: []string{`"code":"not_found"`},
+		},
+	})
+}
+
+// Хранилище файлов, которое отказывает (ошибка бизнес-логики) или падает с паникой (ошибка сервера)
+type brokenBlobStorage struct {
+	panics bool
+}
+
+func (s brokenBlobStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if s.panics {
+		panic("blob storage is broken")
+	}
+
+	return 0, errors.New("blob storage is unavailable")
+}
+
+func (s brokenBlobStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, errors.New("blob storage is unavailable")
+}
+
+func (s brokenBlobStorage) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestHttpServerErrors(t *testing.T) {
+	upload := "--b\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename=\"agenda.txt\"\r\n" +
+		"Content-Type: text/plain\r\n\r\n" +
+		"1. Roadmap\r\n" +
+		"--b--\r\n"
+
+	tests := []struct {
+		name    string
+		storage brokenBlobStorage
+		status  int
+		code    string
+	}{
+		// Ошибка бизнес-логики (сбой хранилища) - HTTP 503
+		{name: "storage error", storage: brokenBlobStorage{}, status: http.StatusServiceUnavailable, code: ErrorCodeUnavailable},
+		// Прочие ошибки - HTTP 500, сервер продолжает работать
+		{name: "handler panic", storage: brokenBlobStorage{panics: true}, status: http.StatusInternalServerError, code: ErrorCodeInternal},
+	}
+
+	for _, test := range tests {
+		config := builder.DefaultConfig()
+		config.BlobStorage = test.storage
+
+		server, h := newTestServer(t, config)
+
+		runSteps(t, server, []httpStep{
+			{
+				name: test.name + ": create event", method: http.MethodPost, path: "/create_event", contentType: formType,
+				body:   "user_id=3&date=2019-09-09&description=standup",
+				status: http.StatusOK,
+			},
+			{
+				name: test.name + ": upload", method: http.MethodPost, path: "/upload_attachment?event_id=1", contentType: "multipart/form-data; boundary=b",
+				body:   upload,
+				status: test.status, contains: []string{`"code":"` + test.code + `"`},
+			},
+			{
+				name: test.name + ": server is alive", method: http.MethodGet, path: "/events_for_day?date=2019-09-09",
+				status: http.StatusOK, contains: []string{`"standup"`},
+			},
+		})
+
+		// Снятая готовность - тоже HTTP 503
+		h.SetReady(false)
+		runSteps(t, server, []httpStep{{
+			name: test.name + ": readyz while draining", method: http.MethodGet, path: "/readyz",
+			status: http.StatusServiceUnavailable, contains: []string{`"code":"unavailable"`},
+		}})
+	}
+}
+
+func TestIdempotencyKeyReleasedAfterPanic(t *testing.T) {
+	h := NewHttpCalendarHandler(builder.NewApplication(context.Background()))
+
+	// Первый вызов падает, повтор с тем же ключом выполняется заново и сохраняется
+	calls := 0
+	handler := h.MiddlewareRecover(h.MiddlewareIdempotency(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("storage is broken")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	statuses := []int{http.StatusInternalServerError, http.StatusCreated, http.StatusCreated}
+
+	for i, expected := range statuses {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(`{"user_id":1}`))
+		req.Header.Set("Idempotency-Key", "retry-after-panic")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != expected {
+			t.Errorf("request %d: status = %d %s, expected %d", i+1, rec.Code, rec.Body, expected)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("handler calls = %d, expected 2: the saved response is replayed on the third request", calls)
+	}
+}
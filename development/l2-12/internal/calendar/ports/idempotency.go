@@ -0,0 +1,167 @@
+package ports
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// MiddlewareIdempotency повторяет сохранённый ответ на запрос с тем же заголовком Idempotency-Key и телом.
+// Ключ с другим телом отклоняется с HTTP 422, ключ запроса, который ещё выполняется, - с HTTP 409.
+// Ответы с кодом 5xx не сохраняются, такой запрос можно повторить с тем же ключом
+func (h HttpCalendarHandler) MiddlewareIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if err := validateIdempotencyKey(key); err != nil {
+			// Если ошибка во входных данных, возвращаем HTTP 400
+			h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		if err != nil {
+			h.mapUploadError(w, fmt.Errorf("%w: %w", errInvalidInput, err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Ключи разных арендаторов и пользователей не пересекаются
+		storeKey := domain.TenantFromContext(r.Context()) + ":"
+		if userID, ok := UserIDFromContext(r.Context()); ok {
+			storeKey += strconv.Itoa(userID) + ":"
+		}
+		storeKey += key
+
+		fingerprint := requestFingerprint(r, body)
+		store := h.app.IdempotencyStore
+
+		response, done, err := store.Reserve(r.Context(), storeKey, fingerprint)
+		if err != nil {
+			// Ошибка переводится в HTTP код по её типу
+			h.mapErrorToResponse(w, err)
+			return
+		}
+
+		if done {
+			if response.Fingerprint != fingerprint {
+				h.writeResponse(w, http.StatusUnprocessableEntity, nil, ErrorCodeIdempotencyMismatch,
+					fmt.Sprintf("idempotency key %q was already used with a different request", key))
+				return
+			}
+
+			for name, values := range response.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(response.StatusCode)
+			_, _ = w.Write(response.Body)
+			return
+		}
+
+		// Сбой хранилища или сервера, в том числе паника обработчика, не фиксирует результат: клиент повторит запрос.
+		// Освобождение отложено, потому что MiddlewareRecover перехватывает панику выше этого обработчика
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(r.Context(), storeKey); err != nil {
+				log.Printf("idempotency key %q: release: %v", key, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		completed = true
+
+		err = store.Complete(r.Context(), storeKey, domain.IdempotentResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.statusCode,
+			Header:      recorder.header,
+			Body:        recorder.body.Bytes(),
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			log.Printf("idempotency key %q: complete: %v", key, err)
+		}
+	}
+}
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
+
+	for _, c := range []byte(key) {
+		if c < 0x21 || c > 0x7e {
+			return errors.New(idempotencyKeyHeader + " must contain only visible ASCII characters")
+		}
+	}
+
+	return nil
+}
+
+// Отпечаток запроса: метод, путь, тип содержимого и тело
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Пропускает ответ клиенту и запоминает код, заголовки и тело
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.wroteHeader = true
+	r.statusCode = statusCode
+	r.header = r.ResponseWriter.Header().Clone()
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	r.body.Write(data)
+
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,394 @@
+package ports
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Коды ошибок JSON-RPC 2.0
+const (
+	jsonRpcParseError     = -32700
+	jsonRpcInvalidRequest = -32600
+	jsonRpcMethodNotFound = -32601
+	jsonRpcInvalidParams  = -32602
+	jsonRpcInternalError  = -32603
+	// Коды -32000..-32099 зарезервированы под ошибки приложения
+	jsonRpcUnavailable = -32000
+	jsonRpcForbidden   = -32003
+	jsonRpcNotFound    = -32004
+	jsonRpcConflict    = -32009
+)
+
+// Максимальный размер одного сообщения JSON-RPC
+const jsonRpcMaxMessageSize = 1 << 20
+
+type jsonRpcRequest struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type jsonRpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonRpcError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonRpcError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    *jsonRpcErrorData `json:"data,omitempty"`
+}
+
+// В data передаётся тот же машиночитаемый код, что и в HTTP API
+type jsonRpcErrorData struct {
+	Code string `json:"code"`
+}
+
+// Параметры методов. Дата принимается как в form параметрах (2006-01-02) или в RFC 3339.
+// tag и category отбирают события в методах getEventsFor*. tenant_id и tenant_token задают арендатора
+// вместо заголовков HTTP, которых у TCP транспорта нет
+type jsonRpcEventParams struct {
+	ID          int      `json:"event_id"`
+	UserID      int      `json:"user_id"`
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+	Title       string   `json:"title"`
+	Location    string   `json:"location"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+	Color       string   `json:"color"`
+	Tag         string   `json:"tag"`
+	TenantID    string   `json:"tenant_id"`
+	TenantToken string   `json:"tenant_token"`
+}
+
+// JsonRpcCalendarHandler - транспорт JSON-RPC 2.0 для сценариев календаря.
+// Работает поверх HTTP (POST /rpc) и поверх TCP (одно сообщение на строку)
+type JsonRpcCalendarHandler struct {
+	service calendarService
+}
+
+func NewJsonRpcCalendarHandler(app *builder.Application) JsonRpcCalendarHandler {
+	return JsonRpcCalendarHandler{service: newCalendarService(app)}
+}
+
+// ServeHTTP принимает запрос или пакет запросов в теле POST
+func (h JsonRpcCalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, jsonRpcMaxMessageSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := h.Handle(r.Context(), body)
+	if response == nil {
+		// Ответ на уведомления не отправляется
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(response)
+}
+
+// ServeTCP принимает соединения, пока не будет закрыт listener или отменён ctx
+func (h JsonRpcCalendarHandler) ServeTCP(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeConn(ctx, conn)
+		}()
+	}
+}
+
+// ServeConn обслуживает одно TCP соединение: каждое сообщение и каждый ответ занимают одну строку.
+// Соединение из tls.NewListener аутентифицируется по клиентскому сертификату
+func (h JsonRpcCalendarHandler) ServeConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	// Закрываем соединение при остановке сервера, чтобы прервать чтение
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	log.Printf("JSON-RPC connection from %s\n", conn.RemoteAddr())
+
+	ctx, err := h.authenticateConn(ctx, conn)
+	if err != nil {
+		log.Printf("JSON-RPC connection from %s rejected: %v\n", conn.RemoteAddr(), err)
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), jsonRpcMaxMessageSize)
+
+	writer := bufio.NewWriter(conn)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		response := h.Handle(ctx, line)
+		if response == nil {
+			continue
+		}
+
+		if _, err := writer.Write(append(response, '\n')); err != nil {
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Для TLS соединения завершает рукопожатие и, как MiddlewareClientCert, кладёт в контекст пользователя
+// из клиентского сертификата. Соединение без TLS или без сертификата обслуживается анонимно
+func (h JsonRpcCalendarHandler) authenticateConn(ctx context.Context, conn net.Conn) (context.Context, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return ctx, nil
+	}
+
+	// Без явного рукопожатия сертификат клиента был бы известен только после первого чтения
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return ctx, err
+	}
+
+	certificates := tlsConn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return ctx, nil
+	}
+
+	userID, err := UserIDFromCertificate(certificates[0])
+	if err != nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, userIDContextKey, userID), nil
+}
+
+// Handle обрабатывает одно сообщение (запрос или пакет) и возвращает ответ.
+// Для уведомлений без id ответ равен nil
+func (h JsonRpcCalendarHandler) Handle(ctx context.Context, message []byte) []byte {
+	message = bytes.TrimSpace(message)
+
+	if len(message) > 0 && message[0] == '[' {
+		batch := []json.RawMessage{}
+		if err := json.Unmarshal(message, &batch); err != nil {
+			return h.marshal(h.errorResponse(nil, jsonRpcParseError, err.Error(), ""))
+		}
+		if len(batch) == 0 {
+			return h.marshal(h.errorResponse(nil, jsonRpcInvalidRequest, "empty batch", ""))
+		}
+
+		responses := make([]jsonRpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if response, ok := h.handleOne(ctx, raw); ok {
+				responses = append(responses, response)
+			}
+		}
+
+		if len(responses) == 0 {
+			return nil
+		}
+
+		return h.marshal(responses)
+	}
+
+	response, ok := h.handleOne(ctx, message)
+	if !ok {
+		return nil
+	}
+
+	return h.marshal(response)
+}
+
+func (h JsonRpcCalendarHandler) handleOne(ctx context.Context, raw json.RawMessage) (jsonRpcResponse, bool) {
+	request := jsonRpcRequest{}
+
+	if err := json.Unmarshal(raw, &request); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return h.errorResponse(nil, jsonRpcParseError, err.Error(), ""), true
+		}
+		return h.errorResponse(nil, jsonRpcInvalidRequest, err.Error(), ""), true
+	}
+
+	if request.JsonRpc != "2.0" || request.Method == "" {
+		return h.errorResponse(request.ID, jsonRpcInvalidRequest, `expected "jsonrpc": "2.0" and method`, ""), true
+	}
+
+	result, err := h.call(ctx, request.Method, request.Params)
+
+	// Уведомление: запрос без id, ответ не нужен
+	if len(request.ID) == 0 {
+		return jsonRpcResponse{}, false
+	}
+
+	if err != nil {
+		rpcErr := &jsonRpcError{}
+		if errors.As(err, &rpcErr) {
+			return jsonRpcResponse{JsonRpc: "2.0", Error: rpcErr, ID: request.ID}, true
+		}
+
+		code, errCode := mapJsonRpcError(err)
+		return h.errorResponse(request.ID, code, err.Error(), errCode), true
+	}
+
+	return jsonRpcResponse{JsonRpc: "2.0", Result: result, ID: request.ID}, true
+}
+
+func (h JsonRpcCalendarHandler) call(ctx context.Context, method string, rawParams json.RawMessage) (interface{}, error) {
+	params := jsonRpcEventParams{}
+
+	if len(rawParams) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(rawParams))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&params); err != nil {
+			return nil, &jsonRpcError{Code: jsonRpcInvalidParams, Message: err.Error(), Data: &jsonRpcErrorData{Code: ErrorCodeBadRequest}}
+		}
+	}
+
+	// Арендатор определяется по тем же правилам, что и в MiddlewareTenant, в том числе с -require-tenant-token
+	if params.TenantID != "" || params.TenantToken != "" {
+		tenant, err := h.service.app.ResolveTenant.Execute(ctx, params.TenantID, params.TenantToken)
+		if err != nil {
+			return nil, err
+		}
+		ctx = domain.WithTenant(ctx, tenant.ID)
+	}
+
+	event := domain.Event{
+		ID:          params.ID,
+		UserID:      params.UserID,
+		Description: params.Description,
+		Title:       params.Title,
+		Location:    params.Location,
+		Category:    params.Category,
+		Tags:        params.Tags,
+		Color:       params.Color,
+	}
+	filter := domain.EventFilter{Tag: params.Tag, Category: params.Category}
+
+	if params.Date != "" {
+		date, err := parseJsonRpcDate(params.Date)
+		if err != nil {
+			return nil, &jsonRpcError{Code: jsonRpcInvalidParams, Message: err.Error(), Data: &jsonRpcErrorData{Code: ErrorCodeBadRequest}}
+		}
+		event.Date = date
+	}
+
+	switch method {
+	case "calendar.createEvent":
+		return h.service.createEvent(ctx, event)
+	case "calendar.updateEvent":
+		return h.service.updateEvent(ctx, event)
+	case "calendar.deleteEvent":
+		return h.service.deleteEvent(ctx, event.ID)
+	case "calendar.getEventsForDay":
+		return h.service.eventsFor(ctx, periodDay, event.Date, filter)
+	case "calendar.getEventsForWeek":
+		return h.service.eventsFor(ctx, periodWeek, event.Date, filter)
+	case "calendar.getEventsForMonth":
+		return h.service.eventsFor(ctx, periodMonth, event.Date, filter)
+	default:
+		return nil, &jsonRpcError{Code: jsonRpcMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+	}
+}
+
+func (h JsonRpcCalendarHandler) errorResponse(id json.RawMessage, code int, message, errCode string) jsonRpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	rpcErr := &jsonRpcError{Code: code, Message: message}
+	if errCode != "" {
+		rpcErr.Data = &jsonRpcErrorData{Code: errCode}
+	}
+
+	return jsonRpcResponse{JsonRpc: "2.0", Error: rpcErr, ID: id}
+}
+
+func (h JsonRpcCalendarHandler) marshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(h.errorResponse(nil, jsonRpcInternalError, err.Error(), ErrorCodeInternal))
+	}
+
+	return data
+}
+
+func (e *jsonRpcError) Error() string {
+	return e.Message
+}
+
+// Переводит ошибку в код JSON-RPC через ту же таблицу, что и для HTTP
+func mapJsonRpcError(err error) (int, string) {
+	_, errCode := mapError(err)
+
+	switch errCode {
+	case ErrorCodeBadRequest, ErrorCodeValidation, ErrorCodeTooLarge:
+		return jsonRpcInvalidParams, errCode
+	case ErrorCodeNotFound:
+		return jsonRpcNotFound, errCode
+	case ErrorCodeConflict:
+		return jsonRpcConflict, errCode
+	case ErrorCodeForbidden:
+		return jsonRpcForbidden, errCode
+	case ErrorCodeUnavailable:
+		return jsonRpcUnavailable, errCode
+	default:
+		return jsonRpcInternalError, errCode
+	}
+}
+
+func parseJsonRpcDate(value string) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
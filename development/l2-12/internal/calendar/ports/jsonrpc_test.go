@@ -0,0 +1,188 @@
+package ports
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func TestJsonRpcHandle(t *testing.T) {
+	h := NewJsonRpcCalendarHandler(builder.NewApplication(context.Background()))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		request  string
+		expected string
+	}{
+		{
+			name:     "create event",
+			request:  `{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"user_id":3,"date":"2019-09-09","description":"standup"},"id":1}`,
+			expected: `{"jsonrpc":"2.0","result":{"ID":1,"UserID":3,"Date":"2019-09-09T00:00:00Z","Description":"standup","Title":"","Location":"","Category":"","Tags":[],"Color":"","ReadOnly":false},"id":1}`,
+		},
+		{
+			name:     "missing fields map to invalid params",
+			request:  `{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"user_id":3},"id":2}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"bad request: user_id, date and description or title are required","data":{"code":"bad_request"}},"id":2}`,
+		},
+		{
+			name:     "domain not found",
+			request:  `{"jsonrpc":"2.0","method":"calendar.deleteEvent","params":{"event_id":42},"id":"x"}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32004,"message":"not found: event 42","data":{"code":"not_found"}},"id":"x"}`,
+		},
+		{
+			name:     "unknown method",
+			request:  `{"jsonrpc":"2.0","method":"calendar.nope","id":3}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method \"calendar.nope\" not found"},"id":3}`,
+		},
+		{
+			name:     "parse error",
+			request:  `{"jsonrpc":`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"unexpected end of JSON input"},"id":null}`,
+		},
+		{
+			name:     "notification has no response",
+			request:  `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"date":"2019-09-09"}}`,
+			expected: ``,
+		},
+		{
+			name:     "batch",
+			request:  `[{"jsonrpc":"2.0","method":"calendar.getEventsForMonth","params":{"date":"2019-09-01"},"id":4},{"jsonrpc":"2.0","method":"calendar.getEventsForDay"}]`,
+			expected: `[{"jsonrpc":"2.0","result":[{"ID":1,"UserID":3,"Date":"2019-09-09T00:00:00Z","Description":"standup","Title":"","Location":"","Category":"","Tags":[],"Color":"","ReadOnly":false}],"id":4}]`,
+		},
+		{
+			name:     "create event with tags",
+			request:  `{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"user_id":3,"date":"2019-09-20","title":"Retro","category":"work","tags":["team"," sprint ","team"],"color":"#FFAA00"},"id":5}`,
+			expected: `{"jsonrpc":"2.0","result":{"ID":2,"UserID":3,"Date":"2019-09-20T00:00:00Z","Description":"","Title":"Retro","Location":"","Category":"work","Tags":["team","sprint"],"Color":"#ffaa00","ReadOnly":false},"id":5}`,
+		},
+		{
+			name:     "filter by tag",
+			request:  `{"jsonrpc":"2.0","method":"calendar.getEventsForMonth","params":{"date":"2019-09-01","tag":"sprint"},"id":6}`,
+			expected: `{"jsonrpc":"2.0","result":[{"ID":2,"UserID":3,"Date":"2019-09-20T00:00:00Z","Description":"","Title":"Retro","Location":"","Category":"work","Tags":["team","sprint"],"Color":"#ffaa00","ReadOnly":false}],"id":6}`,
+		},
+	}
+
+	for _, test := range tests {
+		result := string(h.Handle(ctx, []byte(test.request)))
+		if result != test.expected {
+			t.Errorf("%s: Handle() = %s, expected %s", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestJsonRpcServeConn(t *testing.T) {
+	h := NewJsonRpcCalendarHandler(builder.NewApplication(context.Background()))
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go h.ServeConn(context.Background(), server)
+
+	requests := []string{
+		`{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"user_id":1,"date":"2019-09-09","description":"tcp"},"id":1}`,
+		`{"jsonrpc":"2.0","method":"calendar.getEventsForWeek","params":{"date":"2019-09-10"},"id":2}`,
+	}
+
+	reader := bufio.NewReader(client)
+
+	for i, request := range requests {
+		if _, err := client.Write([]byte(request + "\n")); err != nil {
+			t.Fatalf("write request %d error = %v", i, err)
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read response %d error = %v", i, err)
+		}
+
+		response := struct {
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}{}
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("response %d is not JSON: %v", i, err)
+		}
+		if response.Error != nil || !strings.Contains(string(response.Result), `"Description":"tcp"`) {
+			t.Errorf("response %d = %s, expected event in result", i, line)
+		}
+	}
+}
+
+func TestJsonRpcCreateEventIgnoresEventID(t *testing.T) {
+	h := NewJsonRpcCalendarHandler(builder.NewApplication(context.Background()))
+
+	owner := context.WithValue(context.Background(), userIDContextKey, 1)
+	other := context.WithValue(context.Background(), userIDContextKey, 2)
+
+	h.Handle(owner, []byte(`{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"date":"2019-09-09","description":"private"},"id":1}`))
+
+	// Пользователь 2 передаёт ID чужого события: создаётся новое событие, а не перезаписывается событие пользователя 1
+	result := string(h.Handle(other, []byte(`{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"event_id":1,"date":"2019-09-09","description":"stolen"},"id":2}`)))
+	if !strings.Contains(result, `"ID":2,"UserID":2`) {
+		t.Errorf("create with foreign event_id = %s, expected new event 2", result)
+	}
+
+	result = string(h.Handle(owner, []byte(`{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"date":"2019-09-09"},"id":3}`)))
+	if !strings.Contains(result, `"ID":1,"UserID":1,"Date":"2019-09-09T00:00:00Z","Description":"private"`) || strings.Contains(result, "stolen") {
+		t.Errorf("owner events = %s, expected only the untouched event 1", result)
+	}
+}
+
+func TestJsonRpcResolvesTenantFromParams(t *testing.T) {
+	config := builder.DefaultConfig()
+	config.RequireTenantToken = true
+
+	app := builder.NewApplicationWithConfig(context.Background(), config)
+	h := NewJsonRpcCalendarHandler(app)
+	ctx := context.Background()
+
+	tenant, err := app.CreateTenant.Execute(ctx, domain.Tenant{ID: "team-a", Name: "Team A"})
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		request  string
+		contains string
+	}{
+		{
+			name:     "tenant id without token",
+			request:  `{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"tenant_id":"team-a","user_id":3,"date":"2019-09-09","description":"leak"},"id":1}`,
+			contains: `"data":{"code":"forbidden"}`,
+		},
+		{
+			name:     "invalid token",
+			request:  `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"tenant_token":"nope","date":"2019-09-09"},"id":2}`,
+			contains: `"data":{"code":"forbidden"}`,
+		},
+		{
+			name:     "tenant token",
+			request:  `{"jsonrpc":"2.0","method":"calendar.createEvent","params":{"tenant_token":"` + tenant.Token + `","user_id":3,"date":"2019-09-09","description":"team"},"id":3}`,
+			contains: `"ID":1,"UserID":3`,
+		},
+		{
+			name:     "tenant events are not visible to default tenant",
+			request:  `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"date":"2019-09-09"},"id":4}`,
+			contains: `"result":[]`,
+		},
+		{
+			name:     "tenant events",
+			request:  `{"jsonrpc":"2.0","method":"calendar.getEventsForDay","params":{"tenant_id":"team-a","tenant_token":"` + tenant.Token + `","date":"2019-09-09"},"id":5}`,
+			contains: `"Description":"team"`,
+		},
+	}
+
+	for _, test := range tests {
+		result := string(h.Handle(ctx, []byte(test.request)))
+		if !strings.Contains(result, test.contains) {
+			t.Errorf("%s: Handle() = %s, expected %s", test.name, result, test.contains)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// Спецификация OpenAPI 3, описывающая все маршруты из CustomRegisterHandlers
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func (h HttpCalendarHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPISpec)
+}
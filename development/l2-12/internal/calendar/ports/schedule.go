@@ -0,0 +1,287 @@
+package ports
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Формат времени суток в рабочих часах
+const clockLayout = "15:04"
+
+type jsonWorkingHours struct {
+	UserID   int      `json:"user_id"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Weekdays []string `json:"weekdays"`
+}
+
+type jsonAvailability struct {
+	Date    string `json:"date"`
+	Working bool   `json:"working"`
+	Start   string `json:"start,omitempty"`
+	End     string `json:"end,omitempty"`
+	Holiday string `json:"holiday,omitempty"`
+	Events  int    `json:"events"`
+}
+
+func newJsonWorkingHours(userID int, hours domain.WorkingHours) jsonWorkingHours {
+	weekdays := make([]string, 0, len(hours.Weekdays))
+	for _, weekday := range hours.Weekdays {
+		weekdays = append(weekdays, strings.ToLower(weekday.String()[:3]))
+	}
+
+	return jsonWorkingHours{
+		UserID:   userID,
+		Start:    formatClock(hours.Start),
+		End:      formatClock(hours.End),
+		Weekdays: weekdays,
+	}
+}
+
+func (h jsonWorkingHours) toDomain() (domain.WorkingHours, error) {
+	hours := domain.DefaultWorkingHours()
+
+	var err error
+
+	if h.Start != "" {
+		if hours.Start, err = parseClock(h.Start); err != nil {
+			return hours, err
+		}
+	}
+
+	if h.End != "" {
+		if hours.End, err = parseClock(h.End); err != nil {
+			return hours, err
+		}
+	}
+
+	if len(h.Weekdays) > 0 {
+		if hours.Weekdays, err = ParseWeekdays(h.Weekdays); err != nil {
+			return hours, err
+		}
+	}
+
+	return hours, nil
+}
+
+// Availability возвращает рабочее время пользователя по дням: ?user_id=3&date=2019-09-09&period=week
+func (h HttpCalendarHandler) Availability(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	// Валиадции и парсинг параметров
+	event, statusCode, errMessage := h.validationAndParse(r)
+	if statusCode != 200 {
+		// Если ошибка во входных данных, возвращаем HTTP 400
+		h.mapToResponse(w, statusCode, nil, errMessage)
+		return
+	}
+
+	period := r.Form.Get("period")
+	if period == "" {
+		period = periodDay
+	}
+
+	days, err := h.service.availability(r.Context(), event.UserID, period, event.Date)
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	result := make([]jsonAvailability, 0, len(days))
+	for _, day := range days {
+		availability := jsonAvailability{
+			Date:    day.Date.Format(dateLayout),
+			Working: day.Working,
+			Holiday: day.Holiday,
+			Events:  day.Events,
+		}
+		if day.Working {
+			availability.Start = formatClock(day.Start.Sub(day.Date))
+			availability.End = formatClock(day.End.Sub(day.Date))
+		}
+
+		result = append(result, availability)
+	}
+
+	h.mapToResponse(w, http.StatusOK, result, "")
+}
+
+// WorkingHours возвращает (GET) или задаёт (POST) рабочее время пользователя
+func (h HttpCalendarHandler) WorkingHours(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+		if err != nil && r.URL.Query().Get("user_id") != "" {
+			// Если ошибка валидации входных данных, возвращаем HTTP 400
+			h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+
+		// Без user_id используется пользователь из клиентского сертификата
+		if userID == 0 {
+			userID, _ = UserIDFromContext(r.Context())
+		}
+
+		hours, err := h.service.workingHours(r.Context(), userID)
+		if err != nil {
+			// Ошибка переводится в HTTP код по её типу
+			h.mapErrorToResponse(w, err)
+			return
+		}
+
+		h.mapToResponse(w, http.StatusOK, newJsonWorkingHours(userID, hours), "")
+	case http.MethodPost:
+		in, err := h.parseWorkingHours(r)
+		if err != nil {
+			// Если ошибка во входных данных, возвращаем HTTP 400
+			h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+
+		hours, err := in.toDomain()
+		if err != nil {
+			h.mapErrorToResponse(w, err)
+			return
+		}
+
+		// Без user_id используется пользователь из клиентского сертификата
+		if in.UserID == 0 {
+			in.UserID, _ = UserIDFromContext(r.Context())
+		}
+
+		hours, err = h.service.setWorkingHours(r.Context(), in.UserID, hours)
+		if err != nil {
+			// Ошибка переводится в HTTP код по её типу
+			h.mapErrorToResponse(w, err)
+			return
+		}
+
+		h.mapToResponse(w, http.StatusOK, newJsonWorkingHours(in.UserID, hours), "")
+	default:
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+	}
+}
+
+// Разбирает рабочее время из формы (weekdays через запятую) или из JSON
+func (h HttpCalendarHandler) parseWorkingHours(r *http.Request) (jsonWorkingHours, error) {
+	in := jsonWorkingHours{}
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		err := json.NewDecoder(r.Body).Decode(&in)
+		return in, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return in, err
+	}
+
+	if value := r.PostForm.Get("user_id"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			return in, err
+		}
+		in.UserID = userID
+	}
+
+	in.Start = r.PostForm.Get("start")
+	in.End = r.PostForm.Get("end")
+
+	for _, value := range r.PostForm["weekdays"] {
+		for _, weekday := range strings.Split(value, ",") {
+			if weekday = strings.TrimSpace(weekday); weekday != "" {
+				in.Weekdays = append(in.Weekdays, weekday)
+			}
+		}
+	}
+
+	return in, nil
+}
+
+// ParseWeekday разбирает день недели: английское название (monday, mon) или номер от 0 (воскресенье) до 6
+func ParseWeekday(value string) (time.Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if number, err := strconv.Atoi(value); err == nil && number >= 0 && number <= 6 {
+		return time.Weekday(number), nil
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if value == name || value == name[:3] {
+			return weekday, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unknown weekday %q", errInvalidInput, value)
+}
+
+// ParseWeekdays разбирает список дней недели, убирая повторы
+func ParseWeekdays(values []string) ([]time.Weekday, error) {
+	weekdays := make([]time.Weekday, 0, len(values))
+	seen := make(map[time.Weekday]bool, len(values))
+
+	for _, value := range values {
+		weekday, err := ParseWeekday(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[weekday] {
+			seen[weekday] = true
+			weekdays = append(weekdays, weekday)
+		}
+	}
+
+	return weekdays, nil
+}
+
+// ParseWorkingHours разбирает рабочее время вида 09:00-18:00 для дней weekdays
+func ParseWorkingHours(value string, weekdays []time.Weekday) (domain.WorkingHours, error) {
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return domain.WorkingHours{}, fmt.Errorf("%w: working hours must look like 09:00-18:00", errInvalidInput)
+	}
+
+	hours := domain.WorkingHours{Weekdays: weekdays}
+
+	var err error
+
+	if hours.Start, err = parseClock(start); err != nil {
+		return hours, err
+	}
+	if hours.End, err = parseClock(end); err != nil {
+		return hours, err
+	}
+
+	return hours, hours.Validate()
+}
+
+// Время суток 15:04 как смещение от полуночи. 24:00 допускается как конец дня
+func parseClock(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	clock, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time %q, expected HH:MM", errInvalidInput, value)
+	}
+
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func formatClock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
@@ -0,0 +1,315 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Ошибка входных данных: не хватает обязательных полей или они не разбираются
+var errInvalidInput = errors.New("bad request")
+
+// Операции календаря, общие для всех транспортов (HTTP, JSON-RPC).
+// Проверяют обязательные поля и права пользователя и вызывают сценарии бизнес-логики
+type calendarService struct {
+	app *builder.Application
+}
+
+func newCalendarService(app *builder.Application) calendarService {
+	return calendarService{app: app}
+}
+
+func (s calendarService) getEvent(ctx context.Context, eventID int) (domain.Event, error) {
+	event, err := s.app.GetEventByID.Execute(ctx, eventID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeEvent(ctx, &event) {
+		return domain.Event{}, fmt.Errorf("%w: event belongs to another user", domain.ErrForbidden)
+	}
+
+	return event, nil
+}
+
+func (s calendarService) createEvent(ctx context.Context, event domain.Event) (domain.Event, error) {
+	// ID нового события выдаёт хранилище. Переданный клиентом ID переписал бы существующее, в том числе чужое, событие
+	event.ID = 0
+
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeEvent(ctx, &event) {
+		return domain.Event{}, fmt.Errorf("%w: event belongs to another user", domain.ErrForbidden)
+	}
+
+	// Проверка обязательных полей. Старые клиенты передают только description, новые - title
+	if event.UserID == 0 || event.Date.IsZero() || (event.Description == "" && event.Title == "") {
+		return domain.Event{}, fmt.Errorf("%w: user_id, date and description or title are required", errInvalidInput)
+	}
+
+	event, err := normalizeEvent(event)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	id, err := s.app.CreateEvent.Execute(ctx, event)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	event.ID = id
+
+	return event, nil
+}
+
+func (s calendarService) updateEvent(ctx context.Context, event domain.Event) (domain.Event, error) {
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeEvent(ctx, &event) {
+		return domain.Event{}, fmt.Errorf("%w: event belongs to another user", domain.ErrForbidden)
+	}
+
+	// Проверка обязательных полей. Старые клиенты передают только description, новые - title
+	if event.ID == 0 || event.UserID == 0 || event.Date.IsZero() || (event.Description == "" && event.Title == "") {
+		return domain.Event{}, fmt.Errorf("%w: event_id, user_id, date and description or title are required", errInvalidInput)
+	}
+
+	event, err := normalizeEvent(event)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	storedEvent, err := s.app.GetEventByID.Execute(ctx, event.ID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	// Чужое событие нельзя переписать на себя
+	if !authorizeEvent(ctx, &storedEvent) {
+		return domain.Event{}, fmt.Errorf("%w: event belongs to another user", domain.ErrForbidden)
+	}
+
+	if err := s.app.UpdateEvent.Execute(ctx, event); err != nil {
+		return domain.Event{}, err
+	}
+
+	return event, nil
+}
+
+func (s calendarService) deleteEvent(ctx context.Context, eventID int) (domain.Event, error) {
+	// Проверка обязательных полей
+	if eventID == 0 {
+		return domain.Event{}, fmt.Errorf("%w: event_id is required", errInvalidInput)
+	}
+
+	event, err := s.app.GetEventByID.Execute(ctx, eventID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeEvent(ctx, &event) {
+		return domain.Event{}, fmt.Errorf("%w: event belongs to another user", domain.ErrForbidden)
+	}
+
+	if err := s.app.DeleteEvent.Execute(ctx, event.ID); err != nil {
+		return domain.Event{}, err
+	}
+
+	return event, nil
+}
+
+func (s calendarService) uploadAttachment(ctx context.Context, eventID int, name, contentType string, content io.Reader) (domain.Attachment, error) {
+	// Проверка обязательных полей
+	if eventID == 0 {
+		return domain.Attachment{}, fmt.Errorf("%w: event_id is required", errInvalidInput)
+	}
+
+	// Событие должно существовать и принадлежать пользователю из сертификата
+	if _, err := s.getEvent(ctx, eventID); err != nil {
+		return domain.Attachment{}, err
+	}
+
+	return s.app.UploadAttachment.Execute(ctx, eventID, name, contentType, content)
+}
+
+func (s calendarService) attachments(ctx context.Context, eventID int) ([]domain.Attachment, error) {
+	// Проверка обязательных полей
+	if eventID == 0 {
+		return nil, fmt.Errorf("%w: event_id is required", errInvalidInput)
+	}
+
+	if _, err := s.getEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	return s.app.ListAttachments.Execute(ctx, eventID)
+}
+
+func (s calendarService) openAttachment(ctx context.Context, eventID int, attachmentID string) (domain.Attachment, io.ReadCloser, error) {
+	// Проверка обязательных полей
+	if eventID == 0 || attachmentID == "" {
+		return domain.Attachment{}, nil, fmt.Errorf("%w: event_id and attachment_id are required", errInvalidInput)
+	}
+
+	if _, err := s.getEvent(ctx, eventID); err != nil {
+		return domain.Attachment{}, nil, err
+	}
+
+	return s.app.OpenAttachment.Execute(ctx, eventID, attachmentID)
+}
+
+// Периоды, за которые можно получить список событий
+const (
+	periodDay   = "day"
+	periodWeek  = "week"
+	periodMonth = "month"
+)
+
+func (s calendarService) eventsFor(ctx context.Context, period string, date time.Time, filter domain.EventFilter) ([]domain.Event, error) {
+	// Проверка обязательных полей
+	if date.IsZero() {
+		return nil, fmt.Errorf("%w: date is required", errInvalidInput)
+	}
+
+	var (
+		events []domain.Event
+		err    error
+	)
+
+	switch period {
+	case periodDay:
+		events, err = s.app.GetEventsForDay.Execute(ctx, date, filter)
+	case periodWeek:
+		events, err = s.app.GetEventsForWeek.Execute(ctx, date, filter)
+	case periodMonth:
+		events, err = s.app.GetEventsForMonth.Execute(ctx, date, filter)
+	default:
+		return nil, fmt.Errorf("%w: unknown period %q", errInvalidInput, period)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ownEvents(ctx, events), nil
+}
+
+// Возвращает границы [from, to) периода, в который входит date
+func (s calendarService) periodRange(period string, date time.Time) (time.Time, time.Time, error) {
+	switch period {
+	case periodDay:
+		from := domain.StartOfDay(date)
+		return from, from.AddDate(0, 0, 1), nil
+	case periodWeek:
+		from, to := domain.WeekRange(date, s.app.WeekStart)
+		return from, to, nil
+	case periodMonth:
+		from, to := domain.MonthRange(date)
+		return from, to, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: unknown period %q", errInvalidInput, period)
+	}
+}
+
+func (s calendarService) workingHours(ctx context.Context, userID int) (domain.WorkingHours, error) {
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeUser(ctx, &userID) {
+		return domain.WorkingHours{}, fmt.Errorf("%w: working hours belong to another user", domain.ErrForbidden)
+	}
+
+	// Проверка обязательных полей
+	if userID == 0 {
+		return domain.WorkingHours{}, fmt.Errorf("%w: user_id is required", errInvalidInput)
+	}
+
+	return s.app.GetWorkingHours.Execute(ctx, userID)
+}
+
+func (s calendarService) setWorkingHours(ctx context.Context, userID int, hours domain.WorkingHours) (domain.WorkingHours, error) {
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeUser(ctx, &userID) {
+		return domain.WorkingHours{}, fmt.Errorf("%w: working hours belong to another user", domain.ErrForbidden)
+	}
+
+	// Проверка обязательных полей
+	if userID == 0 {
+		return domain.WorkingHours{}, fmt.Errorf("%w: user_id is required", errInvalidInput)
+	}
+
+	if err := s.app.SetWorkingHours.Execute(ctx, userID, hours); err != nil {
+		return domain.WorkingHours{}, err
+	}
+
+	return hours, nil
+}
+
+// Рабочее время пользователя по дням периода с учётом праздников
+func (s calendarService) availability(ctx context.Context, userID int, period string, date time.Time) ([]domain.Availability, error) {
+	// Проверка прав пользователя из клиентского сертификата
+	if !authorizeUser(ctx, &userID) {
+		return nil, fmt.Errorf("%w: availability of another user", domain.ErrForbidden)
+	}
+
+	// Проверка обязательных полей
+	if userID == 0 || date.IsZero() {
+		return nil, fmt.Errorf("%w: user_id and date are required", errInvalidInput)
+	}
+
+	from, to, err := s.periodRange(period, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.app.GetAvailability.Execute(ctx, userID, from, to)
+}
+
+// Цвет события в формате #rrggbb
+var eventColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Приводит необязательные поля события к единому виду и проверяет их
+func normalizeEvent(event domain.Event) (domain.Event, error) {
+	event.Title = strings.TrimSpace(event.Title)
+	event.Location = strings.TrimSpace(event.Location)
+	event.Category = strings.TrimSpace(event.Category)
+	event.Color = strings.ToLower(strings.TrimSpace(event.Color))
+
+	if event.Color != "" && !eventColorPattern.MatchString(event.Color) {
+		return event, fmt.Errorf("%w: color must be in #rrggbb format", errInvalidInput)
+	}
+
+	event.Tags = normalizeTags(event.Tags)
+
+	return event, nil
+}
+
+// Убирает пустые теги и повторы, сохраняя порядок
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+// Разбирает теги из повторяющихся параметров и списков через запятую: tags=a,b&tags=c
+func splitTags(values []string) []string {
+	tags := make([]string, 0, len(values))
+
+	for _, value := range values {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+
+	return normalizeTags(tags)
+}
@@ -0,0 +1,183 @@
+package ports
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Заголовок с ID арендатора. Вместо него можно передать токен арендатора в Authorization: Bearer
+const tenantHeader = "X-Tenant-ID"
+
+type jsonTenant struct {
+	ID        string    `json:"tenant_id"`
+	Name      string    `json:"name"`
+	MaxEvents int       `json:"max_events"`
+	CreatedAt time.Time `json:"created_at"`
+	// Токен показывается только в ответе на создание арендатора
+	Token string `json:"token,omitempty"`
+}
+
+func newJsonTenant(tenant domain.Tenant) jsonTenant {
+	return jsonTenant{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		MaxEvents: tenant.MaxEvents,
+		CreatedAt: tenant.CreatedAt,
+	}
+}
+
+// MiddlewareTenant определяет арендатора по токену или заголовку X-Tenant-ID и кладёт его в контекст запроса.
+// Запросы без арендатора обслуживаются арендатором по умолчанию
+func (h HttpCalendarHandler) MiddlewareTenant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := h.app.ResolveTenant.Execute(r.Context(), strings.TrimSpace(r.Header.Get(tenantHeader)), bearerToken(r))
+		if err != nil {
+			// Неизвестный арендатор или чужой токен переводятся в HTTP код по типу ошибки
+			h.mapErrorToResponse(w, err)
+			return
+		}
+
+		next(w, r.WithContext(domain.WithTenant(r.Context(), tenant.ID)))
+	}
+}
+
+// MiddlewareAdmin пропускает только запросы с токеном администратора в Authorization: Bearer
+func (h HttpCalendarHandler) MiddlewareAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.app.AdminToken == "" {
+			// Без настроенного токена административные методы недоступны
+			h.mapToResponse(w, http.StatusForbidden, nil, "admin api is disabled")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(h.app.AdminToken)) != 1 {
+			h.mapToResponse(w, http.StatusForbidden, nil, "invalid admin token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// CreateTenant создаёт арендатора: tenant_id, name и max_events из формы или JSON
+func (h HttpCalendarHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodPost {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	in, err := h.parseTenant(r)
+	if err != nil {
+		// Если ошибка во входных данных, возвращаем HTTP 400
+		h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	tenant, err := h.app.CreateTenant.Execute(r.Context(), domain.Tenant{ID: in.ID, Name: in.Name, MaxEvents: in.MaxEvents})
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	result := newJsonTenant(tenant)
+	result.Token = tenant.Token
+
+	h.mapToResponse(w, http.StatusOK, result, "")
+}
+
+// ListTenants возвращает всех арендаторов без токенов
+func (h HttpCalendarHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodGet {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	tenants, err := h.app.ListTenants.Execute(r.Context())
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	result := make([]jsonTenant, 0, len(tenants))
+	for _, tenant := range tenants {
+		result = append(result, newJsonTenant(tenant))
+	}
+
+	h.mapToResponse(w, http.StatusOK, result, "")
+}
+
+// DeleteTenant удаляет арендатора tenant_id со всеми его данными
+func (h HttpCalendarHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	// Проверка на соответствие метода запроса
+	if r.Method != http.MethodPost {
+		h.mapToResponse(w, http.StatusMethodNotAllowed, nil, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	in, err := h.parseTenant(r)
+	if err == nil && in.ID == "" {
+		err = errors.New("tenant_id is required")
+	}
+	if err != nil {
+		// Если ошибка во входных данных, возвращаем HTTP 400
+		h.mapToResponse(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	tenant, err := h.app.DeleteTenant.Execute(r.Context(), in.ID)
+	if err != nil {
+		// Ошибка переводится в HTTP код по её типу
+		h.mapErrorToResponse(w, err)
+		return
+	}
+
+	h.mapToResponse(w, http.StatusOK, newJsonTenant(tenant), "")
+}
+
+// Разбирает арендатора из формы или из JSON
+func (h HttpCalendarHandler) parseTenant(r *http.Request) (jsonTenant, error) {
+	in := jsonTenant{}
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		err := json.NewDecoder(r.Body).Decode(&in)
+		return in, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return in, err
+	}
+
+	in.ID = r.PostForm.Get("tenant_id")
+	in.Name = r.PostForm.Get("name")
+
+	if value := r.PostForm.Get("max_events"); value != "" {
+		maxEvents, err := strconv.Atoi(value)
+		if err != nil {
+			return in, err
+		}
+		in.MaxEvents = maxEvents
+	}
+
+	return in, nil
+}
+
+// Токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,264 @@
+package ports
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Форматы выгрузки и загрузки событий
+const (
+	TransferFormatCSV    = "csv"
+	TransferFormatNDJSON = "ndjson"
+)
+
+// Заголовок CSV файла с событиями
+var csvHeader = []string{"event_id", "user_id", "date", "description", "title", "location", "category", "tags", "color"}
+
+// Заголовок выгрузок, сделанных до появления title, location, category, tags и color
+var legacyCSVHeader = csvHeader[:4]
+
+// ImportLineError - ошибка валидации одной строки импортируемого файла
+type ImportLineError struct {
+	Line int
+	Err  error
+}
+
+func (e ImportLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e ImportLineError) Unwrap() error {
+	return e.Err
+}
+
+// TransferFormatFromPath определяет формат по расширению файла
+func TransferFormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return TransferFormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return TransferFormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("can't detect format of %q, use csv or ndjson", path)
+	}
+}
+
+// ExportEvents выгружает все события хранилища в w и возвращает их количество
+func ExportEvents(ctx context.Context, app *builder.Application, w io.Writer, format string) (int, error) {
+	events, err := app.ListEvents.Execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	switch format {
+	case TransferFormatCSV:
+		writer := csv.NewWriter(w)
+
+		if err := writer.Write(csvHeader); err != nil {
+			return 0, err
+		}
+
+		for _, event := range events {
+			record := []string{
+				strconv.Itoa(event.ID),
+				strconv.Itoa(event.UserID),
+				event.Date.Format(time.RFC3339),
+				event.Description,
+				event.Title,
+				event.Location,
+				event.Category,
+				strings.Join(event.Tags, ","),
+				event.Color,
+			}
+			if err := writer.Write(record); err != nil {
+				return 0, err
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return 0, err
+		}
+	case TransferFormatNDJSON:
+		encoder := json.NewEncoder(w)
+
+		for _, event := range events {
+			if err := encoder.Encode(newJsonEvent(event)); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unknown export format %q", format)
+	}
+
+	return len(events), nil
+}
+
+// ImportEvents загружает события из r с сохранением их ID.
+// Невалидные строки пропускаются и возвращаются в списке ошибок, остальные сохраняются
+func ImportEvents(ctx context.Context, app *builder.Application, r io.Reader, format string) (int, []ImportLineError, error) {
+	var (
+		imported   int
+		lineErrors []ImportLineError
+		seen       = make(map[int]int)
+	)
+
+	// Проверяет и сохраняет одно событие из строки line
+	restore := func(line int, event domain.Event) error {
+		if err := validateImportedEvent(event); err != nil {
+			return err
+		}
+
+		event, err := normalizeEvent(event)
+		if err != nil {
+			return err
+		}
+
+		if prev, ok := seen[event.ID]; ok {
+			return fmt.Errorf("%w: duplicate event_id %d, first seen on line %d", domain.ErrConflict, event.ID, prev)
+		}
+
+		if err := app.RestoreEvent.Execute(ctx, event); err != nil {
+			return err
+		}
+
+		seen[event.ID] = line
+		imported++
+
+		return nil
+	}
+
+	switch format {
+	case TransferFormatCSV:
+		reader := csv.NewReader(r)
+		// Количество полей во всех строках должно совпадать с заголовком
+		reader.FieldsPerRecord = 0
+
+		header, err := reader.Read()
+		if err != nil {
+			return 0, nil, fmt.Errorf("read csv header: %w", err)
+		}
+		if !slices.Equal(header, csvHeader) && !slices.Equal(header, legacyCSVHeader) {
+			return 0, nil, fmt.Errorf("unexpected csv header %q, expected %q", strings.Join(header, ","), strings.Join(csvHeader, ","))
+		}
+
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				// Синтаксическая ошибка в строке не мешает читать следующие строки
+				parseErr := &csv.ParseError{}
+				if errors.As(err, &parseErr) {
+					lineErrors = append(lineErrors, ImportLineError{Line: parseErr.StartLine, Err: parseErr.Err})
+					continue
+				}
+				return imported, lineErrors, err
+			}
+
+			line, _ := reader.FieldPos(0)
+
+			event, err := parseCSVEvent(record)
+			if err == nil {
+				err = restore(line, event)
+			}
+			if err != nil {
+				lineErrors = append(lineErrors, ImportLineError{Line: line, Err: err})
+			}
+		}
+	case TransferFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			jEvent := jsonEvent{}
+
+			decoder := json.NewDecoder(strings.NewReader(text))
+			decoder.DisallowUnknownFields()
+
+			if err := decoder.Decode(&jEvent); err != nil {
+				lineErrors = append(lineErrors, ImportLineError{Line: line, Err: err})
+				continue
+			}
+
+			if err := restore(line, jEvent.toDomain()); err != nil {
+				lineErrors = append(lineErrors, ImportLineError{Line: line, Err: err})
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return imported, lineErrors, err
+		}
+	default:
+		return 0, nil, fmt.Errorf("unknown import format %q", format)
+	}
+
+	return imported, lineErrors, nil
+}
+
+func parseCSVEvent(record []string) (domain.Event, error) {
+	event := domain.Event{Description: record[3]}
+
+	if len(record) == len(csvHeader) {
+		event.Title = record[4]
+		event.Location = record[5]
+		event.Category = record[6]
+		event.Tags = splitTags([]string{record[7]})
+		event.Color = record[8]
+	}
+
+	var err error
+
+	if event.ID, err = strconv.Atoi(record[0]); err != nil {
+		return domain.Event{}, fmt.Errorf("invalid event_id: %w", err)
+	}
+
+	if event.UserID, err = strconv.Atoi(record[1]); err != nil {
+		return domain.Event{}, fmt.Errorf("invalid user_id: %w", err)
+	}
+
+	if event.Date, err = time.Parse(time.RFC3339, record[2]); err != nil {
+		// Допускаем короткий формат даты, как в параметрах HTTP API
+		if event.Date, err = time.Parse(dateLayout, record[2]); err != nil {
+			return domain.Event{}, fmt.Errorf("invalid date: %w", err)
+		}
+	}
+
+	return event, nil
+}
+
+// Импортируемое событие должно содержать те же обязательные поля, что и /update_event
+func validateImportedEvent(event domain.Event) error {
+	switch {
+	case event.ID <= 0:
+		return fmt.Errorf("%w: event_id must be positive", domain.ErrValidation)
+	case event.UserID <= 0:
+		return fmt.Errorf("%w: user_id must be positive", domain.ErrValidation)
+	case event.Date.IsZero():
+		return fmt.Errorf("%w: date is required", domain.ErrValidation)
+	case event.Description == "" && event.Title == "":
+		return fmt.Errorf("%w: description or title is required", domain.ErrValidation)
+	}
+
+	return nil
+}
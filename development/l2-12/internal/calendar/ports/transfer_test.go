@@ -0,0 +1,156 @@
+package ports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+func TestTransferRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	// ID идут не подряд, чтобы было видно, что они сохраняются, а не выдаются заново
+	events := []domain.Event{
+		{ID: 3, UserID: 1, Date: time.Date(2019, 9, 9, 10, 30, 0, 0, time.UTC), Description: "standup"},
+		{ID: 17, UserID: 2, Date: time.Date(2019, 9, 20, 0, 0, 0, 0, time.UTC), Title: "Retro, sprint 5", Location: "room \"A\"", Category: "work", Tags: []string{"team", "sprint"}, Color: "#ffaa00"},
+	}
+
+	for _, format := range []string{TransferFormatCSV, TransferFormatNDJSON} {
+		source := builder.NewApplication(ctx)
+		for _, event := range events {
+			if err := source.RestoreEvent.Execute(ctx, event); err != nil {
+				t.Fatalf("RestoreEvent() error = %v", err)
+			}
+		}
+
+		buf := &bytes.Buffer{}
+		if exported, err := ExportEvents(ctx, source, buf, format); err != nil || exported != len(events) {
+			t.Fatalf("%s: ExportEvents() = %d, %v, expected %d", format, exported, err, len(events))
+		}
+
+		target := builder.NewApplication(ctx)
+
+		imported, lineErrors, err := ImportEvents(ctx, target, buf, format)
+		if err != nil || imported != len(events) || len(lineErrors) != 0 {
+			t.Fatalf("%s: ImportEvents() = %d, %v, %v, expected %d events", format, imported, lineErrors, err, len(events))
+		}
+
+		restored, err := target.ListEvents.Execute(ctx)
+		if err != nil {
+			t.Fatalf("%s: ListEvents() error = %v", format, err)
+		}
+
+		// Пустой список тегов после импорта равен отсутствующему
+		for i := range restored {
+			if len(restored[i].Tags) == 0 {
+				restored[i].Tags = nil
+			}
+		}
+
+		if !reflect.DeepEqual(restored, events) {
+			t.Errorf("%s: events after round trip = %+v, expected %+v", format, restored, events)
+		}
+	}
+}
+
+func TestImportEventsLineErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		imported int
+		// Номер строки и ожидаемая ошибка для каждой отклонённой строки
+		lines    []int
+		expected []error
+	}{
+		{
+			name:   "csv",
+			format: TransferFormatCSV,
+			input: "event_id,user_id,date,description,title,location,category,tags,color\n" +
+				"1,1,2019-09-09,standup,,,,,\n" +
+				"1,2,2019-09-10,duplicate,,,,,\n" +
+				"x,1,2019-09-09,bad id,,,,,\n" +
+				"2,0,2019-09-09,no user,,,,,\n" +
+				"3,1,,no date,,,,,\n" +
+				"4,1,2019-09-09,,,,,,\n" +
+				"5,1,2019-09-09,bad color,,,,,red\n" +
+				"6,1,2019-09-09,short\n" +
+				"7,1,2019-09-09,ok,,,,,\n",
+			imported: 2,
+			lines:    []int{3, 4, 5, 6, 7, 8, 9},
+			expected: []error{domain.ErrConflict, nil, domain.ErrValidation, nil, domain.ErrValidation, errInvalidInput, nil},
+		},
+		{
+			name:   "legacy csv header",
+			format: TransferFormatCSV,
+			input: "event_id,user_id,date,description\n" +
+				"1,1,2019-09-09,standup\n",
+			imported: 1,
+		},
+		{
+			name:   "ndjson",
+			format: TransferFormatNDJSON,
+			input: `{"event_id":1,"user_id":1,"date":"2019-09-09T00:00:00Z","description":"standup"}` + "\n" +
+				"\n" +
+				`{"event_id":1,"user_id":1,"date":"2019-09-09T00:00:00Z","description":"duplicate"}` + "\n" +
+				`{"event_id":2,"user_id":1,"date":"2019-09-09T00:00:00Z"}` + "\n" +
+				`{"event_id":3,"user_id":1,"date":"2019-09-09T00:00:00Z","description":"x","owner":"me"}` + "\n" +
+				`{"event_id":4,` + "\n" +
+				`{"event_id":5,"user_id":1,"date":"2019-09-09T00:00:00Z","description":"ok"}` + "\n",
+			imported: 2,
+			lines:    []int{3, 4, 5, 6},
+			expected: []error{domain.ErrConflict, domain.ErrValidation, nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		app := builder.NewApplication(ctx)
+
+		imported, lineErrors, err := ImportEvents(ctx, app, strings.NewReader(test.input), test.format)
+		if err != nil {
+			t.Errorf("%s: ImportEvents() error = %v", test.name, err)
+			continue
+		}
+
+		if imported != test.imported {
+			t.Errorf("%s: imported = %d, expected %d", test.name, imported, test.imported)
+		}
+
+		if len(lineErrors) != len(test.lines) {
+			t.Errorf("%s: line errors = %v, expected lines %v", test.name, lineErrors, test.lines)
+			continue
+		}
+
+		for i, lineErr := range lineErrors {
+			if lineErr.Line != test.lines[i] {
+				t.Errorf("%s: error %q is on line %d, expected %d", test.name, lineErr, lineErr.Line, test.lines[i])
+			}
+			if test.expected[i] != nil && !errors.Is(lineErr, test.expected[i]) {
+				t.Errorf("%s: line %d error = %v, expected %v", test.name, lineErr.Line, lineErr, test.expected[i])
+			}
+		}
+
+		// Первое событие с повторяющимся ID не переписывается дубликатом
+		event, err := app.GetEventByID.Execute(ctx, 1)
+		if err != nil || event.Description != "standup" {
+			t.Errorf("%s: event 1 = %+v, %v, expected the first imported event", test.name, event, err)
+		}
+	}
+}
+
+func TestImportEventsRejectsUnknownHeader(t *testing.T) {
+	app := builder.NewApplication(context.Background())
+
+	_, _, err := ImportEvents(context.Background(), app, strings.NewReader("id,user,date\n1,1,2019-09-09\n"), TransferFormatCSV)
+	if err == nil || !strings.Contains(err.Error(), "unexpected csv header") {
+		t.Errorf("ImportEvents() error = %v, expected unexpected csv header", err)
+	}
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type CheckHealthUseCase struct {
+	eventRepository domain.Repository
+}
+
+func NewCheckHealthUseCase(
+	eventRepository domain.Repository,
+) *CheckHealthUseCase {
+	return &CheckHealthUseCase{
+		eventRepository: eventRepository,
+	}
+}
+
+func (uc *CheckHealthUseCase) Execute(ctx context.Context) error {
+	return uc.eventRepository.Ping(ctx)
+}
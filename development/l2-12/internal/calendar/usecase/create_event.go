@@ -7,17 +7,44 @@ import (
 )
 
 type CreateEventUseCase struct {
-	eventRepository domain.Repository
+	eventRepository      domain.Repository
+	tenantRepository     domain.TenantRepository
+	attachmentRepository domain.AttachmentRepository
+	blobStorage          domain.BlobStorage
 }
 
 func NewCreateEventUseCase(
 	eventRepository domain.Repository,
+	tenantRepository domain.TenantRepository,
+	attachmentRepository domain.AttachmentRepository,
+	blobStorage domain.BlobStorage,
 ) *CreateEventUseCase {
 	return &CreateEventUseCase{
-		eventRepository: eventRepository,
+		eventRepository:      eventRepository,
+		tenantRepository:     tenantRepository,
+		attachmentRepository: attachmentRepository,
+		blobStorage:          blobStorage,
 	}
 }
 
 func (uc *CreateEventUseCase) Execute(ctx context.Context, event domain.Event) (int, error) {
-	return uc.eventRepository.CreateEvent(ctx, event)
+	tenant, err := uc.tenantRepository.GetTenant(ctx, domain.TenantFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	// Ограничение арендатора на количество событий проверяет хранилище: отдельные подсчёт и создание
+	// позволили бы параллельным запросам превысить его
+	id, err := uc.eventRepository.CreateEventWithLimit(ctx, event, tenant.MaxEvents)
+	if err != nil {
+		return 0, err
+	}
+
+	// Хранилище выдаёт ID по кругу и вытесняет старое событие с тем же ID. Его вложения остались бы
+	// у нового события, поэтому удаляются до того, как клиент узнает ID
+	if err := deleteAttachments(ctx, uc.attachmentRepository, uc.blobStorage, id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
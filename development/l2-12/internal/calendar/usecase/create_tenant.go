@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type CreateTenantUseCase struct {
+	tenantRepository domain.TenantRepository
+}
+
+func NewCreateTenantUseCase(
+	tenantRepository domain.TenantRepository,
+) *CreateTenantUseCase {
+	return &CreateTenantUseCase{
+		tenantRepository: tenantRepository,
+	}
+}
+
+// Execute создаёт арендатора и выдаёт ему токен доступа. Токен возвращается только здесь
+func (uc *CreateTenantUseCase) Execute(ctx context.Context, tenant domain.Tenant) (domain.Tenant, error) {
+	tenant.ID = strings.ToLower(strings.TrimSpace(tenant.ID))
+	tenant.Name = strings.TrimSpace(tenant.Name)
+
+	if err := tenant.Validate(); err != nil {
+		return domain.Tenant{}, err
+	}
+
+	if tenant.Name == "" {
+		tenant.Name = tenant.ID
+	}
+
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant.Token = hex.EncodeToString(token)
+	tenant.CreatedAt = time.Now().UTC()
+
+	if err := uc.tenantRepository.CreateTenant(ctx, tenant); err != nil {
+		return domain.Tenant{}, err
+	}
+
+	return tenant, nil
+}
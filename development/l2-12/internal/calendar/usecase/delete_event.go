@@ -7,17 +7,48 @@ import (
 )
 
 type DeleteEventUseCase struct {
-	eventRepository domain.Repository
+	eventRepository      domain.Repository
+	attachmentRepository domain.AttachmentRepository
+	blobStorage          domain.BlobStorage
 }
 
 func NewDeleteEventUseCase(
 	eventRepository domain.Repository,
+	attachmentRepository domain.AttachmentRepository,
+	blobStorage domain.BlobStorage,
 ) *DeleteEventUseCase {
 	return &DeleteEventUseCase{
-		eventRepository: eventRepository,
+		eventRepository:      eventRepository,
+		attachmentRepository: attachmentRepository,
+		blobStorage:          blobStorage,
 	}
 }
 
 func (uc *DeleteEventUseCase) Execute(ctx context.Context, eventID int) error {
+	// Вложения удаляются до события: если удалить файл не удалось, событие остаётся и удаление можно повторить
+	if err := deleteAttachments(ctx, uc.attachmentRepository, uc.blobStorage, eventID); err != nil {
+		return err
+	}
+
 	return uc.eventRepository.DeleteEvent(ctx, eventID)
 }
+
+// Удаляет файлы и описания всех вложений события eventID
+func deleteAttachments(ctx context.Context, attachmentRepository domain.AttachmentRepository, blobStorage domain.BlobStorage, eventID int) error {
+	attachments, err := attachmentRepository.ListAttachments(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if err := blobStorage.Delete(ctx, attachment.BlobKey()); err != nil {
+			return err
+		}
+
+		if err := attachmentRepository.DeleteAttachment(ctx, eventID, attachment.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
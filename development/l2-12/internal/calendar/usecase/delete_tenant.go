@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type DeleteTenantUseCase struct {
+	tenantRepository     domain.TenantRepository
+	eventRepository      domain.Repository
+	attachmentRepository domain.AttachmentRepository
+	scheduleRepository   domain.ScheduleRepository
+	blobStorage          domain.BlobStorage
+}
+
+func NewDeleteTenantUseCase(
+	tenantRepository domain.TenantRepository,
+	eventRepository domain.Repository,
+	attachmentRepository domain.AttachmentRepository,
+	scheduleRepository domain.ScheduleRepository,
+	blobStorage domain.BlobStorage,
+) *DeleteTenantUseCase {
+	return &DeleteTenantUseCase{
+		tenantRepository:     tenantRepository,
+		eventRepository:      eventRepository,
+		attachmentRepository: attachmentRepository,
+		scheduleRepository:   scheduleRepository,
+		blobStorage:          blobStorage,
+	}
+}
+
+// Execute удаляет арендатора вместе с его событиями, вложениями и рабочим временем
+func (uc *DeleteTenantUseCase) Execute(ctx context.Context, tenantID string) (domain.Tenant, error) {
+	if tenantID == domain.DefaultTenantID {
+		return domain.Tenant{}, fmt.Errorf("%w: default tenant can not be deleted", domain.ErrConflict)
+	}
+
+	tenant, err := uc.tenantRepository.GetTenant(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	// Данные арендатора удаляются в его пространстве
+	tenantCtx := domain.WithTenant(ctx, tenant.ID)
+
+	events, err := uc.eventRepository.ListEvents(tenantCtx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	// Как и при удалении события, файлы удаляются раньше описаний, чтобы удаление можно было повторить
+	for _, event := range events {
+		attachments, err := uc.attachmentRepository.ListAttachments(tenantCtx, event.ID)
+		if err != nil {
+			return domain.Tenant{}, err
+		}
+
+		for _, attachment := range attachments {
+			if err := uc.blobStorage.Delete(tenantCtx, attachment.BlobKey()); err != nil {
+				return domain.Tenant{}, err
+			}
+		}
+	}
+
+	if err := uc.attachmentRepository.DeleteNamespace(tenantCtx); err != nil {
+		return domain.Tenant{}, err
+	}
+	if err := uc.scheduleRepository.DeleteNamespace(tenantCtx); err != nil {
+		return domain.Tenant{}, err
+	}
+	if err := uc.eventRepository.DeleteNamespace(tenantCtx); err != nil {
+		return domain.Tenant{}, err
+	}
+
+	if err := uc.tenantRepository.DeleteTenant(ctx, tenant.ID); err != nil {
+		return domain.Tenant{}, err
+	}
+
+	return tenant, nil
+}
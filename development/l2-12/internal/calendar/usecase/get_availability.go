@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type GetAvailabilityUseCase struct {
+	eventRepository    domain.Repository
+	scheduleRepository domain.ScheduleRepository
+}
+
+func NewGetAvailabilityUseCase(
+	eventRepository domain.Repository,
+	scheduleRepository domain.ScheduleRepository,
+) *GetAvailabilityUseCase {
+	return &GetAvailabilityUseCase{
+		eventRepository:    eventRepository,
+		scheduleRepository: scheduleRepository,
+	}
+}
+
+// Execute возвращает рабочее время пользователя по дням диапазона [from, to)
+// с учётом его рабочих часов и производственного календаря
+func (uc *GetAvailabilityUseCase) Execute(ctx context.Context, userID int, from, to time.Time) ([]domain.Availability, error) {
+	hours, err := uc.scheduleRepository.GetWorkingHours(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := uc.scheduleRepository.GetHolidays(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	holidayByDay := make(map[time.Time]domain.Holiday, len(holidays))
+	for _, holiday := range holidays {
+		holidayByDay[domain.StartOfDay(holiday.Date)] = holiday
+	}
+
+	events, err := uc.eventRepository.GetEventsForPeriod(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsByDay := make(map[time.Time]int)
+	for _, event := range events {
+		if event.UserID == userID {
+			eventsByDay[domain.StartOfDay(event.Date)]++
+		}
+	}
+
+	availability := make([]domain.Availability, 0, 31)
+
+	for day := domain.StartOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		var holiday *domain.Holiday
+		if h, ok := holidayByDay[day]; ok {
+			holiday = &h
+		}
+
+		dayAvailability := domain.DayAvailability(day, hours, holiday)
+		dayAvailability.Events = eventsByDay[day]
+
+		availability = append(availability, dayAvailability)
+	}
+
+	return availability, nil
+}
@@ -8,17 +8,32 @@ import (
 )
 
 type GetEventsForDayUseCase struct {
-	eventRepository domain.Repository
+	eventRepository    domain.Repository
+	scheduleRepository domain.ScheduleRepository
 }
 
 func NewGetEventsForDayUseCase(
 	eventRepository domain.Repository,
+	scheduleRepository domain.ScheduleRepository,
 ) *GetEventsForDayUseCase {
 	return &GetEventsForDayUseCase{
-		eventRepository: eventRepository,
+		eventRepository:    eventRepository,
+		scheduleRepository: scheduleRepository,
 	}
 }
 
-func (uc *GetEventsForDayUseCase) Execute(ctx context.Context, date time.Time) ([]domain.Event, error) {
-	return uc.eventRepository.GetEventsForDay(ctx, date)
+func (uc *GetEventsForDayUseCase) Execute(ctx context.Context, date time.Time, filter domain.EventFilter) ([]domain.Event, error) {
+	from := domain.StartOfDay(date)
+
+	holidays, err := holidayEvents(ctx, uc.scheduleRepository, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := uc.eventRepository.GetEventsForDay(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(append(holidays, events...)), nil
 }
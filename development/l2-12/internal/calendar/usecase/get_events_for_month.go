@@ -8,17 +8,32 @@ import (
 )
 
 type GetEventsForMonthUseCase struct {
-	eventRepository domain.Repository
+	eventRepository    domain.Repository
+	scheduleRepository domain.ScheduleRepository
 }
 
 func NewGetEventsForMonthUseCase(
 	eventRepository domain.Repository,
+	scheduleRepository domain.ScheduleRepository,
 ) *GetEventsForMonthUseCase {
 	return &GetEventsForMonthUseCase{
-		eventRepository: eventRepository,
+		eventRepository:    eventRepository,
+		scheduleRepository: scheduleRepository,
 	}
 }
 
-func (uc *GetEventsForMonthUseCase) Execute(ctx context.Context, date time.Time) ([]domain.Event, error) {
-	return uc.eventRepository.GetEventsForMonth(ctx, date)
+func (uc *GetEventsForMonthUseCase) Execute(ctx context.Context, date time.Time, filter domain.EventFilter) ([]domain.Event, error) {
+	from, to := domain.MonthRange(date)
+
+	holidays, err := holidayEvents(ctx, uc.scheduleRepository, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := uc.eventRepository.GetEventsForMonth(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(append(holidays, events...)), nil
 }
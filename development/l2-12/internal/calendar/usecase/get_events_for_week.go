@@ -8,17 +8,36 @@ import (
 )
 
 type GetEventsForWeekUseCase struct {
-	eventRepository domain.Repository
+	eventRepository    domain.Repository
+	scheduleRepository domain.ScheduleRepository
+	// День, с которого начинается неделя
+	weekStart time.Weekday
 }
 
 func NewGetEventsForWeekUseCase(
 	eventRepository domain.Repository,
+	scheduleRepository domain.ScheduleRepository,
+	weekStart time.Weekday,
 ) *GetEventsForWeekUseCase {
 	return &GetEventsForWeekUseCase{
-		eventRepository: eventRepository,
+		eventRepository:    eventRepository,
+		scheduleRepository: scheduleRepository,
+		weekStart:          weekStart,
 	}
 }
 
-func (uc *GetEventsForWeekUseCase) Execute(ctx context.Context, date time.Time) ([]domain.Event, error) {
-	return uc.eventRepository.GetEventsForWeek(ctx, date)
+func (uc *GetEventsForWeekUseCase) Execute(ctx context.Context, date time.Time, filter domain.EventFilter) ([]domain.Event, error) {
+	from, to := domain.WeekRange(date, uc.weekStart)
+
+	holidays, err := holidayEvents(ctx, uc.scheduleRepository, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := uc.eventRepository.GetEventsForPeriod(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(append(holidays, events...)), nil
 }
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type GetWorkingHoursUseCase struct {
+	scheduleRepository domain.ScheduleRepository
+}
+
+func NewGetWorkingHoursUseCase(
+	scheduleRepository domain.ScheduleRepository,
+) *GetWorkingHoursUseCase {
+	return &GetWorkingHoursUseCase{
+		scheduleRepository: scheduleRepository,
+	}
+}
+
+func (uc *GetWorkingHoursUseCase) Execute(ctx context.Context, userID int) (domain.WorkingHours, error) {
+	return uc.scheduleRepository.GetWorkingHours(ctx, userID)
+}
@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Возвращает праздничные выходные в диапазоне [from, to) как события только для чтения
+func holidayEvents(ctx context.Context, scheduleRepository domain.ScheduleRepository, from, to time.Time) ([]domain.Event, error) {
+	holidays, err := scheduleRepository.GetHolidays(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.Event, 0, len(holidays))
+
+	for _, holiday := range holidays {
+		if holiday.Kind == domain.DayOff {
+			events = append(events, holiday.Event())
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type ImportHolidaysUseCase struct {
+	scheduleRepository domain.ScheduleRepository
+}
+
+func NewImportHolidaysUseCase(
+	scheduleRepository domain.ScheduleRepository,
+) *ImportHolidaysUseCase {
+	return &ImportHolidaysUseCase{
+		scheduleRepository: scheduleRepository,
+	}
+}
+
+func (uc *ImportHolidaysUseCase) Execute(ctx context.Context, holidays []domain.Holiday) error {
+	return uc.scheduleRepository.AddHolidays(ctx, holidays)
+}
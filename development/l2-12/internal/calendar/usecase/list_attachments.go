@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type ListAttachmentsUseCase struct {
+	eventRepository      domain.Repository
+	attachmentRepository domain.AttachmentRepository
+}
+
+func NewListAttachmentsUseCase(
+	eventRepository domain.Repository,
+	attachmentRepository domain.AttachmentRepository,
+) *ListAttachmentsUseCase {
+	return &ListAttachmentsUseCase{
+		eventRepository:      eventRepository,
+		attachmentRepository: attachmentRepository,
+	}
+}
+
+func (uc *ListAttachmentsUseCase) Execute(ctx context.Context, eventID int) ([]domain.Attachment, error) {
+	if _, err := uc.eventRepository.GetEventByID(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	return uc.attachmentRepository.ListAttachments(ctx, eventID)
+}
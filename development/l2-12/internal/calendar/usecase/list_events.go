@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type ListEventsUseCase struct {
+	eventRepository domain.Repository
+}
+
+func NewListEventsUseCase(
+	eventRepository domain.Repository,
+) *ListEventsUseCase {
+	return &ListEventsUseCase{
+		eventRepository: eventRepository,
+	}
+}
+
+func (uc *ListEventsUseCase) Execute(ctx context.Context) ([]domain.Event, error) {
+	return uc.eventRepository.ListEvents(ctx)
+}
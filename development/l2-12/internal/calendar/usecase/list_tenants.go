@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type ListTenantsUseCase struct {
+	tenantRepository domain.TenantRepository
+}
+
+func NewListTenantsUseCase(
+	tenantRepository domain.TenantRepository,
+) *ListTenantsUseCase {
+	return &ListTenantsUseCase{
+		tenantRepository: tenantRepository,
+	}
+}
+
+func (uc *ListTenantsUseCase) Execute(ctx context.Context) ([]domain.Tenant, error) {
+	return uc.tenantRepository.ListTenants(ctx)
+}
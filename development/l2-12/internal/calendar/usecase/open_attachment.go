@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type OpenAttachmentUseCase struct {
+	attachmentRepository domain.AttachmentRepository
+	blobStorage          domain.BlobStorage
+}
+
+func NewOpenAttachmentUseCase(
+	attachmentRepository domain.AttachmentRepository,
+	blobStorage domain.BlobStorage,
+) *OpenAttachmentUseCase {
+	return &OpenAttachmentUseCase{
+		attachmentRepository: attachmentRepository,
+		blobStorage:          blobStorage,
+	}
+}
+
+// Execute возвращает описание вложения и его содержимое. Содержимое нужно закрыть после чтения
+func (uc *OpenAttachmentUseCase) Execute(ctx context.Context, eventID int, attachmentID string) (domain.Attachment, io.ReadCloser, error) {
+	attachment, err := uc.attachmentRepository.GetAttachment(ctx, eventID, attachmentID)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+
+	content, err := uc.blobStorage.Get(ctx, attachment.BlobKey())
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+
+	return attachment, content, nil
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type ResolveTenantUseCase struct {
+	tenantRepository domain.TenantRepository
+	// Арендатор, кроме арендатора по умолчанию, определяется только по токену
+	requireToken bool
+}
+
+func NewResolveTenantUseCase(
+	tenantRepository domain.TenantRepository,
+	requireToken bool,
+) *ResolveTenantUseCase {
+	return &ResolveTenantUseCase{
+		tenantRepository: tenantRepository,
+		requireToken:     requireToken,
+	}
+}
+
+// Execute определяет арендатора запроса по токену или по ID. Если не передано ни то ни другое,
+// запрос относится к арендатору по умолчанию
+func (uc *ResolveTenantUseCase) Execute(ctx context.Context, tenantID, token string) (domain.Tenant, error) {
+	if token != "" {
+		tenant, err := uc.tenantRepository.GetTenantByToken(ctx, token)
+		if err != nil {
+			return domain.Tenant{}, fmt.Errorf("%w: invalid tenant token", domain.ErrForbidden)
+		}
+
+		if tenantID != "" && tenantID != tenant.ID {
+			return domain.Tenant{}, fmt.Errorf("%w: token does not belong to tenant %q", domain.ErrForbidden, tenantID)
+		}
+
+		return tenant, nil
+	}
+
+	if tenantID == "" {
+		tenantID = domain.DefaultTenantID
+	}
+
+	if uc.requireToken && tenantID != domain.DefaultTenantID {
+		return domain.Tenant{}, fmt.Errorf("%w: tenant %q requires a token", domain.ErrForbidden, tenantID)
+	}
+
+	return uc.tenantRepository.GetTenant(ctx, tenantID)
+}
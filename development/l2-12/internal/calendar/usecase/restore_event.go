@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type RestoreEventUseCase struct {
+	eventRepository domain.Repository
+}
+
+func NewRestoreEventUseCase(
+	eventRepository domain.Repository,
+) *RestoreEventUseCase {
+	return &RestoreEventUseCase{
+		eventRepository: eventRepository,
+	}
+}
+
+func (uc *RestoreEventUseCase) Execute(ctx context.Context, event domain.Event) error {
+	return uc.eventRepository.RestoreEvent(ctx, event)
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type SetWorkingHoursUseCase struct {
+	scheduleRepository domain.ScheduleRepository
+}
+
+func NewSetWorkingHoursUseCase(
+	scheduleRepository domain.ScheduleRepository,
+) *SetWorkingHoursUseCase {
+	return &SetWorkingHoursUseCase{
+		scheduleRepository: scheduleRepository,
+	}
+}
+
+func (uc *SetWorkingHoursUseCase) Execute(ctx context.Context, userID int, hours domain.WorkingHours) error {
+	if err := hours.Validate(); err != nil {
+		return err
+	}
+
+	return uc.scheduleRepository.SetWorkingHours(ctx, userID, hours)
+}
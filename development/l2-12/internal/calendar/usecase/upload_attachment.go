@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+type UploadAttachmentUseCase struct {
+	eventRepository      domain.Repository
+	attachmentRepository domain.AttachmentRepository
+	blobStorage          domain.BlobStorage
+	limits               domain.AttachmentLimits
+}
+
+func NewUploadAttachmentUseCase(
+	eventRepository domain.Repository,
+	attachmentRepository domain.AttachmentRepository,
+	blobStorage domain.BlobStorage,
+	limits domain.AttachmentLimits,
+) *UploadAttachmentUseCase {
+	return &UploadAttachmentUseCase{
+		eventRepository:      eventRepository,
+		attachmentRepository: attachmentRepository,
+		blobStorage:          blobStorage,
+		limits:               limits,
+	}
+}
+
+// Execute сохраняет файл name с содержимым r и прикрепляет его к событию eventID
+func (uc *UploadAttachmentUseCase) Execute(ctx context.Context, eventID int, name, contentType string, r io.Reader) (domain.Attachment, error) {
+	if _, err := uc.eventRepository.GetEventByID(ctx, eventID); err != nil {
+		return domain.Attachment{}, err
+	}
+
+	// Из имени файла оставляем только последний элемент пути
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return domain.Attachment{}, fmt.Errorf("%w: file name is required", domain.ErrValidation)
+	}
+
+	if !uc.limits.Allows(contentType) {
+		return domain.Attachment{}, fmt.Errorf("%w: file type %q is not allowed", domain.ErrValidation, contentType)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return domain.Attachment{}, err
+	}
+
+	attachment := domain.Attachment{
+		ID:          hex.EncodeToString(id),
+		TenantID:    domain.TenantFromContext(ctx),
+		EventID:     eventID,
+		Name:        name,
+		ContentType: contentType,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	// Читаем на байт больше лимита, чтобы отличить файл ровно по лимиту от превышающего его
+	size, err := uc.blobStorage.Put(ctx, attachment.BlobKey(), io.LimitReader(r, uc.limits.MaxSize+1))
+	if err == nil && size > uc.limits.MaxSize {
+		err = fmt.Errorf("%w: file %q exceeds %d bytes", domain.ErrTooLarge, name, uc.limits.MaxSize)
+	}
+	if err == nil {
+		attachment.Size = size
+		err = uc.attachmentRepository.AddAttachment(ctx, attachment)
+	}
+
+	if err != nil {
+		// Содержимое без описания никому не доступно, поэтому удаляем его
+		return domain.Attachment{}, errors.Join(err, uc.blobStorage.Delete(ctx, attachment.BlobKey()))
+	}
+
+	return attachment, nil
+}
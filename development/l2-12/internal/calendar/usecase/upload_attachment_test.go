@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/adapters"
+	"github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+)
+
+// Сценарии вложений поверх хранилищ в памяти и файлов во временном каталоге
+type attachmentFixture struct {
+	events      *adapters.CacheEventRepository
+	attachments *adapters.CacheAttachmentRepository
+	blobs       *adapters.LocalBlobStorage
+
+	create *CreateEventUseCase
+	upload *UploadAttachmentUseCase
+	delete *DeleteEventUseCase
+}
+
+func newAttachmentFixture(t *testing.T, maxEvents int) attachmentFixture {
+	t.Helper()
+
+	tenants := adapters.NewCacheTenantRepository()
+	if err := tenants.CreateTenant(context.Background(), domain.Tenant{ID: domain.DefaultTenantID}); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+
+	f := attachmentFixture{
+		events:      adapters.NewCacheEventRepository(maxEvents),
+		attachments: adapters.NewCacheAttachmentRepository(),
+		blobs:       adapters.NewLocalBlobStorage(t.TempDir()),
+	}
+
+	limits := domain.AttachmentLimits{MaxSize: 8, AllowedTypes: []string{"text/plain", "image/*"}}
+
+	f.create = NewCreateEventUseCase(f.events, tenants, f.attachments, f.blobs)
+	f.upload = NewUploadAttachmentUseCase(f.events, f.attachments, f.blobs, limits)
+	f.delete = NewDeleteEventUseCase(f.events, f.attachments, f.blobs)
+
+	return f
+}
+
+func (f attachmentFixture) createEvent(t *testing.T) int {
+	t.Helper()
+
+	id, err := f.create.Execute(context.Background(), domain.Event{UserID: 1, Date: time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC), Title: "standup"})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	return id
+}
+
+// Проверяет, что у события не осталось ни описаний вложений, ни их файлов
+func (f attachmentFixture) expectNoAttachments(t *testing.T, eventID int, removed []domain.Attachment) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if attachments, err := f.attachments.ListAttachments(ctx, eventID); err != nil || len(attachments) != 0 {
+		t.Errorf("ListAttachments(%d) = %v, %v, expected none", eventID, attachments, err)
+	}
+
+	for _, attachment := range removed {
+		if content, err := f.blobs.Get(ctx, attachment.BlobKey()); err == nil {
+			content.Close()
+			t.Errorf("file %s is still stored", attachment.BlobKey())
+		}
+	}
+}
+
+func TestUploadAttachmentLimits(t *testing.T) {
+	f := newAttachmentFixture(t, 10)
+	eventID := f.createEvent(t)
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		content     string
+		expected    error
+	}{
+		{name: "exactly max size", fileName: "notes.txt", contentType: "text/plain; charset=utf-8", content: "12345678"},
+		{name: "image subtype", fileName: "photo.png", contentType: "image/png", content: "png"},
+		{name: "too large", fileName: "big.txt", contentType: "text/plain", content: "123456789", expected: domain.ErrTooLarge},
+		{name: "type not allowed", fileName: "run.sh", contentType: "application/x-sh", content: "ls", expected: domain.ErrValidation},
+		{name: "empty name", fileName: "", contentType: "text/plain", content: "x", expected: domain.ErrValidation},
+	}
+
+	for _, test := range tests {
+		attachment, err := f.upload.Execute(context.Background(), eventID, test.fileName, test.contentType, strings.NewReader(test.content))
+		if !errors.Is(err, test.expected) || (test.expected == nil) != (err == nil) {
+			t.Errorf("%s: Execute() error = %v, expected %v", test.name, err, test.expected)
+			continue
+		}
+
+		if test.expected == nil && attachment.Size != int64(len(test.content)) {
+			t.Errorf("%s: attachment size = %d, expected %d", test.name, attachment.Size, len(test.content))
+		}
+	}
+
+	// Отклонённые файлы не сохраняются
+	attachments, err := f.attachments.ListAttachments(context.Background(), eventID)
+	if err != nil || len(attachments) != 2 {
+		t.Errorf("ListAttachments() = %v, %v, expected 2 accepted files", attachments, err)
+	}
+
+	if _, err := f.upload.Execute(context.Background(), 42, "notes.txt", "text/plain", strings.NewReader("x")); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("upload to unknown event error = %v, expected %v", err, domain.ErrNotFound)
+	}
+}
+
+func TestDeleteEventRemovesAttachments(t *testing.T) {
+	f := newAttachmentFixture(t, 10)
+	eventID := f.createEvent(t)
+
+	attachment, err := f.upload.Execute(context.Background(), eventID, "notes.txt", "text/plain", strings.NewReader("agenda"))
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+
+	if err := f.delete.Execute(context.Background(), eventID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	f.expectNoAttachments(t, eventID, []domain.Attachment{attachment})
+}
+
+func TestCreateEventRemovesAttachmentsOfEvictedEvent(t *testing.T) {
+	// Хранилище выдаёт ID 1 и 2, третье событие вытесняет первое и получает его ID
+	f := newAttachmentFixture(t, 3)
+
+	first := f.createEvent(t)
+	attachment, err := f.upload.Execute(context.Background(), first, "notes.txt", "text/plain", strings.NewReader("private"))
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+
+	f.createEvent(t)
+	if reused := f.createEvent(t); reused != first {
+		t.Fatalf("CreateEvent() = %d, expected reused id %d", reused, first)
+	}
+
+	f.expectNoAttachments(t, first, []domain.Attachment{attachment})
+}
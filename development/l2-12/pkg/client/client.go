@@ -0,0 +1,550 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Формат даты, который сервер принимает в form и query параметрах
+const dateLayout = "2006-01-02"
+
+// Event - событие в том виде, в котором его возвращает сервер
+type Event struct {
+	ID          int       `json:"ID"`
+	UserID      int       `json:"UserID"`
+	Date        time.Time `json:"Date"`
+	Description string    `json:"Description"`
+	Title       string    `json:"Title"`
+	Location    string    `json:"Location"`
+	Category    string    `json:"Category"`
+	Tags        []string  `json:"Tags"`
+	Color       string    `json:"Color"`
+	// Праздники из производственного календаря приходят как события только для чтения
+	ReadOnly bool `json:"ReadOnly"`
+}
+
+// EventInput - параметры для создания и обновления события
+type EventInput struct {
+	ID          int
+	UserID      int
+	Date        time.Time
+	Description string
+	Title       string
+	Location    string
+	Category    string
+	Tags        []string
+	// Цвет в формате #rrggbb
+	Color string
+}
+
+// EventFilter - отбор событий в списках по тегу и категории. Пустые поля не ограничивают выборку
+type EventFilter struct {
+	Tag      string
+	Category string
+}
+
+// WorkingHours - рабочее время пользователя. Время в формате 15:04, дни недели - mon, tue, ...
+type WorkingHours struct {
+	UserID   int      `json:"user_id"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Weekdays []string `json:"weekdays"`
+}
+
+// Availability - рабочее время пользователя в один день с учётом праздников
+type Availability struct {
+	Date    string `json:"date"`
+	Working bool   `json:"working"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Holiday string `json:"holiday"`
+	Events  int    `json:"events"`
+}
+
+// Attachment - файл, прикреплённый к событию
+type Attachment struct {
+	ID          string    `json:"attachment_id"`
+	EventID     int       `json:"event_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Tenant - арендатор календаря
+type Tenant struct {
+	ID        string    `json:"tenant_id"`
+	Name      string    `json:"name"`
+	MaxEvents int       `json:"max_events"`
+	CreatedAt time.Time `json:"created_at"`
+	// Токен доступа арендатора, заполнен только в ответе CreateTenant
+	Token string `json:"token,omitempty"`
+}
+
+// Периоды для GetAvailability
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Коды ошибок, которые сервер возвращает в поле "code"
+const (
+	CodeBadRequest          = "bad_request"
+	CodeValidation          = "validation_failed"
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodeForbidden           = "forbidden"
+	CodeTooLarge            = "too_large"
+	CodeIdempotencyMismatch = "idempotency_mismatch"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeUnavailable         = "unavailable"
+	CodeInternal            = "internal"
+)
+
+// APIError - ошибка, которую вернул сервер в теле {"error": "...", "code": "..."}
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("calendar api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsCode сообщает, что err - ошибка API с кодом code
+func IsCode(err error, code string) bool {
+	apiErr := &APIError{}
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Client - типизированный клиент HTTP API календаря
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient создаёт клиента для сервера по адресу baseURL (например, http://localhost:8080).
+// Если httpClient равен nil, используется http.DefaultClient
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Заголовок, по которому сервер повторяет ответ на ретрай вместо повторного выполнения запроса
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// Заголовок с ID арендатора
+const tenantHeader = "X-Tenant-ID"
+
+type tenantContextKey struct{}
+
+type tokenContextKey struct{}
+
+// WithTenant выполняет запросы с этим контекстом от имени арендатора tenantID (заголовок X-Tenant-ID)
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// WithToken передаёт с запросами токен в заголовке Authorization: Bearer.
+// Это токен арендатора или, для методов управления арендаторами, токен администратора
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// WithIdempotencyKey задаёт ключ идемпотентности для CreateEvent и UpdateEvent, выполняемых с этим контекстом.
+// Повтор запроса с тем же ключом и теми же данными вернёт первый ответ сервера, а не создаст событие заново
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// CreateEvent вызывает POST /create_event
+func (c *Client) CreateEvent(ctx context.Context, in EventInput) (Event, error) {
+	event := Event{}
+	err := c.postForm(ctx, "/create_event", eventForm(in), &event)
+	return event, err
+}
+
+// UpdateEvent вызывает POST /update_event
+func (c *Client) UpdateEvent(ctx context.Context, in EventInput) (Event, error) {
+	event := Event{}
+	err := c.postForm(ctx, "/update_event", eventForm(in), &event)
+	return event, err
+}
+
+// DeleteEvent вызывает POST /delete_event и возвращает удалённое событие
+func (c *Client) DeleteEvent(ctx context.Context, eventID int) (Event, error) {
+	form := url.Values{}
+	form.Set("event_id", strconv.Itoa(eventID))
+
+	event := Event{}
+	err := c.postForm(ctx, "/delete_event", form, &event)
+	return event, err
+}
+
+// GetEventsForDay вызывает GET /events_for_day
+func (c *Client) GetEventsForDay(ctx context.Context, date time.Time, filter EventFilter) ([]Event, error) {
+	return c.getEvents(ctx, "/events_for_day", date, filter)
+}
+
+// GetEventsForWeek вызывает GET /events_for_week
+func (c *Client) GetEventsForWeek(ctx context.Context, date time.Time, filter EventFilter) ([]Event, error) {
+	return c.getEvents(ctx, "/events_for_week", date, filter)
+}
+
+// GetEventsForMonth вызывает GET /events_for_month
+func (c *Client) GetEventsForMonth(ctx context.Context, date time.Time, filter EventFilter) ([]Event, error) {
+	return c.getEvents(ctx, "/events_for_month", date, filter)
+}
+
+// UploadAttachment вызывает POST /upload_attachment и прикрепляет к событию файл name с содержимым content.
+// Тип файла сервер определяет по расширению имени
+func (c *Client) UploadAttachment(ctx context.Context, eventID int, name string, content io.Reader) (Attachment, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	// Тело пишется параллельно с отправкой, чтобы не держать файл в памяти
+	go func() {
+		part, err := form.CreateFormFile("file", name)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	query := url.Values{}
+	query.Set("event_id", strconv.Itoa(eventID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/upload_attachment?"+query.Encode(), body)
+	if err != nil {
+		body.Close()
+		return Attachment{}, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	attachments := make([]Attachment, 0, 1)
+	if err := c.do(req, &attachments); err != nil {
+		return Attachment{}, err
+	}
+	if len(attachments) != 1 {
+		return Attachment{}, fmt.Errorf("calendar api: expected 1 attachment, got %d", len(attachments))
+	}
+
+	return attachments[0], nil
+}
+
+// ListAttachments вызывает GET /attachments
+func (c *Client) ListAttachments(ctx context.Context, eventID int) ([]Attachment, error) {
+	query := url.Values{}
+	query.Set("event_id", strconv.Itoa(eventID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/attachments?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]Attachment, 0)
+	err = c.do(req, &attachments)
+	return attachments, err
+}
+
+// DownloadAttachment вызывает GET /download_attachment и возвращает содержимое файла.
+// Содержимое нужно закрыть после чтения
+func (c *Client) DownloadAttachment(ctx context.Context, eventID int, attachmentID string) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("event_id", strconv.Itoa(eventID))
+	query.Set("attachment_id", attachmentID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/download_attachment?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setContextHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, decodeError(resp.StatusCode, body)
+	}
+
+	return resp.Body, nil
+}
+
+// GetAvailability вызывает GET /availability и возвращает рабочее время пользователя по дням периода
+func (c *Client) GetAvailability(ctx context.Context, userID int, date time.Time, period string) ([]Availability, error) {
+	query := url.Values{}
+	query.Set("date", date.Format(dateLayout))
+	if userID != 0 {
+		query.Set("user_id", strconv.Itoa(userID))
+	}
+	if period != "" {
+		query.Set("period", period)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/availability?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]Availability, 0)
+	err = c.do(req, &days)
+	return days, err
+}
+
+// GetWorkingHours вызывает GET /working_hours
+func (c *Client) GetWorkingHours(ctx context.Context, userID int) (WorkingHours, error) {
+	query := url.Values{}
+	if userID != 0 {
+		query.Set("user_id", strconv.Itoa(userID))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/working_hours?"+query.Encode(), nil)
+	if err != nil {
+		return WorkingHours{}, err
+	}
+
+	hours := WorkingHours{}
+	err = c.do(req, &hours)
+	return hours, err
+}
+
+// SetWorkingHours вызывает POST /working_hours. Пустые поля сервер заменяет значениями по умолчанию
+func (c *Client) SetWorkingHours(ctx context.Context, in WorkingHours) (WorkingHours, error) {
+	form := url.Values{}
+	if in.UserID != 0 {
+		form.Set("user_id", strconv.Itoa(in.UserID))
+	}
+	if in.Start != "" {
+		form.Set("start", in.Start)
+	}
+	if in.End != "" {
+		form.Set("end", in.End)
+	}
+	if len(in.Weekdays) > 0 {
+		form.Set("weekdays", strings.Join(in.Weekdays, ","))
+	}
+
+	hours := WorkingHours{}
+	err := c.postForm(ctx, "/working_hours", form, &hours)
+	return hours, err
+}
+
+// CreateTenant вызывает POST /admin/create_tenant. Нужен токен администратора в WithToken
+func (c *Client) CreateTenant(ctx context.Context, tenantID, name string, maxEvents int) (Tenant, error) {
+	form := url.Values{}
+	form.Set("tenant_id", tenantID)
+	form.Set("name", name)
+	form.Set("max_events", strconv.Itoa(maxEvents))
+
+	tenant := Tenant{}
+	err := c.postForm(ctx, "/admin/create_tenant", form, &tenant)
+	return tenant, err
+}
+
+// ListTenants вызывает GET /admin/tenants. Нужен токен администратора в WithToken
+func (c *Client) ListTenants(ctx context.Context) ([]Tenant, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/admin/tenants", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]Tenant, 0)
+	err = c.do(req, &tenants)
+	return tenants, err
+}
+
+// DeleteTenant вызывает POST /admin/delete_tenant и возвращает удалённого арендатора
+func (c *Client) DeleteTenant(ctx context.Context, tenantID string) (Tenant, error) {
+	form := url.Values{}
+	form.Set("tenant_id", tenantID)
+
+	tenant := Tenant{}
+	err := c.postForm(ctx, "/admin/delete_tenant", form, &tenant)
+	return tenant, err
+}
+
+// OpenAPI возвращает спецификацию сервера из GET /openapi.json
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setContextHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp.StatusCode, body)
+	}
+
+	return body, nil
+}
+
+func (c *Client) getEvents(ctx context.Context, path string, date time.Time, filter EventFilter) ([]Event, error) {
+	query := url.Values{}
+	query.Set("date", date.Format(dateLayout))
+	if filter.Tag != "" {
+		query.Set("tag", filter.Tag)
+	}
+	if filter.Category != "" {
+		query.Set("category", filter.Category)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0)
+	err = c.do(req, &events)
+	return events, err
+}
+
+func (c *Client) postForm(ctx context.Context, path string, form url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	return c.do(req, result)
+}
+
+// Выполняет запрос и раскладывает {"result": ...} в result, а {"error": ...} в *APIError
+func (c *Client) do(req *http.Request, result interface{}) error {
+	setContextHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeError(resp.StatusCode, body)
+	}
+
+	envelope := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("calendar api: decode response: %w", err)
+	}
+
+	if result == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("calendar api: decode result: %w", err)
+	}
+
+	return nil
+}
+
+// Переносит арендатора и токен из контекста запроса в заголовки
+func setContextHeaders(req *http.Request) {
+	if tenantID, ok := req.Context().Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		req.Header.Set(tenantHeader, tenantID)
+	}
+
+	if token, ok := req.Context().Value(tokenContextKey{}).(string); ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func decodeError(statusCode int, body []byte) error {
+	envelope := struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == "" {
+		envelope.Error = strings.TrimSpace(string(body))
+	}
+
+	return &APIError{StatusCode: statusCode, Code: envelope.Code, Message: envelope.Error}
+}
+
+func eventForm(in EventInput) url.Values {
+	form := url.Values{}
+
+	if in.ID != 0 {
+		form.Set("event_id", strconv.Itoa(in.ID))
+	}
+	if in.UserID != 0 {
+		form.Set("user_id", strconv.Itoa(in.UserID))
+	}
+	if !in.Date.IsZero() {
+		form.Set("date", in.Date.Format(dateLayout))
+	}
+	if in.Description != "" {
+		form.Set("description", in.Description)
+	}
+	if in.Title != "" {
+		form.Set("title", in.Title)
+	}
+	if in.Location != "" {
+		form.Set("location", in.Location)
+	}
+	if in.Category != "" {
+		form.Set("category", in.Category)
+	}
+	for _, tag := range in.Tags {
+		form.Add("tags", tag)
+	}
+	if in.Color != "" {
+		form.Set("color", in.Color)
+	}
+
+	return form
+}
@@ -0,0 +1,520 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	calendarBuilder "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/builder"
+	calendarDomain "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/domain"
+	calendarPorts "github.com/H1DDENP00L/wbtech-l2/development/l2-12/internal/calendar/ports"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	router := http.NewServeMux()
+	calendarApp := calendarBuilder.NewApplication(context.Background())
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarApp))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClientEventLifecycle(t *testing.T) {
+	server := newTestServer(t)
+	c := NewClient(server.URL, server.Client())
+	ctx := context.Background()
+
+	date := time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC)
+
+	created, err := c.CreateEvent(ctx, EventInput{UserID: 3, Date: date, Description: "standup", Category: "work", Tags: []string{"team", "daily"}})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if created.ID == 0 || created.UserID != 3 || !created.Date.Equal(date) || created.Description != "standup" {
+		t.Fatalf("CreateEvent() = %+v", created)
+	}
+	if created.Category != "work" || len(created.Tags) != 2 || created.Tags[1] != "daily" {
+		t.Fatalf("CreateEvent() category and tags = %q %q", created.Category, created.Tags)
+	}
+
+	updated, err := c.UpdateEvent(ctx, EventInput{ID: created.ID, UserID: 3, Date: date, Description: "retro", Category: "work", Tags: []string{"team"}})
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Description != "retro" {
+		t.Fatalf("UpdateEvent() description = %q, expected %q", updated.Description, "retro")
+	}
+
+	for name, get := range map[string]func(context.Context, time.Time, EventFilter) ([]Event, error){
+		"day":   c.GetEventsForDay,
+		"week":  c.GetEventsForWeek,
+		"month": c.GetEventsForMonth,
+	} {
+		events, err := get(ctx, date, EventFilter{Tag: "team", Category: "work"})
+		if err != nil {
+			t.Fatalf("GetEventsFor %s error = %v", name, err)
+		}
+		if len(events) != 1 || events[0].ID != created.ID {
+			t.Fatalf("GetEventsFor %s = %+v, expected event %d", name, events, created.ID)
+		}
+
+		events, err = get(ctx, date, EventFilter{Tag: "daily"})
+		if err != nil {
+			t.Fatalf("GetEventsFor %s error = %v", name, err)
+		}
+		if len(events) != 0 {
+			t.Fatalf("GetEventsFor %s with removed tag = %+v, expected no events", name, events)
+		}
+	}
+
+	deleted, err := c.DeleteEvent(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if deleted.ID != created.ID {
+		t.Fatalf("DeleteEvent() = %+v, expected event %d", deleted, created.ID)
+	}
+
+	events, err := c.GetEventsForDay(ctx, date, EventFilter{})
+	if err != nil {
+		t.Fatalf("GetEventsForDay() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("GetEventsForDay() after delete = %+v, expected no events", events)
+	}
+}
+
+func TestClientScheduleAndHolidays(t *testing.T) {
+	ctx := context.Background()
+
+	config := calendarBuilder.DefaultConfig()
+	config.WeekStart = time.Sunday
+
+	calendarApp := calendarBuilder.NewApplicationWithConfig(ctx, config)
+	err := calendarApp.ImportHolidays.Execute(ctx, []calendarDomain.Holiday{
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Name: "Новый год", Kind: calendarDomain.DayOff},
+		{Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Name: "Сокращённый день", Kind: calendarDomain.ShortDay},
+		{Date: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), Name: "Рабочая суббота", Kind: calendarDomain.WorkingDay},
+	})
+	if err != nil {
+		t.Fatalf("ImportHolidays error = %v", err)
+	}
+
+	router := http.NewServeMux()
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarApp))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := NewClient(server.URL, server.Client())
+
+	// Неделя начинается с воскресенья: 31.12.2023 входит в неделю 03.01.2024, а 07.01.2024 - нет
+	for _, offset := range []int{-1, 6} {
+		date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, offset)
+		if _, err := c.CreateEvent(ctx, EventInput{UserID: 3, Date: date, Description: "event"}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+
+	events, err := c.GetEventsForWeek(ctx, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), EventFilter{})
+	if err != nil {
+		t.Fatalf("GetEventsForWeek() error = %v", err)
+	}
+	if len(events) != 2 || !events[0].ReadOnly || events[0].Title != "Новый год" || events[1].Date.Day() != 31 {
+		t.Fatalf("GetEventsForWeek() = %+v, expected holiday and event on 31.12", events)
+	}
+
+	hours, err := c.SetWorkingHours(ctx, WorkingHours{UserID: 3, Start: "10:00", End: "16:00", Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}})
+	if err != nil {
+		t.Fatalf("SetWorkingHours() error = %v", err)
+	}
+	if hours.Start != "10:00" || hours.End != "16:00" || len(hours.Weekdays) != 5 {
+		t.Fatalf("SetWorkingHours() = %+v", hours)
+	}
+
+	days, err := c.GetAvailability(ctx, 3, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), PeriodWeek)
+	if err != nil {
+		t.Fatalf("GetAvailability() error = %v", err)
+	}
+
+	expected := []Availability{
+		{Date: "2023-12-31", Events: 1},
+		{Date: "2024-01-01", Holiday: "Новый год"},
+		{Date: "2024-01-02", Working: true, Start: "10:00", End: "16:00"},
+		{Date: "2024-01-03", Working: true, Start: "10:00", End: "15:00", Holiday: "Сокращённый день"},
+		{Date: "2024-01-04", Working: true, Start: "10:00", End: "16:00"},
+		{Date: "2024-01-05", Working: true, Start: "10:00", End: "16:00"},
+		{Date: "2024-01-06", Working: true, Start: "10:00", End: "16:00", Holiday: "Рабочая суббота"},
+	}
+	if len(days) != len(expected) {
+		t.Fatalf("GetAvailability() = %+v, expected %d days", days, len(expected))
+	}
+	for i := range expected {
+		if days[i] != expected[i] {
+			t.Errorf("GetAvailability() day %d = %+v, expected %+v", i, days[i], expected[i])
+		}
+	}
+
+	_, err = c.SetWorkingHours(ctx, WorkingHours{UserID: 3, Start: "18:00", End: "09:00"})
+	if !IsCode(err, CodeValidation) {
+		t.Fatalf("SetWorkingHours() with end before start error = %v, expected %s", err, CodeValidation)
+	}
+}
+
+func TestClientAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	config := calendarBuilder.DefaultConfig()
+	config.AttachmentsDir = t.TempDir()
+	config.AttachmentLimits.MaxSize = 16
+
+	router := http.NewServeMux()
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarBuilder.NewApplicationWithConfig(ctx, config)))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := NewClient(server.URL, server.Client())
+
+	event, err := c.CreateEvent(ctx, EventInput{UserID: 3, Date: time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC), Title: "Planning"})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	attachment, err := c.UploadAttachment(ctx, event.ID, "agenda.txt", strings.NewReader("1. Roadmap"))
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+	if attachment.Name != "agenda.txt" || attachment.Size != 10 || !strings.HasPrefix(attachment.ContentType, "text/plain") {
+		t.Fatalf("UploadAttachment() = %+v", attachment)
+	}
+
+	attachments, err := c.ListAttachments(ctx, event.ID)
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].ID != attachment.ID {
+		t.Fatalf("ListAttachments() = %+v, expected %s", attachments, attachment.ID)
+	}
+
+	content, err := c.DownloadAttachment(ctx, event.ID, attachment.ID)
+	if err != nil {
+		t.Fatalf("DownloadAttachment() error = %v", err)
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil || string(data) != "1. Roadmap" {
+		t.Fatalf("DownloadAttachment() = %q, %v", data, err)
+	}
+
+	if _, err := c.UploadAttachment(ctx, event.ID, "setup.exe", strings.NewReader("MZ")); !IsCode(err, CodeValidation) {
+		t.Errorf("UploadAttachment() of executable error = %v, expected %s", err, CodeValidation)
+	}
+	if _, err := c.UploadAttachment(ctx, event.ID, "slides.txt", strings.NewReader(strings.Repeat("x", 17))); !IsCode(err, CodeTooLarge) {
+		t.Errorf("UploadAttachment() over the limit error = %v, expected %s", err, CodeTooLarge)
+	}
+	if _, err := c.UploadAttachment(ctx, 42, "agenda.txt", strings.NewReader("x")); !IsCode(err, CodeNotFound) {
+		t.Errorf("UploadAttachment() to unknown event error = %v, expected %s", err, CodeNotFound)
+	}
+
+	if _, err := c.DeleteEvent(ctx, event.ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	// Вместе с событием удаляются и файлы вложений
+	entries, err := os.ReadDir(config.AttachmentsDir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("attachments dir after DeleteEvent() = %v, %v, expected empty", entries, err)
+	}
+	if _, err := c.DownloadAttachment(ctx, event.ID, attachment.ID); !IsCode(err, CodeNotFound) {
+		t.Errorf("DownloadAttachment() after DeleteEvent() error = %v, expected %s", err, CodeNotFound)
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	server := newTestServer(t)
+	c := NewClient(server.URL, server.Client())
+	ctx := context.Background()
+
+	date := time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC)
+	in := EventInput{UserID: 3, Date: date, Description: "standup"}
+
+	keyCtx := WithIdempotencyKey(ctx, "create-standup-1")
+
+	first, err := c.CreateEvent(keyCtx, in)
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	retry, err := c.CreateEvent(keyCtx, in)
+	if err != nil {
+		t.Fatalf("CreateEvent() retry error = %v", err)
+	}
+	if retry.ID != first.ID {
+		t.Fatalf("CreateEvent() retry id = %d, expected replayed id %d", retry.ID, first.ID)
+	}
+
+	events, err := c.GetEventsForDay(ctx, date, EventFilter{})
+	if err != nil {
+		t.Fatalf("GetEventsForDay() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("GetEventsForDay() = %+v, expected a single event after retry", events)
+	}
+
+	// Тот же ключ с другими данными отклоняется
+	in.Description = "retro"
+	_, err = c.CreateEvent(keyCtx, in)
+	if !IsCode(err, CodeIdempotencyMismatch) {
+		t.Fatalf("CreateEvent() with reused key error = %v, expected %q", err, CodeIdempotencyMismatch)
+	}
+
+	// Без ключа каждый запрос создаёт новое событие
+	second, err := c.CreateEvent(ctx, in)
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("CreateEvent() without key id = %d, expected a new event", second.ID)
+	}
+
+	// Повтор отмечается заголовком Idempotent-Replayed
+	for _, replayed := range []string{"", "true"} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/create_event", strings.NewReader("user_id=3&date=2019-09-10&description=demo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Idempotency-Key", "create-demo")
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != replayed {
+			t.Fatalf("POST /create_event = %d, Idempotent-Replayed %q, expected 200 and %q", resp.StatusCode, resp.Header.Get("Idempotent-Replayed"), replayed)
+		}
+	}
+}
+
+func TestClientTenants(t *testing.T) {
+	ctx := context.Background()
+
+	config := calendarBuilder.DefaultConfig()
+	config.AttachmentsDir = t.TempDir()
+	config.AdminToken = "admin-secret"
+
+	router := http.NewServeMux()
+	calendarPorts.CustomRegisterHandlers(router, calendarPorts.NewHttpCalendarHandler(calendarBuilder.NewApplicationWithConfig(ctx, config)))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	c := NewClient(server.URL, server.Client())
+	admin := WithToken(ctx, config.AdminToken)
+
+	if _, err := c.ListTenants(ctx); !IsCode(err, CodeForbidden) {
+		t.Fatalf("ListTenants() without admin token error = %v, expected %q", err, CodeForbidden)
+	}
+
+	teamA, err := c.CreateTenant(admin, "team-a", "Team A", 2)
+	if err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if teamA.ID != "team-a" || teamA.MaxEvents != 2 || teamA.Token == "" {
+		t.Fatalf("CreateTenant() = %+v", teamA)
+	}
+	if _, err := c.CreateTenant(admin, "team-b", "", 0); err != nil {
+		t.Fatalf("CreateTenant() error = %v", err)
+	}
+	if _, err := c.CreateTenant(admin, "team-a", "", 0); !IsCode(err, CodeConflict) {
+		t.Fatalf("CreateTenant() duplicate error = %v, expected %q", err, CodeConflict)
+	}
+	if _, err := c.CreateTenant(admin, "Team A!", "", 0); !IsCode(err, CodeValidation) {
+		t.Fatalf("CreateTenant() invalid id error = %v, expected %q", err, CodeValidation)
+	}
+
+	tenants, err := c.ListTenants(admin)
+	if err != nil {
+		t.Fatalf("ListTenants() error = %v", err)
+	}
+	if len(tenants) != 3 || tenants[0].ID != "default" || tenants[1].ID != "team-a" || tenants[1].Token != "" {
+		t.Fatalf("ListTenants() = %+v", tenants)
+	}
+
+	date := time.Date(2019, 9, 9, 0, 0, 0, 0, time.UTC)
+	tenantA := WithTenant(ctx, "team-a")
+	tenantB := WithTenant(ctx, "team-b")
+
+	// У каждого арендатора своя нумерация и свои события
+	eventA, err := c.CreateEvent(tenantA, EventInput{UserID: 3, Date: date, Title: "A standup"})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	eventB, err := c.CreateEvent(tenantB, EventInput{UserID: 3, Date: date, Title: "B standup"})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if eventA.ID != 1 || eventB.ID != 1 {
+		t.Fatalf("CreateEvent() ids = %d, %d, expected 1 in both tenants", eventA.ID, eventB.ID)
+	}
+
+	for tenantCtx, title := range map[context.Context]string{tenantA: "A standup", tenantB: "B standup"} {
+		events, err := c.GetEventsForDay(tenantCtx, date, EventFilter{})
+		if err != nil {
+			t.Fatalf("GetEventsForDay() error = %v", err)
+		}
+		if len(events) != 1 || events[0].Title != title {
+			t.Fatalf("GetEventsForDay() = %+v, expected only %q", events, title)
+		}
+	}
+
+	if events, err := c.GetEventsForDay(ctx, date, EventFilter{}); err != nil || len(events) != 0 {
+		t.Fatalf("GetEventsForDay() of default tenant = %+v, %v, expected no events", events, err)
+	}
+
+	// Ограничение на количество событий team-a
+	if _, err := c.CreateEvent(tenantA, EventInput{UserID: 3, Date: date, Title: "A retro"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := c.CreateEvent(tenantA, EventInput{UserID: 3, Date: date, Title: "A demo"}); !IsCode(err, CodeConflict) {
+		t.Fatalf("CreateEvent() over limit error = %v, expected %q", err, CodeConflict)
+	}
+
+	// Токен арендатора заменяет заголовок, но не даёт доступа к другому арендатору
+	byToken := WithToken(ctx, teamA.Token)
+	if events, err := c.GetEventsForDay(byToken, date, EventFilter{}); err != nil || len(events) != 2 {
+		t.Fatalf("GetEventsForDay() by token = %+v, %v, expected 2 events of team-a", events, err)
+	}
+	if _, err := c.GetEventsForDay(WithTenant(byToken, "team-b"), date, EventFilter{}); !IsCode(err, CodeForbidden) {
+		t.Fatalf("GetEventsForDay() with foreign token error = %v, expected %q", err, CodeForbidden)
+	}
+	if _, err := c.GetEventsForDay(WithTenant(ctx, "team-c"), date, EventFilter{}); !IsCode(err, CodeNotFound) {
+		t.Fatalf("GetEventsForDay() of unknown tenant error = %v, expected %q", err, CodeNotFound)
+	}
+
+	deleted, err := c.DeleteTenant(admin, "team-a")
+	if err != nil || deleted.ID != "team-a" {
+		t.Fatalf("DeleteTenant() = %+v, %v", deleted, err)
+	}
+	if _, err := c.GetEventsForDay(tenantA, date, EventFilter{}); !IsCode(err, CodeNotFound) {
+		t.Fatalf("GetEventsForDay() of deleted tenant error = %v, expected %q", err, CodeNotFound)
+	}
+	if events, err := c.GetEventsForDay(tenantB, date, EventFilter{}); err != nil || len(events) != 1 {
+		t.Fatalf("GetEventsForDay() of team-b after deleting team-a = %+v, %v", events, err)
+	}
+	if _, err := c.DeleteTenant(admin, "default"); !IsCode(err, CodeConflict) {
+		t.Fatalf("DeleteTenant() of default tenant error = %v, expected %q", err, CodeConflict)
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	server := newTestServer(t)
+	c := NewClient(server.URL, server.Client())
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		call       func() error
+		statusCode int
+		code       string
+	}{
+		{
+			name: "missing description",
+			call: func() error {
+				_, err := c.CreateEvent(ctx, EventInput{UserID: 1, Date: time.Now()})
+				return err
+			},
+			statusCode: http.StatusBadRequest,
+			code:       CodeBadRequest,
+		},
+		{
+			name: "unknown event",
+			call: func() error {
+				_, err := c.DeleteEvent(ctx, 42)
+				return err
+			},
+			statusCode: http.StatusNotFound,
+			code:       CodeNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.call()
+
+		apiErr := &APIError{}
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%s: error = %v, expected *APIError", test.name, err)
+		}
+		if apiErr.StatusCode != test.statusCode || apiErr.Code != test.code || apiErr.Message == "" {
+			t.Errorf("%s: error = %+v, expected status %d and code %q with message", test.name, apiErr, test.statusCode, test.code)
+		}
+		if !IsCode(err, test.code) {
+			t.Errorf("%s: IsCode(%q) = false", test.name, test.code)
+		}
+	}
+}
+
+func TestOpenAPIDescribesRoutes(t *testing.T) {
+	server := newTestServer(t)
+	c := NewClient(server.URL, server.Client())
+
+	raw, err := c.OpenAPI(context.Background())
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+
+	spec := struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}{}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatalf("OpenAPI() returned invalid JSON: %v", err)
+	}
+	if spec.OpenAPI == "" {
+		t.Fatalf("OpenAPI() document has no openapi version")
+	}
+
+	routes := []string{
+		"/create_event",
+		"/update_event",
+		"/delete_event",
+		"/events_for_day",
+		"/events_for_week",
+		"/events_for_month",
+		"/upload_attachment",
+		"/attachments",
+		"/download_attachment",
+		"/availability",
+		"/working_hours",
+		"/openapi.json",
+		"/healthz",
+		"/readyz",
+		"/rpc",
+		"/ui/",
+		"/ui/month",
+		"/ui/week",
+		"/ui/day",
+		"/ui/events/new",
+		"/ui/events/edit",
+		"/ui/events/delete",
+		"/admin/create_tenant",
+		"/admin/tenants",
+		"/admin/delete_tenant",
+	}
+	for _, route := range routes {
+		if _, ok := spec.Paths[route]; !ok {
+			t.Errorf("OpenAPI() document does not describe %s", route)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Символы, которые экранируются в дополненных именах файлов
+const completionSpecialChars = " \t\n|&;<>()$`\\\"'*?[#"
+
+// Дополняет слово, которое заканчивается в конце line (текста до курсора). Возвращает смещение начала слова
+// и варианты замены, отсортированные и экранированные. Первое слово команды дополняется встроенными командами
+// и программами из PATH, остальные - путями. Каталоги заканчиваются на /
+func (sh *shell) complete(line string) (int, []string) {
+	start := wordStart(line)
+	prefix := unescapeWord(line[start:])
+
+	if isCommandPosition(line[:start]) && !strings.Contains(prefix, "/") {
+		return start, sh.completeCommand(prefix)
+	}
+
+	return start, sh.completePath(prefix)
+}
+
+// Начало последнего слова: после пробела или оператора вне кавычек и без экранирования
+func wordStart(line string) int {
+	start := 0
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\\':
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case strings.IndexByte(metaChars, c) >= 0:
+			start = i + 1
+		}
+	}
+
+	return min(start, len(line))
+}
+
+// Зарезервированные слова, после которых стоит имя команды
+var commandKeywords = []string{"if", "then", "elif", "else", "while", "do"}
+
+// Сообщает, стоит ли слово после text на месте имени команды: в начале строки, после |, &, ; и (
+// или после then, do и других слов составных команд
+func isCommandPosition(text string) bool {
+	text = strings.TrimRight(text, " \t")
+	if text == "" || strings.ContainsAny(text[len(text)-1:], "|&;(\n") {
+		return true
+	}
+
+	before := text[:wordStart(text)]
+	return slices.Contains(commandKeywords, text[len(before):]) && isCommandPosition(before)
+}
+
+// Снимает с набранного слова кавычки и экранирование
+func unescapeWord(text string) string {
+	b := strings.Builder{}
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text):
+			i++
+			b.WriteByte(text[i])
+		case c == '\'' || c == '"':
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func escapeWord(text string) string {
+	b := strings.Builder{}
+	for _, c := range text {
+		if strings.ContainsRune(completionSpecialChars, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Встроенные команды и исполняемые файлы из каталогов PATH, имя которых начинается с prefix
+func (sh *shell) completeCommand(prefix string) []string {
+	names := make(map[string]bool)
+
+	for name := range builtins {
+		if strings.HasPrefix(name, prefix) {
+			names[name] = true
+		}
+	}
+	if strings.HasPrefix("quit", prefix) {
+		names["quit"] = true
+	}
+
+	path, _ := sh.getVar("PATH")
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if names[name] || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+				names[name] = true
+			}
+		}
+	}
+
+	candidates := slices.Sorted(maps.Keys(names))
+	for i, name := range candidates {
+		candidates[i] = escapeWord(name)
+	}
+
+	return candidates
+}
+
+// Файлы и каталоги, путь к которым начинается с prefix. Скрытые файлы предлагаются, только если имя начинается с точки
+func (sh *shell) completePath(prefix string) []string {
+	dir, base := "", prefix
+	if i := strings.LastIndexByte(prefix, '/'); i >= 0 {
+		dir, base = prefix[:i+1], prefix[i+1:]
+	}
+
+	listDir := sh.expandTilde(dir)
+	if listDir == "" {
+		listDir = "."
+	}
+
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+
+		candidate := escapeWord(dir + name)
+		// Ссылка на каталог дополняется как каталог
+		if info, err := os.Stat(filepath.Join(listDir, name)); err == nil && info.IsDir() {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	slices.Sort(candidates)
+	return candidates
+}
+
+// Общее начало вариантов дополнения
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	// Общие байты могут закончиться посреди символа
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// Имя варианта для списка: последний компонент пути
+func completionName(candidate string) string {
+	trimmed := strings.TrimSuffix(candidate, "/")
+	if i := strings.LastIndexByte(trimmed, '/'); i >= 0 {
+		return candidate[i+1:]
+	}
+	return candidate
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Выполняет составную команду в шелле с перенаправлениями для всех её команд
+func (sh *shell) runCompound(cmd *compoundCommand, s streams) int {
+	s, files, err := applyRedirects(sh, cmd.redirects, s)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+		return 1
+	}
+	defer closeAll(files)
+
+	switch c := cmd.clause.(type) {
+	case *ifClause:
+		return sh.runIf(c, s)
+	case *whileLoop:
+		return sh.runWhile(c, s)
+	case *forLoop:
+		return sh.runFor(c, s)
+	case *caseClause:
+		return sh.runCase(c, s)
+	default:
+		fmt.Fprintf(s.stderr, "unsupported clause %T\n", c)
+		return 1
+	}
+}
+
+// Выполняет ветку, условие которой завершилось с кодом 0. Без такой ветки и без else код - 0
+func (sh *shell) runIf(c *ifClause, s streams) int {
+	for i, cond := range c.conds {
+		status := sh.runCondition(cond, s)
+		if sh.aborted() {
+			return status
+		}
+		if status == 0 {
+			return sh.runList(c.bodies[i], s)
+		}
+	}
+
+	if c.elseBody != nil {
+		return sh.runList(c.elseBody, s)
+	}
+	return 0
+}
+
+// Выполняет тело, пока условие завершается с кодом 0. Код - код последнего выполнения тела или 0
+func (sh *shell) runWhile(c *whileLoop, s streams) int {
+	defer sh.enterLoop()()
+
+	status := 0
+
+	for {
+		cond := sh.runCondition(c.cond, s)
+		if jumped, next := sh.loopJump(); jumped {
+			if next {
+				continue
+			}
+			return cond
+		}
+		if sh.aborted() {
+			return cond
+		}
+		if cond != 0 {
+			return status
+		}
+
+		status = sh.runList(c.body, s)
+		if jumped, next := sh.loopJump(); jumped {
+			if next {
+				continue
+			}
+			return status
+		}
+		if sh.aborted() {
+			return status
+		}
+	}
+}
+
+// Выполняет тело для каждого слова, присваивая его переменной цикла. Слова раскрываются один раз перед циклом
+func (sh *shell) runFor(c *forLoop, s streams) int {
+	values := sh.positional()
+	if c.hasIn {
+		var err error
+		if values, err = sh.expandWords(c.words); err != nil {
+			fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+			return 1
+		}
+	}
+
+	defer sh.enterLoop()()
+
+	status := 0
+	for _, value := range values {
+		sh.setVar(c.name, value)
+
+		status = sh.runList(c.body, s)
+		if jumped, next := sh.loopJump(); jumped {
+			if next {
+				continue
+			}
+			break
+		}
+		if sh.aborted() {
+			break
+		}
+	}
+
+	return status
+}
+
+// Увеличивает глубину вложенности циклов и возвращает функцию, которая восстанавливает её после цикла
+func (sh *shell) enterLoop() func() {
+	sh.mu.Lock()
+	sh.loops++
+	sh.mu.Unlock()
+
+	return func() {
+		sh.mu.Lock()
+		sh.loops--
+		sh.mu.Unlock()
+	}
+}
+
+// Вызывается циклом после условия или тела. jumped сообщает, что break или continue покидают этот цикл,
+// next - что цикл продолжается следующей итерацией (continue для этого цикла), а не завершается
+func (sh *shell) loopJump() (jumped, next bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if sh.loopJumps == 0 {
+		return false, false
+	}
+
+	sh.loopJumps--
+
+	return true, sh.loopJumps == 0 && sh.loopContinue
+}
+
+// break [n] завершает n объемлющих циклов, по умолчанию один
+func executeBreak(sh *shell, args []string, s streams) int {
+	return sh.jumpLoops(args, false, s)
+}
+
+// continue [n] начинает следующую итерацию n-го объемлющего цикла, по умолчанию ближайшего
+func executeContinue(sh *shell, args []string, s streams) int {
+	return sh.jumpLoops(args, true, s)
+}
+
+func (sh *shell) jumpLoops(args []string, next bool, s streams) int {
+	if len(args) > 2 {
+		fmt.Fprintf(s.stderr, "%s: too many arguments\n", args[0])
+		return statusUsage
+	}
+
+	n := 1
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil {
+			fmt.Fprintf(s.stderr, "%s: %s: numeric argument required\n", args[0], args[1])
+			return statusUsage
+		}
+		if n < 1 {
+			fmt.Fprintf(s.stderr, "%s: %d: loop count out of range\n", args[0], n)
+			return 1
+		}
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if sh.loops == 0 {
+		fmt.Fprintf(s.stderr, "%s: only meaningful in a for or while loop\n", args[0])
+		return 0
+	}
+
+	// Как в bash, break 5 во вложенности из двух циклов завершает оба
+	sh.loopJumps = min(n, sh.loops)
+	sh.loopContinue = next
+
+	return 0
+}
+
+// Выполняет первую ветку, шаблон которой подходит к слову. Без подходящей ветки код - 0
+func (sh *shell) runCase(c *caseClause, s streams) int {
+	subject, err := sh.expandAssignment(c.subject)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+		return 1
+	}
+
+	for _, item := range c.items {
+		for _, w := range item.patterns {
+			pattern, err := sh.expandPattern(w)
+			if err != nil {
+				fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+				return 1
+			}
+
+			if matchPattern(pattern, subject) {
+				return sh.runList(item.body, s)
+			}
+		}
+	}
+
+	return 0
+}
+
+// Сопоставляет строку с шаблоном: * - любая строка, ? - любой символ, [abc], [a-z] и [!abc] - символ из набора.
+// Обратная косая черта отменяет особое значение следующего символа
+func matchPattern(pattern, s string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := range s {
+				if matchPattern(pattern, s[i:]) {
+					return true
+				}
+			}
+			return matchPattern(pattern, "")
+		case '?':
+			if s == "" {
+				return false
+			}
+			_, size := utf8.DecodeRuneInString(s)
+			pattern, s = pattern[1:], s[size:]
+			continue
+		case '[':
+			if s == "" {
+				return false
+			}
+			r, size := utf8.DecodeRuneInString(s)
+			if matched, length, ok := matchClass(pattern, r); ok {
+				if !matched {
+					return false
+				}
+				pattern, s = pattern[length:], s[size:]
+				continue
+			}
+			// [ без закрывающей ] - обычный символ
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+		}
+
+		p, size := utf8.DecodeRuneInString(pattern)
+		r, _ := utf8.DecodeRuneInString(s)
+		if s == "" || p != r {
+			return false
+		}
+		pattern, s = pattern[size:], s[size:]
+	}
+
+	return s == ""
+}
+
+// Проверяет символ r по набору [...] в начале pattern. Возвращает результат и длину набора.
+// ok = false, если набор не закрыт
+func matchClass(pattern string, r rune) (bool, int, bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	matched := false
+	// ] сразу после [ или [! - обычный символ набора
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+
+		lo, size := classChar(pattern[i:])
+		i += size
+		hi := lo
+
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = classChar(pattern[i+1:])
+			i += 1 + size
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0, false
+}
+
+// Символ набора, возможно экранированный, и его длина в шаблоне
+func classChar(text string) (rune, int) {
+	if text[0] == '\\' && len(text) > 1 {
+		r, size := utf8.DecodeRuneInString(text[1:])
+		return r, size + 1
+	}
+	return utf8.DecodeRuneInString(text)
+}
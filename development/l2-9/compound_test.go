@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"*", "", true},
+		{"*", "any/thing", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"a*c", "ac", true},
+		{"a*c", "abcd", false},
+		{"a**b*c", "axbyc", true},
+		{"?", "я", true},
+		{"??", "я", false},
+		{"a?c", "abc", true},
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"[a-c]x", "bx", true},
+		{"[!a-c]", "d", true},
+		{"[^a-c]", "a", false},
+		{"[]a]", "]", true},
+		{"[a-]", "-", true},
+		{"[а-я]", "ж", true},
+		{"[ab", "[ab", true},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`a\?`, "ab", false},
+		{`[\]]`, "]", true},
+		{"*.tar.gz", "x.tar.gz", true},
+		{"*.go", "main.go.bak", false},
+	}
+
+	for _, test := range tests {
+		if got := matchPattern(test.pattern, test.name); got != test.expected {
+			t.Errorf("matchPattern(%q, %q) = %v, expected %v", test.pattern, test.name, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ввод строки прерван Ctrl+C
+var errInterrupted = errors.New("interrupted")
+
+// Источник строк команд
+type lineReader interface {
+	// Выводит приглашение и читает строку без перевода строки. В конце ввода возвращает io.EOF
+	readLine(prompt string) (string, error)
+}
+
+// Читает строки как есть, без редактирования: ввод не с терминала
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func newScannerReader(in io.Reader, out io.Writer) *scannerReader {
+	return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+func (r *scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// Управляющие клавиши
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Клавиши, которые терминал передаёт escape-последовательностями
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDeleteForward
+	keyUnknown
+)
+
+// Редактор строки в неканоническом режиме терминала: перемещение курсора, удаление слов, история
+// стрелками и Ctrl+R, дополнение по Tab
+type lineEditor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// Терминал, -1 - режим терминала не меняется
+	fd int
+	// Режим терминала до редактора, он восстанавливается на время выполнения команд
+	original syscall.Termios
+
+	history *history
+	// Дополнение слова перед курсором: смещение начала слова и варианты
+	complete func(line string) (int, []string)
+}
+
+func newLineEditor(in *os.File, out io.Writer, sh *shell) (*lineEditor, error) {
+	fd := int(in.Fd())
+
+	original, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lineEditor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		fd:       fd,
+		original: original,
+		history:  sh.history,
+		complete: sh.complete,
+	}, nil
+}
+
+// Переводит терминал в режим посимвольного чтения без эха. Сигналы от Ctrl+C и Ctrl+Z тоже приходят символами
+func (e *lineEditor) enterRaw() error {
+	if e.fd < 0 {
+		return nil
+	}
+
+	raw := e.original
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	return setTermios(e.fd, raw)
+}
+
+func (e *lineEditor) restore() {
+	if e.fd >= 0 {
+		_ = setTermios(e.fd, e.original)
+	}
+}
+
+// Состояние редактируемой строки
+type editLine struct {
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+func (l *editLine) insert(text []rune) {
+	l.buf = append(l.buf[:l.pos], append(text, l.buf[l.pos:]...)...)
+	l.pos += len(text)
+}
+
+// Удаляет символы строки с from до курсора
+func (l *editLine) deleteBack(from int) {
+	l.buf = append(l.buf[:from], l.buf[l.pos:]...)
+	l.pos = from
+}
+
+func (l *editLine) set(text string) {
+	l.buf = []rune(text)
+	l.pos = len(l.buf)
+}
+
+// Начало слова перед курсором для Ctrl+W
+func (l *editLine) wordStart() int {
+	i := l.pos
+	for i > 0 && unicode.IsSpace(l.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(l.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if err := e.enterRaw(); err != nil {
+		return "", err
+	}
+	defer e.restore()
+
+	line := &editLine{prompt: prompt}
+
+	entries := e.history.list()
+	// Позиция в истории: len(entries) - новая строка, которая сохраняется при листании
+	historyPos := len(entries)
+	draft := ""
+
+	// Второй Tab подряд выводит варианты дополнения
+	lastTab := false
+
+	e.refresh(line)
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		if key == keyCtrlR {
+			if key, err = e.reverseSearch(line, entries); err != nil {
+				return "", err
+			}
+		}
+
+		tab := false
+
+		switch key {
+		case keyEnter, keyCtrlJ:
+			line.pos = len(line.buf)
+			e.refresh(line)
+			e.write("\n")
+			return string(line.buf), nil
+		case keyCtrlC:
+			e.write("^C\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(line.buf) == 0 {
+				return "", io.EOF
+			}
+			if line.pos < len(line.buf) {
+				line.buf = append(line.buf[:line.pos], line.buf[line.pos+1:]...)
+			}
+		case keyDeleteForward:
+			if line.pos < len(line.buf) {
+				line.buf = append(line.buf[:line.pos], line.buf[line.pos+1:]...)
+			}
+		case keyBackspace, keyDelete:
+			if line.pos > 0 {
+				line.deleteBack(line.pos - 1)
+			}
+		case keyCtrlA, keyHome:
+			line.pos = 0
+		case keyCtrlE, keyEnd:
+			line.pos = len(line.buf)
+		case keyCtrlB, keyLeft:
+			line.pos = max(0, line.pos-1)
+		case keyCtrlF, keyRight:
+			line.pos = min(len(line.buf), line.pos+1)
+		case keyCtrlW:
+			line.deleteBack(line.wordStart())
+		case keyCtrlU:
+			line.deleteBack(0)
+		case keyCtrlK:
+			line.buf = line.buf[:line.pos]
+		case keyCtrlL:
+			e.write("\x1b[H\x1b[2J")
+		case keyCtrlP, keyUp:
+			if historyPos > 0 {
+				if historyPos == len(entries) {
+					draft = string(line.buf)
+				}
+				historyPos--
+				line.set(entries[historyPos])
+			}
+		case keyCtrlN, keyDown:
+			if historyPos < len(entries) {
+				historyPos++
+				if historyPos == len(entries) {
+					line.set(draft)
+				} else {
+					line.set(entries[historyPos])
+				}
+			}
+		case keyTab:
+			tab = true
+			e.completeWord(line, lastTab)
+		default:
+			if key >= ' ' {
+				line.insert([]rune{key})
+			}
+		}
+
+		lastTab = tab
+		e.refresh(line)
+	}
+}
+
+// Читает клавишу. Escape-последовательности стрелок и Home/End/Delete превращаются в коды key*
+func (e *lineEditor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		// Alt+клавиша не поддерживается, а клавиша после Esc обрабатывается как обычно
+		_ = e.in.UnreadRune()
+		return keyEscape, nil
+	}
+
+	// Последовательность заканчивается символом из диапазона @..~
+	seq := strings.Builder{}
+	for seq.Len() < 8 {
+		c, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		seq.WriteRune(c)
+		if c >= '@' && c <= '~' {
+			break
+		}
+	}
+
+	switch seq.String() {
+	case "A":
+		return keyUp, nil
+	case "B":
+		return keyDown, nil
+	case "C":
+		return keyRight, nil
+	case "D":
+		return keyLeft, nil
+	case "H", "1~", "7~":
+		return keyHome, nil
+	case "F", "4~", "8~":
+		return keyEnd, nil
+	case "3~":
+		return keyDeleteForward, nil
+	default:
+		return keyUnknown, nil
+	}
+}
+
+// Поиск по истории назад (Ctrl+R): набранный текст ищется в командах от новых к старым, повторный Ctrl+R
+// ищет дальше. Найденная команда попадает в строку, а клавиша, завершившая поиск, возвращается для обработки:
+// Enter выполняет команду, стрелки и Ctrl+A/E переходят к её редактированию. Ctrl+G отменяет поиск
+func (e *lineEditor) reverseSearch(line *editLine, entries []string) (rune, error) {
+	original := *line
+	original.buf = append([]rune(nil), line.buf...)
+
+	query := ""
+	index := len(entries)
+	failed := false
+
+	// Ищет query в командах начиная с from
+	search := func(from int) {
+		for i := min(from, len(entries)-1); i >= 0; i-- {
+			if strings.Contains(entries[i], query) {
+				index = i
+				failed = false
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		match := ""
+		if index < len(entries) {
+			match = entries[index]
+		}
+
+		status := "reverse-i-search"
+		if failed {
+			status = "failed reverse-i-search"
+		}
+		view := &editLine{prompt: fmt.Sprintf("(%s)`%s': ", status, query), buf: []rune(match)}
+		if i := strings.Index(match, query); i >= 0 {
+			view.pos = utf8.RuneCountInString(match[:i])
+		}
+		e.refresh(view)
+
+		key, err := e.readKey()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case key == keyCtrlR:
+			if query != "" {
+				search(index - 1)
+			}
+		case key == keyBackspace || key == keyDelete:
+			if query != "" {
+				_, size := utf8.DecodeLastRuneInString(query)
+				query = query[:len(query)-size]
+				search(len(entries) - 1)
+			}
+		case key == keyCtrlG:
+			*line = original
+			return 0, nil
+		case key >= ' ':
+			query += string(key)
+			search(index)
+		default:
+			line.buf = []rune(match)
+			line.pos = view.pos
+			if match == "" {
+				*line = original
+			}
+			return key, nil
+		}
+	}
+}
+
+// Дополняет слово перед курсором. Если вариантов несколько, вставляется их общее начало,
+// а повторный Tab выводит список вариантов
+func (e *lineEditor) completeWord(line *editLine, list bool) {
+	before := string(line.buf[:line.pos])
+	start, candidates := e.complete(before)
+	startPos := utf8.RuneCountInString(before[:start])
+
+	switch {
+	case len(candidates) == 0:
+		e.write("\a")
+	case len(candidates) == 1:
+		word := candidates[0]
+		if !strings.HasSuffix(word, "/") {
+			word += " "
+		}
+		line.deleteBack(startPos)
+		line.insert([]rune(word))
+	default:
+		prefix := commonPrefix(candidates)
+		if len(prefix) > len(before)-start {
+			line.deleteBack(startPos)
+			line.insert([]rune(prefix))
+			return
+		}
+		if list {
+			e.listCandidates(candidates)
+		} else {
+			e.write("\a")
+		}
+	}
+}
+
+// Выводит варианты дополнения столбцами под строкой
+func (e *lineEditor) listCandidates(candidates []string) {
+	width := 0
+	for _, candidate := range candidates {
+		width = max(width, utf8.RuneCountInString(completionName(candidate))+2)
+	}
+	columns := max(1, e.width()/width)
+
+	b := strings.Builder{}
+	b.WriteString("\n")
+	for i, candidate := range candidates {
+		name := completionName(candidate)
+		if (i+1)%columns == 0 || i == len(candidates)-1 {
+			b.WriteString(name + "\n")
+		} else {
+			b.WriteString(name + strings.Repeat(" ", width-utf8.RuneCountInString(name)))
+		}
+	}
+	e.write(b.String())
+}
+
+func (e *lineEditor) width() int {
+	if e.fd < 0 {
+		return 80
+	}
+	return terminalWidth(e.fd)
+}
+
+// Перерисовывает строку. Длинная строка прокручивается так, чтобы курсор оставался виден
+func (e *lineEditor) refresh(line *editLine) {
+	promptWidth := utf8.RuneCountInString(line.prompt)
+	visible := max(1, e.width()-promptWidth-1)
+
+	offset := 0
+	if line.pos > visible {
+		offset = line.pos - visible
+	}
+	end := min(len(line.buf), offset+visible)
+
+	b := strings.Builder{}
+	b.WriteString("\r")
+	b.WriteString(line.prompt)
+	b.WriteString(string(line.buf[offset:end]))
+	b.WriteString("\x1b[K\r")
+	if column := promptWidth + line.pos - offset; column > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", column)
+	}
+
+	e.write(b.String())
+}
+
+func (e *lineEditor) write(text string) {
+	_, _ = io.WriteString(e.out, text)
+}
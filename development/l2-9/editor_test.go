@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Редактор, который читает нажатия клавиш из строки, не меняя режим терминала
+func newTestEditor(sh *shell, keys string) *lineEditor {
+	return &lineEditor{
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      io.Discard,
+		fd:       -1,
+		history:  sh.history,
+		complete: sh.complete,
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	sh := newShell()
+	for _, line := range []string{"echo first", "ls -l", "echo second"} {
+		if err := sh.history.add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"echo hi\r", "echo hi"},
+		{"ech hi\x01\x06\x06\x06o\r", "echo hi"},
+		{"echo hi\x1b[D\x1b[D\x1b[Dx\x1b[C\x1b[Cy\r", "echox hyi"},
+		{"hi\x1b[Hecho \x1b[F!\r", "echo hi!"},
+		{"echo aaa bbb  \x17\x17ccc\r", "echo ccc"},
+		{"junk\x15echo ok\r", "echo ok"},
+		{"echo abc\x02\x02\x0b\r", "echo a"},
+		{"abc\x7f\x7f\x08x\r", "x"},
+		{"abc\x01\x04\x1b[3~\r", "c"},
+		// История: стрелки вверх и вниз, набранная строка сохраняется
+		{"\x1b[A\r", "echo second"},
+		{"\x1b[A\x1b[A\x1b[A\x1b[A\r", "echo first"},
+		{"draft\x1b[A\x10\x0e\x1b[B\r", "draft"},
+		// Ctrl+R ищет от новых команд к старым
+		{"\x12echo\r", "echo second"},
+		{"\x12echo\x12\r", "echo first"},
+		{"\x12ls\x05 -a\r", "ls -l -a"},
+		// После поиска курсор остаётся на найденном тексте
+		{"\x12-l\x1b[Dx\r", "lsx -l"},
+		{"x\x12missing\x07\r", "x"},
+		{"\x12ls -x\x7f\x7fl\x05 /tmp\r", "ls -l /tmp"},
+		// Tab дополняет встроенные команды
+		{"hist\t\r", "history "},
+		{"ech\t\r", "echo "},
+	}
+
+	for _, test := range tests {
+		line, err := newTestEditor(sh, test.keys).readLine("> ")
+		if err != nil || line != test.expected {
+			t.Errorf("%q = %q, %v, expected %q", test.keys, line, err, test.expected)
+		}
+	}
+
+	if _, err := newTestEditor(sh, "abc\x03").readLine("> "); !errors.Is(err, errInterrupted) {
+		t.Errorf("Ctrl+C error = %v, expected %v", err, errInterrupted)
+	}
+	if _, err := newTestEditor(sh, "\x04").readLine("> "); !errors.Is(err, io.EOF) {
+		t.Errorf("Ctrl+D error = %v, expected %v", err, io.EOF)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha.txt", "alpine/", "beta one.txt", ".hidden", "bin/tool", "bin/toolkit", "bin/data"} {
+		path := filepath.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "bin/data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	t.Setenv("PATH", filepath.Join(dir, "bin"))
+	t.Setenv("HOME", dir)
+
+	tests := []struct {
+		line       string
+		start      int
+		candidates []string
+	}{
+		{"too", 0, []string{"tool", "toolkit"}},
+		{"ec", 0, []string{"echo"}},
+		{"ls al", 3, []string{"alpha.txt", "alpine/"}},
+		{"cat b", 4, []string{"beta\\ one.txt", "bin/"}},
+		{"cat beta\\ o", 4, []string{"beta\\ one.txt"}},
+		{"cat 'beta o", 4, []string{"beta\\ one.txt"}},
+		{"cat .h", 4, []string{".hidden"}},
+		{"cat bin/t", 4, []string{"bin/tool", "bin/toolkit"}},
+		{"cat ~/alph", 4, []string{"~/alpha.txt"}},
+		{"echo x | to", 9, []string{"tool", "toolkit"}},
+		{"echo x>al", 7, []string{"alpha.txt", "alpine/"}},
+		{"if true; then to", 14, []string{"tool", "toolkit"}},
+		{"for x in a; do to", 15, []string{"tool", "toolkit"}},
+		{"echo then al", 10, []string{"alpha.txt", "alpine/"}},
+		{"./bin/d", 0, []string{"./bin/data"}},
+		{"cat missing/", 4, nil},
+	}
+
+	sh := newShell()
+	for _, test := range tests {
+		start, candidates := sh.complete(test.line)
+		if start != test.start || !slices.Equal(candidates, test.candidates) {
+			t.Errorf("complete(%q) = %d, %q, expected %d, %q", test.line, start, candidates, test.start, test.candidates)
+		}
+	}
+
+	if prefix := commonPrefix([]string{"ёж", "ёлка"}); prefix != "ё" {
+		t.Errorf("commonPrefix = %q, expected %q", prefix, "ё")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("one\n\ntwo\nmulti\\nline \\\\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHistory(4)
+	if err := h.load(path); err != nil {
+		t.Fatalf("load error = %v", err)
+	}
+
+	for _, line := range []string{"three", "three", " ", "four"} {
+		if err := h.add(line); err != nil {
+			t.Fatalf("add(%q) error = %v", line, err)
+		}
+	}
+
+	expected := []string{"two", "multi\nline \\n", "three", "four"}
+	if entries := h.list(); !slices.Equal(entries, expected) {
+		t.Errorf("list = %q, expected %q", entries, expected)
+	}
+
+	// Файл дописывается, а при загрузке обрезается до лимита
+	reloaded := newHistory(4)
+	if err := reloaded.load(path); err != nil {
+		t.Fatalf("load error = %v", err)
+	}
+	if entries := reloaded.list(); !slices.Equal(entries, expected) {
+		t.Errorf("reloaded = %q, expected %q", entries, expected)
+	}
+
+	sh := newShell()
+	sh.history = reloaded
+
+	if stdout, _ := runShellInput(t, sh, "history 2"); stdout != "    3  three\n    4  four\n" {
+		t.Errorf("history 2 = %q", stdout)
+	}
+	if _, status := runShellInput(t, sh, "history x"); status != 1 {
+		t.Errorf("history x status = %d, expected 1", status)
+	}
+
+	if _, status := runShellInput(t, sh, "history -c"); status != 0 {
+		t.Errorf("history -c status = %d", status)
+	}
+	if contents, err := os.ReadFile(path); err != nil || len(contents) != 0 {
+		t.Errorf("history file = %q, %v, expected empty", contents, err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import "syscall"
+
+// Выполняет команды списка по очереди и возвращает код завершения последней.
+// После exit, ошибки при set -e, Ctrl+C, break или continue оставшиеся команды не выполняются
+func (sh *shell) runList(list *commandList, s streams) int {
+	status := 0
+
+	for _, item := range list.items {
+		if item.background {
+			status = sh.runBackground(item, s)
+			sh.setStatus(status)
+			continue
+		}
+
+		last := false
+		status, last = sh.runAndOr(item, s)
+
+		// При set -e ошибка конвейера, проверяемого через && или ||, или в условии if и while шелл не завершает
+		if status != 0 && last && sh.option("errexit") && !sh.inCondition() {
+			sh.requestExit(status)
+		}
+		if sh.interruptedBy(status) {
+			sh.setInterrupted(true)
+		}
+		if sh.aborted() {
+			break
+		}
+	}
+
+	if code, ok := sh.exitRequested(); ok {
+		return code
+	}
+	return status
+}
+
+// Выполняет цепочку a && b || c: конвейер после && выполняется, только если предыдущий код 0,
+// после || - только если не 0. Пропущенный конвейер сохраняет код предыдущего.
+// Второе значение сообщает, был ли код получен от последнего конвейера цепочки
+func (sh *shell) runAndOr(item *andOr, s streams) (int, bool) {
+	status := sh.runPipeline(item.pipelines[0], s)
+	sh.setStatus(status)
+	last := len(item.ops) == 0
+
+	for i, op := range item.ops {
+		if sh.aborted() {
+			break
+		}
+		if (op == "&&") != (status == 0) {
+			continue
+		}
+		status = sh.runPipeline(item.pipelines[i+1], s)
+		sh.setStatus(status)
+		last = i == len(item.ops)-1
+	}
+
+	return status, last
+}
+
+// Выполняет условие if или while: его код завершения проверяется, а не завершает шелл при set -e
+func (sh *shell) runCondition(list *commandList, s streams) int {
+	sh.mu.Lock()
+	sh.conditions++
+	sh.mu.Unlock()
+
+	defer func() {
+		sh.mu.Lock()
+		sh.conditions--
+		sh.mu.Unlock()
+	}()
+
+	return sh.runList(list, s)
+}
+
+func (sh *shell) inCondition() bool {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.conditions > 0
+}
+
+// Сообщает, прерывает ли код завершения команды выполнение строки. Ctrl+C и Ctrl+Z в терминале действуют
+// на всю строку, как в bash: иначе цикл нельзя было бы остановить. Копия шелла в задании, как подоболочка
+// в группе его процессов, завершается вместе с командой, убитой сигналом, кроме SIGPIPE: kill %1 останавливает и цикл
+func (sh *shell) interruptedBy(status int) bool {
+	if sh.job != nil {
+		return status > 128 && status != 128+int(syscall.SIGPIPE)
+	}
+	return sh.terminal >= 0 && (status == 128+int(syscall.SIGINT) || status == 128+int(syscall.SIGTSTP))
+}
+
+func (sh *shell) setInterrupted(interrupted bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.interrupted = interrupted
+}
+
+// Сообщает, что выполнение нужно прервать: после exit, ошибки при set -e, Ctrl+C или до цикла,
+// который покидают break и continue
+func (sh *shell) aborted() bool {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.exiting || sh.interrupted || sh.loopJumps > 0
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Разделители полей при разбиении подстановок без кавычек
+const fieldSeparators = " \t\n"
+
+// Специальные параметры: $?, $$, $!, $#, $* и $@
+const specialParams = "?$!#*@"
+
+// Поля, на которые раскрывается слово
+type fields struct {
+	list    []string
+	current strings.Builder
+	// Текущее поле начато, даже если пустое: "" даёт пустой аргумент, а пустая $VAR - ни одного
+	started bool
+}
+
+func (f *fields) add(text string) {
+	if text != "" {
+		f.current.WriteString(text)
+		f.started = true
+	}
+}
+
+// Кавычки начинают поле, даже если внутри пусто
+func (f *fields) quote() {
+	f.started = true
+}
+
+// Добавляет результат подстановки без кавычек, разбивая его по пробелам
+func (f *fields) addSplit(text string) {
+	for _, c := range text {
+		if strings.ContainsRune(fieldSeparators, c) {
+			f.end()
+			continue
+		}
+		f.current.WriteRune(c)
+		f.started = true
+	}
+}
+
+// Каждый параметр "$@" - отдельное поле, а без параметров "$@" не даёт ни одного
+func (f *fields) addEach(args []string) {
+	for i, arg := range args {
+		if i > 0 {
+			f.end()
+		}
+		f.quote()
+		f.add(arg)
+	}
+}
+
+func (f *fields) end() {
+	if f.started {
+		f.list = append(f.list, f.current.String())
+		f.current.Reset()
+		f.started = false
+	}
+}
+
+// Раскрывает слова команды в аргументы: ~, $VAR, ${VAR}, ${VAR:-default}, специальные и позиционные параметры.
+// Результаты подстановок вне кавычек разбиваются по пробелам, а "$@" даёт по аргументу на каждый параметр
+func (sh *shell) expandWords(words []word) ([]string, error) {
+	args := make([]string, 0, len(words))
+
+	for _, w := range words {
+		f, err := sh.expandWord(w, true)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, f...)
+	}
+
+	return args, nil
+}
+
+// Значение присваивания раскрывается без разбиения на поля
+func (sh *shell) expandAssignment(w word) (string, error) {
+	f, err := sh.expandWord(w, false)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(f, ""), nil
+}
+
+// Имя файла перенаправления должно раскрыться ровно в одно слово
+func (sh *shell) expandRedirectTarget(w word) (string, error) {
+	f, err := sh.expandWord(w, true)
+	if err != nil {
+		return "", err
+	}
+
+	if len(f) != 1 {
+		return "", fmt.Errorf("%s: ambiguous redirect", w)
+	}
+
+	return f[0], nil
+}
+
+// Шаблон case раскрывается без разбиения на поля. Символы *, ?, [ и \ в кавычках экранируются и совпадают только сами с собой
+func (sh *shell) expandPattern(w word) (string, error) {
+	b := strings.Builder{}
+	write := func(text string) { b.WriteString(text) }
+	escape := func(text string) { b.WriteString(escapePattern(text)) }
+
+	for i, part := range w {
+		switch part.quoting {
+		case literal:
+			escape(part.text)
+		case doubleQuoted:
+			if err := sh.expandParams(part.text, escape, escape); err != nil {
+				return "", err
+			}
+		default:
+			text := part.text
+			if i == 0 {
+				text = sh.expandTilde(text)
+			}
+			if err := sh.expandParams(text, write, write); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+func escapePattern(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(text)
+}
+
+func (sh *shell) expandWord(w word, split bool) ([]string, error) {
+	f := &fields{}
+
+	for i, part := range w {
+		switch part.quoting {
+		case literal:
+			f.quote()
+			f.add(part.text)
+		case doubleQuoted:
+			if part.text == "$@" || part.text == "${@}" {
+				f.addEach(sh.positional())
+				continue
+			}
+			f.quote()
+			if err := sh.expandParams(part.text, f.add, f.add); err != nil {
+				return nil, err
+			}
+		default:
+			text := part.text
+			if i == 0 {
+				text = sh.expandTilde(text)
+			}
+
+			param := f.add
+			if split {
+				param = f.addSplit
+			}
+			if err := sh.expandParams(text, f.add, param); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f.end()
+
+	return f.list, nil
+}
+
+// ~ и ~/path в начале слова - домашний каталог
+func (sh *shell) expandTilde(text string) string {
+	if text != "~" && !strings.HasPrefix(text, "~/") {
+		return text
+	}
+
+	home, ok := sh.getVar("HOME")
+	if !ok {
+		return text
+	}
+
+	return home + text[1:]
+}
+
+// Находит подстановки $ в text: обычный текст передаётся в literal, значения переменных - в param
+func (sh *shell) expandParams(text string, literal, param func(string)) error {
+	for {
+		i := strings.IndexByte(text, '$')
+		if i < 0 {
+			literal(text)
+			return nil
+		}
+
+		literal(text[:i])
+		text = text[i+1:]
+
+		value, size, ok, err := sh.expandParam(text)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// $ без имени переменной остаётся как есть
+			literal("$")
+			continue
+		}
+
+		param(value)
+		text = text[size:]
+	}
+}
+
+// Раскрывает подстановку в начале text (после $). Возвращает значение и длину подстановки
+func (sh *shell) expandParam(text string) (string, int, bool, error) {
+	if text == "" {
+		return "", 0, false, nil
+	}
+
+	switch c := text[0]; {
+	case c == '{':
+		end := matchingBrace(text)
+		if end < 0 {
+			return "", 0, false, fmt.Errorf("${%s: bad substitution", text[1:])
+		}
+
+		value, err := sh.expandBraced(text[1:end])
+		return value, end + 1, true, err
+	case strings.IndexByte(specialParams, c) >= 0 || (c >= '0' && c <= '9'):
+		value, _ := sh.getVar(text[:1])
+		return value, 1, true, nil
+	default:
+		size := 0
+		for size < len(text) && isName(text[:size+1]) {
+			size++
+		}
+		if size == 0 {
+			return "", 0, false, nil
+		}
+
+		value, _ := sh.getVar(text[:size])
+		return value, size, true, nil
+	}
+}
+
+// ${NAME}, ${NAME:-default} (если не задана или пуста) и ${NAME-default} (если не задана)
+func (sh *shell) expandBraced(expr string) (string, error) {
+	size := 0
+	if expr != "" && strings.IndexByte(specialParams+"0123456789", expr[0]) >= 0 {
+		size = 1
+	} else {
+		for size < len(expr) && isName(expr[:size+1]) {
+			size++
+		}
+	}
+
+	name, op := expr[:size], expr[size:]
+	if name == "" {
+		return "", fmt.Errorf("${%s}: bad substitution", expr)
+	}
+
+	value, ok := sh.getVar(name)
+
+	switch {
+	case op == "":
+		return value, nil
+	case strings.HasPrefix(op, ":-"):
+		if ok && value != "" {
+			return value, nil
+		}
+		return sh.expandDefault(op[2:])
+	case strings.HasPrefix(op, "-"):
+		if ok {
+			return value, nil
+		}
+		return sh.expandDefault(op[1:])
+	default:
+		return "", fmt.Errorf("${%s}: bad substitution", expr)
+	}
+}
+
+// Значение по умолчанию само может содержать подстановки
+func (sh *shell) expandDefault(text string) (string, error) {
+	b := strings.Builder{}
+	write := func(s string) { b.WriteString(s) }
+
+	err := sh.expandParams(text, write, write)
+
+	return b.String(), err
+}
+
+// Индекс }, закрывающей { в начале text, с учётом вложенных ${...}, или -1
+func matchingBrace(text string) int {
+	depth := 0
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Сколько команд хранится в истории
+const historySize = 1000
+
+// Файл истории в домашнем каталоге, если не задана переменная HISTFILE
+const historyFileName = ".goshell_history"
+
+// История введённых команд. Каждая команда сразу дописывается в файл, поэтому история
+// сохраняется, даже если шелл завершился аварийно, а несколько шеллов не затирают команды друг друга
+type history struct {
+	mu      sync.Mutex
+	entries []string
+	// Файл истории, пусто - история только в памяти
+	path  string
+	limit int
+}
+
+func newHistory(limit int) *history {
+	return &history{limit: limit}
+}
+
+// Путь к файлу истории: $HISTFILE или ~/.goshell_history
+func (sh *shell) historyPath() string {
+	if path, ok := sh.getVar("HISTFILE"); ok && path != "" {
+		return path
+	}
+
+	home, ok := sh.getVar("HOME")
+	if !ok || home == "" {
+		return ""
+	}
+	return filepath.Join(home, historyFileName)
+}
+
+// Загружает историю из файла и дальше дописывает в него новые команды.
+// Файл, который вырос больше лимита, перезаписывается последними командами
+func (h *history) load(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.path = path
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			entries = append(entries, decodeHistoryLine(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	h.entries = append(entries, h.entries...)
+	if len(h.entries) <= h.limit {
+		return nil
+	}
+
+	h.entries = slices.Clone(h.entries[len(h.entries)-h.limit:])
+	return h.rewrite()
+}
+
+// Добавляет команду в историю. Пустые команды и повтор предыдущей не сохраняются
+func (h *history) add(line string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if strings.TrimSpace(line) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.limit {
+		h.entries = slices.Delete(h.entries, 0, len(h.entries)-h.limit)
+	}
+
+	if h.path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(encodeHistoryLine(line) + "\n")
+	return errors.Join(err, file.Close())
+}
+
+// Копия команд истории от старых к новым
+func (h *history) list() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return slices.Clone(h.entries)
+}
+
+// Очищает историю вместе с файлом
+func (h *history) clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = nil
+	if h.path == "" {
+		return nil
+	}
+	return h.rewrite()
+}
+
+// Атомарно заменяет файл истории текущими командами
+func (h *history) rewrite() error {
+	tmp := h.path + ".tmp"
+
+	b := strings.Builder{}
+	for _, entry := range h.entries {
+		b.WriteString(encodeHistoryLine(entry) + "\n")
+	}
+
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// Многострочная команда хранится в файле одной строкой: перевод строки записывается как \n, а \ - как \\
+func encodeHistoryLine(line string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(line)
+}
+
+func decodeHistoryLine(line string) string {
+	b := strings.Builder{}
+
+	for i := 0; i < len(line); i++ {
+		if line[i] != '\\' || i+1 == len(line) {
+			b.WriteByte(line[i])
+			continue
+		}
+
+		i++
+		if line[i] == 'n' {
+			b.WriteByte('\n')
+		} else {
+			b.WriteByte(line[i])
+		}
+	}
+
+	return b.String()
+}
+
+// Выводит историю: history [N] - все или N последних команд с номерами, history -c - очищает историю
+func executeHistory(sh *shell, args []string, s streams) int {
+	if len(args) > 1 && args[1] == "-c" {
+		if err := sh.history.clear(); err != nil {
+			fmt.Fprintf(s.stderr, "history error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	entries := sh.history.list()
+
+	first := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			fmt.Fprintf(s.stderr, "history error: %s: numeric argument required\n", args[1])
+			return 1
+		}
+		first = max(0, len(entries)-n)
+	}
+
+	for i := first; i < len(entries); i++ {
+		if _, err := fmt.Fprintf(s.stdout, "%5d  %s\n", i+1, entries[i]); err != nil {
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,753 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var errNoSuchJob = errors.New("no such job")
+
+type jobState int
+
+const (
+	jobRunning jobState = iota
+	jobStopped
+	jobDone
+)
+
+// Задание - конвейер или цепочка конвейеров, запущенная из одной строки. Внешние команды
+// конвейера образуют группу процессов, и сигналы от терминала получает вся группа
+type job struct {
+	// Номер в списке заданий, 0 - задание ещё не в списке
+	id   int
+	text string
+	// Задание на переднем плане получает терминал при запуске процессов
+	foreground bool
+
+	mu     sync.Mutex
+	pgid   int
+	pids   []int
+	state  jobState
+	status int
+	// Состояние, о котором уже сообщено пользователю
+	reported jobState
+
+	// Закрывается, когда задание завершено
+	done chan struct{}
+	// Сигнал об остановке задания
+	stopped chan struct{}
+}
+
+func newJob(text string, foreground bool) *job {
+	return &job{
+		text:       text,
+		foreground: foreground,
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}, 1),
+	}
+}
+
+// Следующий конвейер цепочки запускается в новой группе процессов
+func (j *job) newGroup() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pgid = 0
+}
+
+// Запускает процесс в группе задания. Первый процесс группы становится её лидером.
+// Без управления заданиями команды на переднем плане остаются в группе шелла и вместе с ним получают Ctrl+C
+func (j *job) start(cmd *exec.Cmd, terminal int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	grouped := !j.foreground || terminal >= 0
+	if grouped {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: j.pgid}
+		if j.foreground {
+			cmd.SysProcAttr.Foreground = true
+			cmd.SysProcAttr.Ctty = terminal
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if grouped && j.pgid == 0 {
+		j.pgid = cmd.Process.Pid
+	}
+	j.pids = append(j.pids, cmd.Process.Pid)
+
+	return nil
+}
+
+// Следит за остановкой и продолжением процесса, пока он не завершится.
+// Завершившийся процесс остаётся несобранным для exec.Cmd.Wait
+func (j *job) track(pid int) {
+	for {
+		info, err := waitChild(pid, syscall.WEXITED|syscall.WSTOPPED|syscall.WCONTINUED|syscall.WNOWAIT)
+		if err != nil {
+			return
+		}
+
+		switch info.code {
+		case cldStopped, cldContinued:
+			if _, err := waitChild(pid, syscall.WSTOPPED|syscall.WCONTINUED|syscall.WNOHANG); err != nil {
+				return
+			}
+			j.setStopped(info.code == cldStopped)
+		default:
+			return
+		}
+	}
+}
+
+func (j *job) setStopped(stopped bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state == jobDone {
+		return
+	}
+
+	if !stopped {
+		j.state = jobRunning
+		return
+	}
+
+	j.state = jobStopped
+	select {
+	case j.stopped <- struct{}{}:
+	default:
+	}
+}
+
+// Продолжает остановленное задание сигналом SIGCONT
+func (j *job) resume() error {
+	// Старые уведомления об остановке больше не нужны
+	select {
+	case <-j.stopped:
+	default:
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state == jobDone {
+		return nil
+	}
+	j.state = jobRunning
+
+	if j.pgid == 0 {
+		return nil
+	}
+	return syscall.Kill(-j.pgid, syscall.SIGCONT)
+}
+
+func (j *job) finish(status int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state = jobDone
+	j.status = status
+	close(j.done)
+}
+
+func (j *job) getState() (jobState, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.state, j.status
+}
+
+func (j *job) group() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.pgid
+}
+
+// Запоминает состояние, о котором сообщено пользователю. Возвращает false, если о нём уже сообщалось
+func (j *job) markReported(state jobState) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.reported == state {
+		return false
+	}
+	j.reported = state
+	return true
+}
+
+// Сообщает, принадлежит ли процесс pid заданию
+func (j *job) hasPid(pid int) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return slices.Contains(j.pids, pid)
+}
+
+// Сообщает, принадлежит ли процесс pid одному из заданий списка, в том числе как процесс, запущенный
+// командой задания в её группе
+func (t *jobTable) owns(pid int) bool {
+	t.mu.Lock()
+	jobs := slices.Clone(t.jobs)
+	if t.foreground != nil {
+		jobs = append(jobs, t.foreground)
+	}
+	t.mu.Unlock()
+
+	pgid, err := syscall.Getpgid(pid)
+
+	for _, j := range jobs {
+		if j.hasPid(pid) || (err == nil && pgid == j.group()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Список заданий шелла, общий для всех его копий
+type jobTable struct {
+	mu sync.Mutex
+	// Последнее задание списка - текущее (%+), предпоследнее - предыдущее (%-)
+	jobs []*job
+	// Задание, которое сейчас выполняется на переднем плане
+	foreground *job
+	// PID последнего фонового процесса, $!
+	lastPid int
+
+	// Ctrl+C, полученный шеллом, когда на переднем плане нет процессов. Прерывает wait
+	interrupts chan struct{}
+}
+
+func newJobTable() *jobTable {
+	return &jobTable{interrupts: make(chan struct{}, 1)}
+}
+
+// Добавляет задание в конец списка, делая его текущим. Задание, которое уже было в списке, сохраняет номер
+func (t *jobTable) add(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if j.id == 0 {
+		j.id = 1
+		for _, other := range t.jobs {
+			j.id = max(j.id, other.id+1)
+		}
+	}
+
+	t.jobs = append(t.jobs, j)
+}
+
+func (t *jobTable) remove(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.jobs = slices.DeleteFunc(t.jobs, func(other *job) bool {
+		return other == j
+	})
+}
+
+func (t *jobTable) setForeground(j *job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.foreground = j
+}
+
+func (t *jobTable) setLastPid(pid int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastPid = pid
+}
+
+func (t *jobTable) getLastPid() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.lastPid
+}
+
+// Находит задание по спецификации: %n - по номеру, %%, %+ и % - текущее, %- - предыдущее,
+// %prefix - по началу команды, %?text - по подстроке команды
+func (t *jobTable) find(spec string) (*job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name, ok := strings.CutPrefix(spec, "%")
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", spec, errNoSuchJob)
+	}
+
+	switch {
+	case name == "" || name == "%" || name == "+":
+		if len(t.jobs) > 0 {
+			return t.jobs[len(t.jobs)-1], nil
+		}
+		return nil, fmt.Errorf("current: %w", errNoSuchJob)
+	case name == "-":
+		if len(t.jobs) > 1 {
+			return t.jobs[len(t.jobs)-2], nil
+		}
+		if len(t.jobs) > 0 {
+			return t.jobs[0], nil
+		}
+		return nil, fmt.Errorf("previous: %w", errNoSuchJob)
+	}
+
+	if id, err := strconv.Atoi(name); err == nil {
+		for _, j := range t.jobs {
+			if j.id == id {
+				return j, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", spec, errNoSuchJob)
+	}
+
+	match := func(j *job) bool {
+		return strings.HasPrefix(j.text, name)
+	}
+	if text, ok := strings.CutPrefix(name, "?"); ok {
+		match = func(j *job) bool {
+			return strings.Contains(j.text, text)
+		}
+	}
+
+	var found *job
+	for _, j := range t.jobs {
+		if !match(j) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s: ambiguous job spec", spec)
+		}
+		found = j
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s: %w", spec, errNoSuchJob)
+	}
+
+	return found, nil
+}
+
+// Задания по номеру, каждое с отметкой + (текущее), - (предыдущее) или пробелом
+func (t *jobTable) list() ([]*job, map[*job]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	markers := make(map[*job]string, len(t.jobs))
+	for i, j := range t.jobs {
+		switch i {
+		case len(t.jobs) - 1:
+			markers[j] = "+"
+		case len(t.jobs) - 2:
+			markers[j] = "-"
+		default:
+			markers[j] = " "
+		}
+	}
+
+	jobs := slices.Clone(t.jobs)
+	slices.SortFunc(jobs, func(a, b *job) int {
+		return a.id - b.id
+	})
+
+	return jobs, markers
+}
+
+// Строки о заданиях, состояние которых изменилось с прошлого сообщения. Завершённые задания удаляются из списка
+func (t *jobTable) changes() []string {
+	jobs, markers := t.list()
+
+	lines := make([]string, 0)
+	for _, j := range jobs {
+		state, _ := j.getState()
+		if !j.markReported(state) {
+			continue
+		}
+
+		lines = append(lines, describeJob(j, markers[j], false))
+		if state == jobDone {
+			t.remove(j)
+		}
+	}
+
+	return lines
+}
+
+// Передаёт сигнал от терминала заданию на переднем плане. Без такого задания Ctrl+C прерывает wait, а шелл продолжает работу
+func (t *jobTable) forward(sig syscall.Signal) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.foreground != nil {
+		if pgid := t.foreground.group(); pgid != 0 {
+			_ = syscall.Kill(-pgid, sig)
+			return
+		}
+	}
+
+	if sig == syscall.SIGINT {
+		select {
+		case t.interrupts <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Строка списка заданий: [1]+  Running                 sleep 10 &
+func describeJob(j *job, marker string, long bool) string {
+	state, status := j.getState()
+
+	text := j.text
+	description := ""
+	switch state {
+	case jobRunning:
+		description = "Running"
+		text += " &"
+	case jobStopped:
+		description = "Stopped"
+	default:
+		description = describeStatus(status)
+	}
+
+	if long {
+		return fmt.Sprintf("[%d]%s %d %-24s%s", j.id, marker, j.group(), description, text)
+	}
+	return fmt.Sprintf("[%d]%s  %-24s%s", j.id, marker, description, text)
+}
+
+// Done, Exit N или название сигнала, завершившего задание
+func describeStatus(status int) string {
+	switch {
+	case status == 0:
+		return "Done"
+	case status > 128 && status < 128+65:
+		name := syscall.Signal(status - 128).String()
+		if name != "" && !strings.HasPrefix(name, "signal ") {
+			return strings.ToUpper(name[:1]) + name[1:]
+		}
+	}
+
+	return fmt.Sprintf("Exit %d", status)
+}
+
+// Выполняет цепочку конвейеров в фоне. Код завершения будет доступен через wait
+func (sh *shell) runBackground(item *andOr, s streams) int {
+	j := newJob(item.text, false)
+
+	// Без управления заданиями фоновое задание не должно читать ввод шелла
+	var devNull *os.File
+	if sh.terminal < 0 {
+		file, err := os.Open(os.DevNull)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+			return 1
+		}
+		devNull = file
+		s.stdin = file
+	}
+
+	// Фоновое задание, как подоболочка, не меняет переменные и текущий каталог шелла
+	bg := sh.subshell()
+
+	first := bg.startPipeline(item.pipelines[0], s, j)
+	sh.jobs.add(j)
+	if pgid := j.group(); pgid != 0 {
+		sh.jobs.setLastPid(pgid)
+		// Номер задания выводится, только когда шелл управляет заданиями на терминале
+		if sh.terminal >= 0 {
+			fmt.Fprintf(s.stderr, "[%d] %d\n", j.id, pgid)
+		}
+	}
+
+	go func() {
+		status := <-first
+		bg.setStatus(status)
+
+		for i, op := range item.ops {
+			if (op == "&&") != (status == 0) {
+				continue
+			}
+			status = <-bg.startPipeline(item.pipelines[i+1], s, j)
+			bg.setStatus(status)
+		}
+
+		if devNull != nil {
+			_ = devNull.Close()
+		}
+		j.finish(status)
+	}()
+
+	return 0
+}
+
+// Ждёт задание на переднем плане, пока оно не завершится или не будет остановлено.
+// Остановленное задание попадает в список заданий, его код завершения 128+SIGTSTP
+func (sh *shell) waitForeground(j *job, s streams) int {
+	sh.jobs.setForeground(j)
+	defer sh.jobs.setForeground(nil)
+
+	for {
+		select {
+		case <-j.done:
+			sh.takeTerminal(j)
+			_, status := j.getState()
+			return status
+		case <-j.stopped:
+			if state, _ := j.getState(); state != jobStopped {
+				continue
+			}
+
+			sh.takeTerminal(j)
+			j.markReported(jobStopped)
+			sh.jobs.add(j)
+
+			_, markers := sh.jobs.list()
+			fmt.Fprintf(s.stderr, "\n%s\n", describeJob(j, markers[j], false))
+			return 128 + int(syscall.SIGTSTP)
+		}
+	}
+}
+
+// Возвращает терминал шеллу после задания на переднем плане
+func (sh *shell) takeTerminal(j *job) {
+	if sh.terminal < 0 || j.group() == 0 {
+		return
+	}
+	_ = setForegroundGroup(sh.terminal, sh.pgid)
+}
+
+// Включает управление заданиями, если ввод шелла - терминал: шелл становится лидером своей группы процессов
+// и её делает активной группой терминала
+func (sh *shell) enableJobControl(fd int) {
+	if !isTerminal(fd) {
+		return
+	}
+
+	// Лидер сеанса уже лидер своей группы, и setpgid для него вернёт ошибку
+	_ = syscall.Setpgid(0, 0)
+
+	pgid := syscall.Getpgrp()
+	if err := setForegroundGroup(fd, pgid); err != nil {
+		return
+	}
+
+	sh.terminal = fd
+	sh.pgid = pgid
+}
+
+// Перехватывает сигналы от терминала, чтобы Ctrl+C и Ctrl+Z действовали на задание на переднем плане, а не на шелл
+func (sh *shell) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTSTP, syscall.SIGQUIT)
+
+	go func() {
+		for sig := range signals {
+			sh.jobs.forward(sig.(syscall.Signal))
+		}
+	}()
+}
+
+// Выводит изменения состояния фоновых заданий, например перед приглашением
+func (sh *shell) notifyJobs(w io.Writer) {
+	for _, line := range sh.jobs.changes() {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// Выводит список заданий: jobs [-l|-p] [%job...]
+func executeJobs(sh *shell, args []string, s streams) int {
+	long, pids := false, false
+
+	specs := args[1:]
+	for len(specs) > 0 && strings.HasPrefix(specs[0], "-") {
+		switch specs[0] {
+		case "-l":
+			long = true
+		case "-p":
+			pids = true
+		default:
+			fmt.Fprintf(s.stderr, "jobs: %s: invalid option\n", specs[0])
+			return 1
+		}
+		specs = specs[1:]
+	}
+
+	jobs, markers := sh.jobs.list()
+
+	status := 0
+	if len(specs) > 0 {
+		jobs = jobs[:0]
+		for _, spec := range specs {
+			j, err := sh.jobs.find(spec)
+			if err != nil {
+				fmt.Fprintf(s.stderr, "jobs: %v\n", err)
+				status = 1
+				continue
+			}
+			jobs = append(jobs, j)
+		}
+	}
+
+	for _, j := range jobs {
+		if pids {
+			fmt.Fprintln(s.stdout, j.group())
+			continue
+		}
+
+		state, _ := j.getState()
+		fmt.Fprintln(s.stdout, describeJob(j, markers[j], long))
+
+		// О завершённом задании сообщается один раз
+		j.markReported(state)
+		if state == jobDone {
+			sh.jobs.remove(j)
+		}
+	}
+
+	return status
+}
+
+// Продолжает задание на переднем плане: fg [%job]
+func executeFG(sh *shell, args []string, s streams) int {
+	j, ok := findJob(sh, "fg", args, s)
+	if !ok {
+		return 1
+	}
+
+	fmt.Fprintln(s.stdout, j.text)
+
+	// На время выполнения задание уходит из списка и возвращается в него, если снова будет остановлено
+	sh.jobs.remove(j)
+
+	if pgid := j.group(); sh.terminal >= 0 && pgid != 0 {
+		if err := setForegroundGroup(sh.terminal, pgid); err != nil {
+			fmt.Fprintf(s.stderr, "fg: %v\n", err)
+		}
+	}
+
+	if err := j.resume(); err != nil && !errors.Is(err, syscall.ESRCH) {
+		fmt.Fprintf(s.stderr, "fg: %v\n", err)
+	}
+
+	return sh.waitForeground(j, s)
+}
+
+// Продолжает остановленное задание в фоне: bg [%job]
+func executeBG(sh *shell, args []string, s streams) int {
+	j, ok := findJob(sh, "bg", args, s)
+	if !ok {
+		return 1
+	}
+
+	if state, _ := j.getState(); state != jobStopped {
+		fmt.Fprintf(s.stderr, "bg: job %d already in background\n", j.id)
+		return 0
+	}
+
+	if err := j.resume(); err != nil && !errors.Is(err, syscall.ESRCH) {
+		fmt.Fprintf(s.stderr, "bg: %v\n", err)
+		return 1
+	}
+	j.markReported(jobRunning)
+
+	_, markers := sh.jobs.list()
+	fmt.Fprintf(s.stdout, "[%d]%s %s &\n", j.id, markers[j], j.text)
+
+	return 0
+}
+
+// Задание из аргумента fg и bg, по умолчанию текущее
+func findJob(sh *shell, name string, args []string, s streams) (*job, bool) {
+	spec := "%+"
+	if len(args) > 1 {
+		spec = args[1]
+	}
+
+	j, err := sh.jobs.find(spec)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "%s: %v\n", name, err)
+		return nil, false
+	}
+
+	return j, true
+}
+
+// Ждёт завершения фоновых заданий: wait [%job|pid...]. Без аргументов ждёт все задания и возвращает 0,
+// иначе возвращает код последнего. Ctrl+C прерывает ожидание с кодом 130
+func executeWait(sh *shell, args []string, s streams) int {
+	// Ctrl+C, нажатый до wait, ожидание не прерывает
+	select {
+	case <-sh.jobs.interrupts:
+	default:
+	}
+
+	jobs := make([]*job, 0)
+	status := 0
+
+	if len(args) < 2 {
+		jobs, _ = sh.jobs.list()
+	}
+
+	for _, arg := range args[1:] {
+		j, err := findWaitTarget(sh, arg)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "wait: %v\n", err)
+			status = statusNotFound
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-sh.jobs.interrupts:
+			return 128 + int(syscall.SIGINT)
+		}
+
+		// Задание, которого дождались, из списка удаляется без уведомления
+		sh.jobs.remove(j)
+
+		if len(args) > 1 {
+			_, status = j.getState()
+		}
+	}
+
+	return status
+}
+
+// Задание по спецификации %job или по PID одного из его процессов
+func findWaitTarget(sh *shell, arg string) (*job, error) {
+	if strings.HasPrefix(arg, "%") {
+		return sh.jobs.find(arg)
+	}
+
+	pid, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("`%s': not a pid or valid job spec", arg)
+	}
+
+	jobs, _ := sh.jobs.list()
+	for _, j := range jobs {
+		if j.hasPid(pid) {
+			return j, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pid %d is not a child of this shell", pid)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var errNoProcessMatched = errors.New("no process matched")
+
+// Сигналы Linux в порядке номеров
+var signalNames = []string{
+	"HUP", "INT", "QUIT", "ILL", "TRAP", "ABRT", "BUS", "FPE", "KILL", "USR1", "SEGV", "USR2", "PIPE", "ALRM", "TERM",
+	"STKFLT", "CHLD", "CONT", "STOP", "TSTP", "TTIN", "TTOU", "URG", "XCPU", "XFSZ", "VTALRM", "PROF", "WINCH", "IO", "PWR", "SYS",
+}
+
+const killUsage = "usage: kill [-s sigspec | -n signum | -sigspec] pid | %job ... or kill [-sigspec] -p [-f] pattern ... or kill -l [sigspec]"
+
+// Сигнал по имени (TERM, SIGTERM, term) или номеру
+func parseSignal(spec string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 0 || n > len(signalNames) {
+			return 0, fmt.Errorf("%s: invalid signal specification", spec)
+		}
+		return syscall.Signal(n), nil
+	}
+
+	name := strings.TrimPrefix(strings.ToUpper(spec), "SIG")
+	for i, known := range signalNames {
+		if name == known {
+			return syscall.Signal(i + 1), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%s: invalid signal specification", spec)
+}
+
+// Отправляет сигнал процессам: kill [-SIG] pid|%job ... По умолчанию SIGTERM.
+// Отрицательный PID - группа процессов, %job - группа процессов задания. С -p цели - регулярные выражения
+// для всего имени процесса, как в pkill (с -f - для всей командной строки). Ошибка по одной цели не мешает остальным
+func executeKill(sh *shell, args []string, s streams) int {
+	sig := syscall.SIGTERM
+	sigSet := false
+	byPattern := false
+	fullCommand := false
+
+	i := 1
+options:
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+
+		switch arg {
+		case "--":
+			i++
+			break options
+		case "-l", "-L":
+			return listSignals(args[i+1:], s)
+		case "-p", "--pattern":
+			byPattern = true
+		case "-f":
+			fullCommand = true
+		case "-s", "-n":
+			if i+1 >= len(args) {
+				fmt.Fprintf(s.stderr, "kill error: %s: option requires an argument\n", arg)
+				return 1
+			}
+			i++
+			parsed, err := parseSignal(args[i])
+			if err != nil {
+				fmt.Fprintf(s.stderr, "kill error: %v\n", err)
+				return 1
+			}
+			sig, sigSet = parsed, true
+		default:
+			// После сигнала отрицательное число - группа процессов: kill -9 -123
+			if sigSet && isNumber(arg[1:]) {
+				break options
+			}
+			parsed, err := parseSignal(arg[1:])
+			if err != nil {
+				fmt.Fprintf(s.stderr, "kill error: %v\n", err)
+				return 1
+			}
+			sig, sigSet = parsed, true
+		}
+	}
+
+	if i >= len(args) || (fullCommand && !byPattern) {
+		fmt.Fprintf(s.stderr, "kill error: %s\n", killUsage)
+		return 1
+	}
+
+	status := 0
+	for _, target := range args[i:] {
+		var err error
+		if byPattern {
+			err = sh.signalMatching(target, sig, fullCommand)
+		} else {
+			err = sh.signalTarget(target, sig)
+		}
+		if err != nil {
+			fmt.Fprintf(s.stderr, "kill error: %v\n", err)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// Отправляет сигнал одной цели kill: PID или заданию
+func (sh *shell) signalTarget(target string, sig syscall.Signal) error {
+	if strings.HasPrefix(target, "%") {
+		j, err := sh.jobs.find(target)
+		if err != nil {
+			return err
+		}
+		return signalJob(j, sig)
+	}
+
+	pid, err := strconv.Atoi(target)
+	if err != nil {
+		return fmt.Errorf("%s: arguments must be process or job IDs", target)
+	}
+
+	if err := syscall.Kill(pid, sig); err != nil {
+		return fmt.Errorf("(%d) - %w", pid, err)
+	}
+	return nil
+}
+
+// Отправляет сигнал группе процессов задания. Остановленное задание продолжается, чтобы получить сигнал
+func signalJob(j *job, sig syscall.Signal) error {
+	pgid := j.group()
+	if pgid == 0 {
+		return fmt.Errorf("%%%d: job has no processes", j.id)
+	}
+
+	if err := syscall.Kill(-pgid, sig); err != nil {
+		return fmt.Errorf("%%%d: %w", j.id, err)
+	}
+
+	if state, _ := j.getState(); state == jobStopped && sig != syscall.SIGSTOP && sig != syscall.SIGTSTP {
+		_ = syscall.Kill(-pgid, syscall.SIGCONT)
+	}
+	return nil
+}
+
+// Отправляет сигнал процессам, имя которых (или командная строка при fullCommand) целиком соответствует шаблону.
+// Сам шелл, его родитель (терминал или скрипт, запустивший шелл) и процессы его заданий не затрагиваются:
+// задания выбираются через %job
+func (sh *shell) signalMatching(pattern string, sig syscall.Signal, fullCommand bool) error {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("%s: invalid pattern: %w", pattern, err)
+	}
+
+	processes, err := readProcesses(procRoot)
+	if err != nil {
+		return err
+	}
+
+	matched := 0
+	errs := make([]error, 0)
+
+	for _, p := range processes {
+		text := p.comm
+		if fullCommand {
+			text = p.command()
+		}
+		if p.pid == os.Getpid() || p.pid == os.Getppid() || !re.MatchString(text) || sh.jobs.owns(p.pid) {
+			continue
+		}
+
+		matched++
+		if err := syscall.Kill(p.pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+			errs = append(errs, fmt.Errorf("(%d) - %w", p.pid, err))
+		}
+	}
+
+	if matched == 0 {
+		return fmt.Errorf("%s: %w", pattern, errNoProcessMatched)
+	}
+	return errors.Join(errs...)
+}
+
+// kill -l выводит все сигналы, kill -l SIG - номер по имени или имя по номеру.
+// Номер больше 128 считается кодом завершения процесса, убитого сигналом
+func listSignals(specs []string, s streams) int {
+	if len(specs) == 0 {
+		for i, name := range signalNames {
+			fmt.Fprintf(s.stdout, "%2d) SIG%s\n", i+1, name)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, spec := range specs {
+		if n, err := strconv.Atoi(spec); err == nil && n > 128 {
+			spec = strconv.Itoa(n - 128)
+		}
+
+		sig, err := parseSignal(spec)
+		if err != nil || sig == 0 {
+			fmt.Fprintf(s.stderr, "kill error: %s: invalid signal specification\n", spec)
+			status = 1
+			continue
+		}
+
+		if isNumber(spec) {
+			fmt.Fprintln(s.stdout, signalNames[sig-1])
+		} else {
+			fmt.Fprintln(s.stdout, int(sig))
+		}
+	}
+
+	return status
+}
+
+func isNumber(text string) bool {
+	_, err := strconv.Atoi(text)
+	return err == nil && text != "" && text[0] != '-' && text[0] != '+'
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected syscall.Signal
+	}{
+		{"9", syscall.SIGKILL},
+		{"TERM", syscall.SIGTERM},
+		{"SIGINT", syscall.SIGINT},
+		{"hup", syscall.SIGHUP},
+		{"sigcont", syscall.SIGCONT},
+		{"0", 0},
+	}
+
+	for _, test := range tests {
+		if sig, err := parseSignal(test.spec); err != nil || sig != test.expected {
+			t.Errorf("parseSignal(%q) = %v, %v, expected %v", test.spec, sig, err, test.expected)
+		}
+	}
+
+	for _, spec := range []string{"", "BOGUS", "-1", "65", "SIG"} {
+		if _, err := parseSignal(spec); err == nil {
+			t.Errorf("parseSignal(%q) error = nil", spec)
+		}
+	}
+}
+
+func TestKill(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		status   int
+	}{
+		{"kill -l 9 143 TERM sigint", "KILL\nTERM\n15\n2\n", 0},
+		{"kill -l | wc -l", "31\n", 0},
+		{"kill -l 200", "", 1},
+		// По умолчанию SIGTERM
+		{"sleep 5 & kill %1; wait %1", "", 143},
+		{"sleep 5 & kill -9 $!; wait $!", "", 137},
+		{"sleep 5 & kill -s INT %sleep; wait", "", 0},
+		{"sleep 5 & kill -KILL -- -$!; wait %1", "", 137},
+		// Остановленное задание продолжается, чтобы завершиться
+		{"sleep 5 & kill -STOP %1; sleep 0.1; kill %1; wait %1", "", 143},
+		// Ошибка по одной цели не мешает остальным
+		{"sleep 5 & kill 999999999 %1 %9; echo $?; wait %1", "1\n", 143},
+		// Имя, которое не PID и не задание, - ошибка, а не шаблон
+		{"sleep 5 & kill sleep $!; echo $?; wait %1", "1\n", 143},
+		// Шаблон -p совпадает со всем именем или командной строкой процесса, не задевая задания шелла.
+		// sh завершается сразу, и sleep, не держащий вывод шелла, остаётся чужим процессом
+		{"sh -c 'sleep 5.25 >/dev/null 2>&1 &'; sleep 0.1; kill -p -f 'sleep 5\\.2'; echo $?; kill -p -f 'sleep 5\\.25'; echo $?", "1\n0\n", 0},
+		{"sleep 5.5 & kill -p -f 'sleep 5\\.5'; echo $?; kill %1; wait %1", "1\n", 143},
+		{"kill -f sleep", "", 1},
+		{"kill", "", 1},
+		{"kill -BOGUS 1", "", 1},
+		{"kill -s", "", 1},
+		{"kill -p '(bad'", "", 1},
+	}
+
+	for _, test := range tests {
+		stdout, status := runInput(t, test.input)
+
+		if strings.TrimLeft(stdout, " ") != test.expected || status != test.status {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.expected, test.status)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ввод закончился посреди конструкции: незакрытая кавычка, | или && в конце строки.
+// В интерактивном режиме ввод продолжается на следующей строке
+var errIncomplete = errors.New("unexpected end of input")
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	// Оператор: |, ||, &&, &, ;, ;; и скобки ( ) шаблонов case
+	tokenOperator
+	// Перенаправление ввода-вывода: <, >, >>, >&, &>, &>> с необязательным номером дескриптора
+	tokenRedirect
+	// Перевод строки разделяет команды так же, как ;
+	tokenNewline
+	tokenEOF
+)
+
+// Вид кавычек, в которых записана часть слова
+type quoting int
+
+const (
+	// Без кавычек
+	unquoted quoting = iota
+	// В двойных кавычках
+	doubleQuoted
+	// В одинарных кавычках или экранированная обратной косой чертой: текст берётся как есть
+	literal
+)
+
+type wordPart struct {
+	text    string
+	quoting quoting
+}
+
+// Слово команды. Части хранятся отдельно, так как кавычки влияют на подстановки
+type word []wordPart
+
+// Текст слова без кавычек и экранирования
+func (w word) String() string {
+	b := strings.Builder{}
+	for _, part := range w {
+		b.WriteString(part.text)
+	}
+	return b.String()
+}
+
+type token struct {
+	kind tokenKind
+	// Текст оператора или перенаправления
+	text string
+	word word
+	// Номер дескриптора перенаправления, -1 - по умолчанию для оператора
+	fd int
+	// Смещения начала и конца токена во входной строке
+	pos int
+	end int
+}
+
+// Операторы в порядке проверки: сначала более длинные
+var operators = []string{"&&", "||", ";;", "|", "&", ";", "(", ")"}
+
+// Перенаправления в порядке проверки
+var redirectOperators = []string{"&>>", "&>", ">>", ">&", ">", "<"}
+
+// Символы, которые завершают слово без кавычек
+const metaChars = " \t\n|&;<>()"
+
+// Разбивает строку на слова и операторы. Кавычки и экранирование снимаются, комментарии от # до конца строки отбрасываются
+func tokenize(input string) ([]token, error) {
+	l := lexer{input: input}
+
+	tokens := make([]token, 0)
+
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, tok)
+
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	tok, err := l.scan()
+	tok.end = l.pos
+	return tok, err
+}
+
+func (l *lexer) scan() (token, error) {
+	l.skipBlanks()
+
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	rest := l.input[l.pos:]
+
+	switch {
+	case rest[0] == '\n':
+		l.pos++
+		return token{kind: tokenNewline, text: "\n", pos: start}, nil
+	case rest[0] == '#':
+		// Комментарий начинается только в начале слова
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			l.pos += end
+		} else {
+			l.pos = len(l.input)
+		}
+		return l.scan()
+	}
+
+	if tok, ok := l.readRedirect(); ok {
+		return tok, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			return token{kind: tokenOperator, text: op, pos: start}, nil
+		}
+	}
+
+	w, err := l.readWord()
+	if err != nil {
+		return token{}, err
+	}
+
+	return token{kind: tokenWord, word: w, pos: start}, nil
+}
+
+// Читает перенаправление. Номер дескриптора (2>) относится к перенаправлению, только если записан вплотную к нему
+func (l *lexer) readRedirect() (token, bool) {
+	start := l.pos
+	rest := l.input[l.pos:]
+
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+
+	fd := -1
+	if digits > 0 {
+		n, err := strconv.Atoi(rest[:digits])
+		if err != nil {
+			return token{}, false
+		}
+		fd = n
+	}
+
+	for _, op := range redirectOperators {
+		if !strings.HasPrefix(rest[digits:], op) {
+			continue
+		}
+		// У &> нет номера дескриптора: 2&> - это слово 2 и оператор &
+		if digits > 0 && op[0] == '&' {
+			return token{}, false
+		}
+
+		l.pos += digits + len(op)
+		return token{kind: tokenRedirect, text: op, fd: fd, pos: start}, true
+	}
+
+	return token{}, false
+}
+
+func (l *lexer) skipBlanks() {
+	for l.pos < len(l.input) && (l.input[l.pos] == ' ' || l.input[l.pos] == '\t') {
+		l.pos++
+	}
+
+	// Экранированный перевод строки продолжает команду на следующей строке
+	if strings.HasPrefix(l.input[l.pos:], "\\\n") {
+		l.pos += 2
+		l.skipBlanks()
+	}
+}
+
+// Читает слово до пробела или оператора вне кавычек
+func (l *lexer) readWord() (word, error) {
+	w := word{}
+	current := strings.Builder{}
+
+	// Переносит накопленный текст без кавычек в слово
+	flush := func() {
+		if current.Len() > 0 {
+			w = append(w, wordPart{text: current.String(), quoting: unquoted})
+			current.Reset()
+		}
+	}
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+
+		switch {
+		case strings.IndexByte(metaChars, c) >= 0:
+			flush()
+			return w, nil
+		case c == '\\':
+			flush()
+			if l.pos+1 >= len(l.input) {
+				return nil, fmt.Errorf("%w after \\", errIncomplete)
+			}
+			if l.input[l.pos+1] == '\n' {
+				// Перенос строки внутри слова просто удаляется
+				l.pos += 2
+				continue
+			}
+			// Многобайтовый символ экранируется целиком
+			_, size := utf8.DecodeRuneInString(l.input[l.pos+1:])
+			w = append(w, wordPart{text: l.input[l.pos+1 : l.pos+1+size], quoting: literal})
+			l.pos += 1 + size
+		case c == '\'':
+			flush()
+			end := strings.IndexByte(l.input[l.pos+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated single quote at %d", errIncomplete, l.pos)
+			}
+			w = append(w, wordPart{text: l.input[l.pos+1 : l.pos+1+end], quoting: literal})
+			l.pos += end + 2
+		case c == '$' && strings.HasPrefix(l.input[l.pos:], "${"):
+			// ${VAR:-a b} остаётся одним словом, даже если содержит пробелы
+			end := matchingBrace(l.input[l.pos+1:])
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated ${ at %d", errIncomplete, l.pos)
+			}
+			current.WriteString(l.input[l.pos : l.pos+2+end])
+			l.pos += 2 + end
+		case c == '"':
+			flush()
+			parts, err := l.readDoubleQuoted()
+			if err != nil {
+				return nil, err
+			}
+			w = append(w, parts...)
+		default:
+			current.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	flush()
+
+	return w, nil
+}
+
+// Читает строку в двойных кавычках. Обратная косая черта экранирует только ", \, $ и `
+func (l *lexer) readDoubleQuoted() (word, error) {
+	start := l.pos
+	l.pos++
+
+	w := word{}
+	current := strings.Builder{}
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+
+		switch {
+		case c == '"':
+			l.pos++
+			// Пустые кавычки дают пустое слово, а не его отсутствие
+			if current.Len() > 0 || len(w) == 0 {
+				w = append(w, wordPart{text: current.String(), quoting: doubleQuoted})
+			}
+			return w, nil
+		case c == '\\' && l.pos+1 < len(l.input) && strings.IndexByte("\"\\$`\n", l.input[l.pos+1]) >= 0:
+			if current.Len() > 0 {
+				w = append(w, wordPart{text: current.String(), quoting: doubleQuoted})
+				current.Reset()
+			}
+			if l.input[l.pos+1] != '\n' {
+				w = append(w, wordPart{text: l.input[l.pos+1 : l.pos+2], quoting: literal})
+			}
+			l.pos += 2
+		default:
+			current.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unterminated double quote at %d", errIncomplete, start)
+}
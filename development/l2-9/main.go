@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 /*
@@ -27,123 +28,252 @@ import (
 	Интерактивный сеанс поддерживается до тех пор, пока не будет введена команда выхода (например quit)
 */
 
-// Основная точка входа программы
+// Потоки ввода-вывода команды. В конвейере stdin и stdout связаны с соседними командами
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Встроенная команда выполняется в процессе шелла и возвращает код завершения
+type builtin func(sh *shell, args []string, s streams) int
+
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"cd":       executeCD,
+		"pwd":      executePWD,
+		"echo":     executeEcho,
+		"kill":     executeKill,
+		"ps":       executePS,
+		"export":   executeExport,
+		"unset":    executeUnset,
+		"env":      executeEnv,
+		"jobs":     executeJobs,
+		"fg":       executeFG,
+		"bg":       executeBG,
+		"wait":     executeWait,
+		"history":  executeHistory,
+		"set":      executeSet,
+		"exit":     executeExit,
+		"break":    executeBreak,
+		"continue": executeContinue,
+		"test":     executeTest,
+		"[":        executeTest,
+	}
+}
+
+// Основная точка входа программы: код завершения шелла передаётся ОС
 func main() {
-	fmt.Println("Type 'quit' to exit.")
+	os.Exit(run(os.Args[0], os.Args[1:], streams{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// Запускает шелл с аргументами командной строки: goshell [-ex] [-o параметр] [-c команды [имя [аргументы]] | скрипт [аргументы]].
+// Без скрипта и -c команды читаются из stdin: с терминала - интерактивно, иначе построчно без приглашений.
+// Возвращает код завершения шелла
+func run(name string, args []string, std streams) int {
+	inv, err := parseInvocation(name, args)
+	if err != nil {
+		fmt.Fprintf(std.stderr, "GoShell: %v\n", err)
+		return statusUsage
+	}
+
+	sh := newShell()
+	sh.name = inv.name
+	sh.setPositional(inv.args)
+	for _, opt := range inv.options {
+		if err := sh.setOption(opt, true); err != nil {
+			fmt.Fprintf(std.stderr, "GoShell: %v\n", err)
+			return statusUsage
+		}
+	}
+
+	switch {
+	case inv.hasCommand:
+		return sh.runCommandString(inv.command, std)
+	case inv.script != "":
+		return sh.runScript(inv.script, std)
+	}
+
+	stdin, ok := std.stdin.(*os.File)
+	if !ok || !isTerminal(int(stdin.Fd())) {
+		return sh.runLines(&unbufferedReader{in: std.stdin}, std, "stdin", false)
+	}
+
+	fmt.Fprintln(std.stdout, "Type 'quit' to exit.")
+	sh.enableJobControl(int(stdin.Fd()))
+	sh.handleSignals()
+
+	return sh.runLines(newInput(sh), std, "stdin", true)
+}
+
+// Читает и выполняет команды, пока не закончится ввод или не будет выполнен exit.
+// В интерактивном режиме выводятся приглашения, уведомления о заданиях и ненулевые коды, команды попадают в историю.
+// Вне его синтаксическая ошибка завершает шелл с кодом 2 и сообщением с номером строки из source
+func (sh *shell) runLines(input lineReader, std streams, source string, interactive bool) int {
+	// Строки незавершённой команды: незакрытая кавычка или оператор в конце строки
+	pending := ""
+	lineNumber := 0
 
 	for {
-		fmt.Print("GoShell> ")
+		prompt := "> "
+		if pending == "" {
+			if interactive {
+				sh.notifyJobs(std.stderr)
+			}
+			prompt = "GoShell> "
+		}
 
-		// Читаем пользовательский ввод
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		userInput := scanner.Text()
+		// Читаем пользовательский ввод, конец ввода завершает работу
+		line, err := input.readLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			pending = ""
+			sh.setStatus(128 + int(syscall.SIGINT))
+			continue
+		}
+		if err != nil {
+			if interactive {
+				fmt.Fprintln(std.stdout)
+			}
+			if pending != "" {
+				fmt.Fprintf(std.stderr, "GoShell: %s: line %d: syntax error: unexpected end of file\n", source, lineNumber)
+				return statusUsage
+			}
+			break
+		}
+		lineNumber++
+		userInput := pending + line
 
 		// Завершение работы при вводе команды 'quit'
-		if userInput == "quit" {
+		if strings.TrimSpace(userInput) == "quit" {
 			break
 		}
 
-		// Обработка конвейера команд через пайпы
-		commands := strings.Split(userInput, " | ")
-
-		for _, cmd := range commands {
-			// Разделение строки на команду и аргументы
-			parts := strings.Fields(cmd)
-			if len(parts) == 0 {
-				continue
-			}
+		list, err := parse(userInput)
+		if errors.Is(err, errIncomplete) {
+			pending = userInput + "\n"
+			continue
+		}
+		pending = ""
 
-			// Выполнение встроенных и внешних команд
-			switch parts[0] {
-			case "cd":
-				executeCD(parts)
-			case "pwd":
-				executePWD()
-			case "echo":
-				executeEcho(parts)
-			case "kill":
-				executeKill(parts)
-			case "ps":
-				executePS()
-			default:
-				executeExternal(parts)
+		if interactive {
+			if err := sh.history.add(userInput); err != nil {
+				fmt.Fprintf(std.stderr, "GoShell: history: %v\n", err)
 			}
 		}
-	}
-}
 
-// Меняет текущую директорию
-func executeCD(args []string) {
-	if len(args) < 2 {
-		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "cd error: %v\n", err)
-			return
+			if !interactive {
+				fmt.Fprintf(std.stderr, "GoShell: %s: line %d: %v\n", source, lineNumber, err)
+				return statusUsage
+			}
+			fmt.Fprintf(std.stderr, "GoShell: %v\n", err)
+			continue
 		}
-		if err := os.Chdir(homeDir); err != nil {
-			fmt.Fprintf(os.Stderr, "cd error: %v\n", err)
+
+		// Код завершения списка - код его последней команды
+		sh.setInterrupted(false)
+		status := sh.runList(list, std)
+		if code, ok := sh.exitRequested(); ok {
+			return code
 		}
-	} else {
-		if err := os.Chdir(args[1]); err != nil {
-			fmt.Fprintf(os.Stderr, "cd error: %v\n", err)
+		if status != 0 && interactive {
+			fmt.Fprintf(std.stderr, "exit status %d\n", status)
 		}
 	}
+
+	status, _ := sh.getVar("?")
+	code, _ := strconv.Atoi(status)
+	return code
 }
 
-// Показывает текущую директорию
-func executePWD() {
-	currentDir, err := os.Getwd()
+// Ввод с терминала читается редактором строки с историей из файла, остальной ввод - построчно
+func newInput(sh *shell) lineReader {
+	editor, err := newLineEditor(os.Stdin, os.Stdout, sh)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "pwd error: %v\n", err)
-		return
+		return newScannerReader(os.Stdin, os.Stdout)
 	}
-	fmt.Println(currentDir)
-}
 
-// Выводит текст на экран
-func executeEcho(args []string) {
-	output := strings.Join(args[1:], " ")
-	fmt.Println(output)
+	if path := sh.historyPath(); path != "" {
+		if err := sh.history.load(path); err != nil {
+			fmt.Fprintf(os.Stderr, "GoShell: history: %v\n", err)
+		}
+	}
+
+	return editor
 }
 
-// Убивает процесс по PID
-func executeKill(args []string) {
+// Меняет текущую директорию
+func executeCD(sh *shell, args []string, s streams) int {
+	dir := ""
 	if len(args) < 2 {
-		fmt.Println("kill error: missing process ID")
-		return
+		homeDir, ok := sh.getVar("HOME")
+		if !ok || homeDir == "" {
+			fmt.Fprintln(s.stderr, "cd error: HOME not set")
+			return 1
+		}
+		dir = homeDir
+	} else {
+		dir = args[1]
 	}
-	pid, err := strconv.Atoi(args[1])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "kill error: invalid process ID\n")
-		return
+
+	// В подоболочке меняется только её каталог
+	if sh.dir != "" {
+		path := sh.path(dir)
+		if err := checkDir(path); err != nil {
+			fmt.Fprintf(s.stderr, "cd error: %v\n", &os.PathError{Op: "chdir", Path: dir, Err: err})
+			return 1
+		}
+		sh.dir = path
+		return 0
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(s.stderr, "cd error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// Проверяет, что в каталог можно перейти, как это сделал бы chdir
+func checkDir(path string) error {
+	info, err := os.Stat(path)
+	if pathErr := (*os.PathError)(nil); errors.As(err, &pathErr) {
+		return pathErr.Err
 	}
-	process, err := os.FindProcess(pid)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "kill error: %v\n", err)
-		return
+		return err
 	}
-	if err := process.Kill(); err != nil {
-		fmt.Fprintf(os.Stderr, "kill error: %v\n", err)
+	if !info.IsDir() {
+		return syscall.ENOTDIR
 	}
+
+	return syscall.Access(path, 1)
 }
 
-// Выводит список процессов
-func executePS() {
-	cmd := exec.Command("ps")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "ps error: %v\n", err)
+// Показывает текущую директорию
+func executePWD(sh *shell, args []string, s streams) int {
+	if sh.dir != "" {
+		fmt.Fprintln(s.stdout, sh.dir)
+		return 0
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(s.stderr, "pwd error: %v\n", err)
+		return 1
 	}
+	fmt.Fprintln(s.stdout, currentDir)
+	return 0
 }
 
-// Выполняет внешнюю команду
-func executeExternal(args []string) {
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "command error: %v\n", err)
+// Выводит текст на экран
+func executeEcho(sh *shell, args []string, s streams) int {
+	output := strings.Join(args[1:], " ")
+	if _, err := fmt.Fprintln(s.stdout, output); err != nil {
+		// Следующая команда конвейера могла завершиться, не дочитав ввод
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Разбирает и выполняет ввод в новом шелле, возвращая stdout и код завершения
+func runInput(t *testing.T, input string) (string, int) {
+	t.Helper()
+
+	return runShellInput(t, newShell(), input)
+}
+
+func runShellInput(t *testing.T, sh *shell, input string) (string, int) {
+	t.Helper()
+
+	list, err := parse(input)
+	if err != nil {
+		t.Fatalf("parse(%q) error = %v", input, err)
+	}
+
+	stdout := &lockedBuffer{}
+	status := sh.runList(list, streams{stdin: strings.NewReader(""), stdout: stdout, stderr: io.Discard})
+
+	return stdout.String(), status
+}
+
+// Буфер для вывода, в который фоновые задания пишут одновременно с основными
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestRunPipeline(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		status   int
+	}{
+		{"echo hello world", "hello world\n", 0},
+		{"echo hello world | tr a-z A-Z", "HELLO WORLD\n", 0},
+		{"echo a | cat | cat | wc -l", "1\n", 0},
+		// Команда, переставшая читать, не должна останавливать конвейер
+		{"yes | head -n 2", "y\ny\n", 0},
+		// Код завершения конвейера - код последней команды
+		{"false | true", "", 0},
+		{"echo x | false", "", 1},
+		{"echo x | no-such-command", "", statusNotFound},
+	}
+
+	for _, test := range tests {
+		stdout, status := runInput(t, test.input)
+
+		if status != test.status {
+			t.Errorf("%q status = %d, expected %d", test.input, status, test.status)
+		}
+		if strings.TrimLeft(stdout, " ") != test.expected {
+			t.Errorf("%q = %q, expected %q", test.input, stdout, test.expected)
+		}
+	}
+}
+
+func TestRunAndOr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		status   int
+	}{
+		{"true && echo yes", "yes\n", 0},
+		{"false && echo yes", "", 1},
+		{"false || echo no", "no\n", 0},
+		{"true || echo no", "", 0},
+		{"false && echo a || echo b", "b\n", 0},
+		{"true && false || echo c; echo d", "c\nd\n", 0},
+		{"echo a; false", "a\n", 1},
+	}
+
+	for _, test := range tests {
+		stdout, status := runInput(t, test.input)
+
+		if stdout != test.expected || status != test.status {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.expected, test.status)
+		}
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		input    string
+		stdout   string
+		status   int
+		file     string
+		contents string
+	}{
+		{"echo hello > " + path("out"), "", 0, "out", "hello\n"},
+		{"echo more >> " + path("out"), "", 0, "out", "hello\nmore\n"},
+		{"cat < " + path("out") + " | wc -l", "2\n", 0, "", ""},
+		{"tr a-z A-Z <" + path("out") + ">" + path("upper"), "", 0, "upper", "HELLO\nMORE\n"},
+		{"ls " + path("missing") + " 2> " + path("err"), "", 2, "err", "ls: cannot access '" + path("missing") + "': No such file or directory\n"},
+		{"ls " + path("missing") + " > " + path("both") + " 2>&1", "", 2, "both", "ls: cannot access '" + path("missing") + "': No such file or directory\n"},
+		// stderr перенаправлен туда, куда шёл stdout до "> file", то есть в конвейер
+		{"ls " + path("missing") + " 2>&1 > " + path("only") + " | wc -l", "1\n", 0, "only", ""},
+		{"ls " + path("missing") + " " + path("out") + " &> " + path("all"), "", 2, "all", "ls: cannot access '" + path("missing") + "': No such file or directory\n" + path("out") + "\n"},
+		{"pwd &>> " + path("all"), "", 0, "", ""},
+		// Встроенные команды тоже перенаправляются
+		{"echo builtin 2> " + path("builtin") + " 1>&2", "", 0, "builtin", "builtin\n"},
+		{"> " + path("empty"), "", 0, "empty", ""},
+		{"cat < " + path("missing"), "", 1, "", ""},
+		{"echo x > " + path("no-dir/out"), "", 1, "", ""},
+		{"echo x 2>&7", "", 1, "", ""},
+	}
+
+	for _, test := range tests {
+		stdout, status := runInput(t, test.input)
+
+		if strings.TrimLeft(stdout, " ") != test.stdout || status != test.status {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.stdout, test.status)
+		}
+
+		if test.file == "" {
+			continue
+		}
+		contents, err := os.ReadFile(path(test.file))
+		if err != nil || string(contents) != test.contents {
+			t.Errorf("%q: %s = %q, %v, expected %q", test.input, test.file, contents, err, test.contents)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	t.Setenv("GOSHELL_TEST", "from env")
+	t.Setenv("HOME", "/home/test")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"echo $GOSHELL_TEST", "from env\n"},
+		{"FOO=bar; echo $FOO ${FOO} \"$FOO\" '$FOO' \\$FOO", "bar bar bar $FOO $FOO\n"},
+		{"echo ${MISSING:-default} ${MISSING-unset} ${EMPTY:-empty}", "default unset empty\n"},
+		{"EMPTY=; echo ${EMPTY:-default}/${EMPTY-unset}/", "default//\n"},
+		{"echo ${MISSING:-a b} \"${MISSING:-$GOSHELL_TEST}\"", "a b from env\n"},
+		{"A=1 B=2; echo $A$B ${A}x $Ax", "12 1x\n"},
+		{"false; echo $?; true; echo $?", "1\n0\n"},
+		{"no-such-command; echo $?", "127\n"},
+		{"echo $ cost 5$ $1", "$ cost 5$\n"},
+		{"echo ~ ~/dir a~", "/home/test /home/test/dir a~\n"},
+		// Подстановка без кавычек разбивается на аргументы, в кавычках - нет
+		{"LIST='a  b c'; printf '[%s]' $LIST; printf '[%s]' \"$LIST\"", "[a][b][c][a  b c]"},
+		{"printf '[%s]' $MISSING x \"$MISSING\"", "[x][]"},
+		// Присваивание перед командой видно только этой команде
+		{"FOO=local sh -c 'echo $FOO'; echo \"[$FOO]\"", "local\n[]\n"},
+		{"FOO=shell; sh -c 'echo [$FOO]'; export FOO; sh -c 'echo [$FOO]'", "[]\n[shell]\n"},
+		{"export BAR=exported; env | grep ^BAR=", "BAR=exported\n"},
+		{"export BAR=1; unset BAR; env | grep -c ^BAR=; echo [$BAR]", "0\n[]\n"},
+		{"BAZ=1 env | grep ^BAZ=; env QUX=2 sh -c 'echo $QUX'", "BAZ=1\n2\n"},
+		{"export X='a \"b\" $c'; export | grep '^export X='", "export X=\"a \\\"b\\\" \\$c\"\n"},
+	}
+
+	for _, test := range tests {
+		if stdout, _ := runInput(t, test.input); strings.TrimLeft(stdout, " ") != test.expected {
+			t.Errorf("%q = %q, expected %q", test.input, stdout, test.expected)
+		}
+	}
+}
+
+func TestVariableErrors(t *testing.T) {
+	tests := []string{"echo ${}", "echo ${A/b}", "echo x > $MISSING", "LIST='a b'; echo x > $LIST", "export 1A=x", "unset -x"}
+
+	for _, input := range tests {
+		if _, status := runInput(t, input); status != 1 {
+			t.Errorf("%q status = %d, expected 1", input, status)
+		}
+	}
+
+	// $$ - PID шелла
+	if stdout, _ := runInput(t, "echo $$"); stdout != strconv.Itoa(os.Getpid())+"\n" {
+		t.Errorf("echo $$ = %q, expected %d", stdout, os.Getpid())
+	}
+}
+
+func TestBackgroundJobs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		status   int
+	}{
+		{"sleep 0.2 && echo second & echo first; wait", "first\nsecond\n", 0},
+		{"sh -c 'exit 3' & wait %1; echo $?", "3\n", 0},
+		{"sh -c 'exit 4' & wait $!", "", 4},
+		{"false & true & jobs | wc -l; wait", "2\n", 0},
+		{"sleep 5 & jobs; kill $!; wait", "[1]+  Running                 sleep 5 &\n", 0},
+		{"sleep 5 & sleep 5 & jobs -p | wc -l; jobs %sl; kill $!", "2\n", 0},
+		// Фоновое задание не меняет переменные шелла
+		{"X=1; X=2 & wait; echo $X", "1\n", 0},
+		{"wait %3", "", statusNotFound},
+		{"fg", "", 1},
+		{"bg %1", "", 1},
+	}
+
+	for _, test := range tests {
+		stdout, status := runInput(t, test.input)
+
+		if stdout != test.expected || status != test.status {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.expected, test.status)
+		}
+	}
+}
+
+func TestStoppedJob(t *testing.T) {
+	sh := newShell()
+
+	if _, status := runShellInput(t, sh, "sleep 5 &"); status != 0 {
+		t.Fatalf("sleep 5 & status = %d", status)
+	}
+
+	j, err := sh.jobs.find("%1")
+	if err != nil {
+		t.Fatalf("find(%%1) error = %v", err)
+	}
+	// Задание, которое не завершилось, не должно подвешивать тест
+	waitDone := func() {
+		t.Helper()
+
+		select {
+		case <-j.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job did not finish")
+		}
+	}
+	defer func() {
+		_ = syscall.Kill(-j.group(), syscall.SIGKILL)
+		waitDone()
+	}()
+
+	// Останавливаем группу задания, как Ctrl+Z от терминала. SIGTSTP можно игнорировать
+	// (nohup, фоновый shell CI), а SIGSTOP нет
+	if err := syscall.Kill(-j.group(), syscall.SIGSTOP); err != nil {
+		t.Fatalf("kill error = %v", err)
+	}
+	select {
+	case <-j.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job was not stopped")
+	}
+
+	expected := "[1]+  Stopped                 sleep 5\n"
+	if stdout, _ := runShellInput(t, sh, "jobs"); stdout != expected {
+		t.Errorf("jobs = %q, expected %q", stdout, expected)
+	}
+
+	expected = "[1]+ sleep 5 &\n[1]+  Running                 sleep 5 &\n"
+	if stdout, _ := runShellInput(t, sh, "bg; jobs"); stdout != expected {
+		t.Errorf("bg; jobs = %q, expected %q", stdout, expected)
+	}
+
+	notifications := bytes.Buffer{}
+	_ = syscall.Kill(-j.group(), syscall.SIGTERM)
+	waitDone()
+	sh.notifyJobs(&notifications)
+
+	expected = "[1]+  Terminated              sleep 5\n"
+	if notifications.String() != expected {
+		t.Errorf("notifyJobs = %q, expected %q", notifications.String(), expected)
+	}
+}
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// command - элемент конвейера
+type command interface {
+	isCommand()
+}
+
+// Перенаправление ввода-вывода команды
+type redirect struct {
+	// Номер перенаправляемого дескриптора: 0, 1 или 2
+	fd int
+	op string
+	// Файл или, для >&, номер дескриптора
+	target word
+}
+
+// Присваивание NAME=value перед командой
+type assignment struct {
+	name  string
+	value word
+}
+
+// Простая команда: присваивания, имя, аргументы и перенаправления в порядке записи
+type simpleCommand struct {
+	assigns   []assignment
+	args      []word
+	redirects []redirect
+}
+
+func (*simpleCommand) isCommand() {}
+
+// Составная команда: if, while, for или case. Перенаправления действуют на все её команды
+type compoundCommand struct {
+	clause    clause
+	redirects []redirect
+}
+
+func (*compoundCommand) isCommand() {}
+
+// clause - конструкция составной команды
+type clause interface {
+	isClause()
+}
+
+// if cond; then body; elif cond; then body; else body; fi. conds[i] проверяет, выполнять ли bodies[i]
+type ifClause struct {
+	conds    []*commandList
+	bodies   []*commandList
+	elseBody *commandList
+}
+
+// while cond; do body; done
+type whileLoop struct {
+	cond *commandList
+	body *commandList
+}
+
+// for name in words; do body; done. Без in перебираются позиционные параметры
+type forLoop struct {
+	name  string
+	words []word
+	hasIn bool
+	body  *commandList
+}
+
+// case subject in pattern | pattern) body ;; ... esac
+type caseClause struct {
+	subject word
+	items   []caseItem
+}
+
+type caseItem struct {
+	patterns []word
+	body     *commandList
+}
+
+func (*ifClause) isClause()   {}
+func (*whileLoop) isClause()  {}
+func (*forLoop) isClause()    {}
+func (*caseClause) isClause() {}
+
+// Конвейер cmd1 | cmd2 | ... | cmdN
+type pipeline struct {
+	commands []command
+	// Исходный текст для списка заданий
+	text string
+}
+
+// Цепочка конвейеров, связанных && и ||. ops[i] стоит между pipelines[i] и pipelines[i+1]
+type andOr struct {
+	pipelines []*pipeline
+	ops       []string
+	// Цепочка завершена & и выполняется в фоне
+	background bool
+	text       string
+}
+
+// Список команд, разделённых ; или переводом строки
+type commandList struct {
+	items []*andOr
+}
+
+// Разбирает ввод в дерево команд
+func parse(input string) (*commandList, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens, input: input}
+
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return list, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	input  string
+}
+
+// Исходный текст от начала токена start до конца последнего прочитанного токена
+func (p *parser) textFrom(start token) string {
+	return p.input[start.pos:p.tokens[p.pos-1].end]
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// Сообщает, является ли следующий токен оператором op
+func (p *parser) isOperator(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOperator && tok.text == op
+}
+
+func (p *parser) skipNewlines() {
+	for p.peek().kind == tokenNewline {
+		p.advance()
+	}
+}
+
+// Ошибка синтаксиса. Конец ввода там, где ожидается продолжение, - неполный ввод
+func (p *parser) unexpected(tok token) error {
+	switch tok.kind {
+	case tokenEOF:
+		return errIncomplete
+	case tokenNewline:
+		return fmt.Errorf("syntax error near unexpected newline")
+	case tokenWord:
+		return fmt.Errorf("syntax error near unexpected word `%s`", tok.word)
+	default:
+		return fmt.Errorf("syntax error near unexpected token `%s`", tok.text)
+	}
+}
+
+// Зарезервированные слова, которые завершают список команд внутри составной команды
+var listTerminators = []string{"then", "elif", "else", "fi", "do", "done", "esac"}
+
+// Сообщает, является ли следующий токен зарезервированным словом name. Слово зарезервировано,
+// только если записано без кавычек и стоит на месте имени команды: echo fi выводит fi
+func (p *parser) isReserved(name string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && len(tok.word) == 1 && tok.word[0].quoting == unquoted && tok.word[0].text == name
+}
+
+// Конец списка внутри составной команды: then, fi, done, ;; и т. п.
+func (p *parser) atListEnd() bool {
+	if p.isOperator(";;") || p.isOperator(")") {
+		return true
+	}
+	for _, name := range listTerminators {
+		if p.isReserved(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Пропускает ожидаемое зарезервированное слово
+func (p *parser) expect(name string) error {
+	if !p.isReserved(name) {
+		return p.unexpected(p.peek())
+	}
+	p.advance()
+	return nil
+}
+
+// list := (andOr ((';' | '&' | '\n') andOr?)*)?
+func (p *parser) parseList() (*commandList, error) {
+	list := &commandList{}
+
+	p.skipNewlines()
+
+	for (p.peek().kind == tokenWord || p.peek().kind == tokenRedirect) && !p.atListEnd() {
+		item, err := p.parseAndOr()
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+
+		if p.isOperator("&") {
+			item.background = true
+		} else if !p.isOperator(";") && p.peek().kind != tokenNewline {
+			break
+		}
+		p.advance()
+		p.skipNewlines()
+	}
+
+	return list, nil
+}
+
+// andOr := pipeline (('&&' | '||') '\n'* pipeline)*
+func (p *parser) parseAndOr() (*andOr, error) {
+	start := p.peek()
+
+	first, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	item := &andOr{pipelines: []*pipeline{first}}
+
+	for p.isOperator("&&") || p.isOperator("||") {
+		op := p.advance().text
+		p.skipNewlines()
+
+		next, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+
+		item.pipelines = append(item.pipelines, next)
+		item.ops = append(item.ops, op)
+	}
+
+	item.text = p.textFrom(start)
+
+	return item, nil
+}
+
+// pipeline := command ('|' '\n'* command)*
+func (p *parser) parsePipeline() (*pipeline, error) {
+	start := p.peek()
+	pl := &pipeline{}
+
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		pl.commands = append(pl.commands, cmd)
+
+		if !p.isOperator("|") {
+			pl.text = p.textFrom(start)
+			return pl, nil
+		}
+		p.advance()
+		p.skipNewlines()
+	}
+}
+
+// command := compound redirect* | assignment* (word | redirect)+
+func (p *parser) parseCommand() (command, error) {
+	if parse, ok := p.clauseParser(); ok {
+		return p.parseCompound(parse)
+	}
+
+	cmd := &simpleCommand{}
+
+	for {
+		tok := p.peek()
+
+		if tok.kind == tokenWord {
+			p.advance()
+			// Присваиваниями считаются только слова до имени команды
+			if a, ok := parseAssignment(tok.word); ok && len(cmd.args) == 0 {
+				cmd.assigns = append(cmd.assigns, a)
+			} else {
+				cmd.args = append(cmd.args, tok.word)
+			}
+			continue
+		}
+		if tok.kind != tokenRedirect {
+			break
+		}
+
+		r, err := p.parseRedirect()
+		if err != nil {
+			return nil, err
+		}
+		cmd.redirects = append(cmd.redirects, r)
+	}
+
+	if len(cmd.assigns) == 0 && len(cmd.args) == 0 && len(cmd.redirects) == 0 {
+		return nil, p.unexpected(p.peek())
+	}
+
+	return cmd, nil
+}
+
+// redirect := ('<' | '>' | ...) word
+func (p *parser) parseRedirect() (redirect, error) {
+	tok := p.advance()
+
+	target := p.peek()
+	if target.kind == tokenEOF {
+		// Перенаправление без файла - ошибка, а не незавершённый ввод
+		return redirect{}, fmt.Errorf("syntax error near unexpected newline")
+	}
+	if target.kind != tokenWord {
+		return redirect{}, p.unexpected(target)
+	}
+	p.advance()
+
+	return newRedirect(tok, target.word), nil
+}
+
+// Функция разбора составной команды, если следующее слово её начинает
+func (p *parser) clauseParser() (func() (clause, error), bool) {
+	switch {
+	case p.isReserved("if"):
+		return p.parseIf, true
+	case p.isReserved("while"):
+		return p.parseWhile, true
+	case p.isReserved("for"):
+		return p.parseFor, true
+	case p.isReserved("case"):
+		return p.parseCase, true
+	default:
+		return nil, false
+	}
+}
+
+func (p *parser) parseCompound(parse func() (clause, error)) (command, error) {
+	c, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &compoundCommand{clause: c}
+	for p.peek().kind == tokenRedirect {
+		r, err := p.parseRedirect()
+		if err != nil {
+			return nil, err
+		}
+		cmd.redirects = append(cmd.redirects, r)
+	}
+
+	return cmd, nil
+}
+
+// Непустой список команд внутри составной команды
+func (p *parser) parseBody() (*commandList, error) {
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if len(list.items) == 0 {
+		return nil, p.unexpected(p.peek())
+	}
+	return list, nil
+}
+
+// if := 'if' list 'then' list ('elif' list 'then' list)* ('else' list)? 'fi'
+func (p *parser) parseIf() (clause, error) {
+	p.advance()
+	c := &ifClause{}
+
+	for {
+		cond, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("then"); err != nil {
+			return nil, err
+		}
+		body, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+
+		c.conds = append(c.conds, cond)
+		c.bodies = append(c.bodies, body)
+
+		if !p.isReserved("elif") {
+			break
+		}
+		p.advance()
+	}
+
+	if p.isReserved("else") {
+		p.advance()
+
+		body, err := p.parseBody()
+		if err != nil {
+			return nil, err
+		}
+		c.elseBody = body
+	}
+
+	return c, p.expect("fi")
+}
+
+// while := 'while' list 'do' list 'done'
+func (p *parser) parseWhile() (clause, error) {
+	p.advance()
+
+	cond, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.parseDoGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	return &whileLoop{cond: cond, body: body}, nil
+}
+
+// for := 'for' name '\n'* ('in' word* (';' | '\n'))? '\n'* 'do' list 'done'. Без in допускается ; после имени
+func (p *parser) parseFor() (clause, error) {
+	p.advance()
+
+	tok := p.peek()
+	if tok.kind != tokenWord {
+		return nil, p.unexpected(tok)
+	}
+	if len(tok.word) != 1 || tok.word[0].quoting != unquoted || !isName(tok.word[0].text) {
+		return nil, fmt.Errorf("syntax error: `%s': not a valid identifier", tok.word)
+	}
+	p.advance()
+
+	c := &forLoop{name: tok.word[0].text}
+	p.skipNewlines()
+
+	switch {
+	case p.isReserved("in"):
+		p.advance()
+		c.hasIn = true
+
+		for p.peek().kind == tokenWord {
+			c.words = append(c.words, p.advance().word)
+		}
+		if !p.isOperator(";") && p.peek().kind != tokenNewline {
+			return nil, p.unexpected(p.peek())
+		}
+		p.advance()
+	case p.isOperator(";"):
+		p.advance()
+	}
+
+	body, err := p.parseDoGroup()
+	if err != nil {
+		return nil, err
+	}
+	c.body = body
+
+	return c, nil
+}
+
+// do := '\n'* 'do' list 'done'
+func (p *parser) parseDoGroup() (*commandList, error) {
+	p.skipNewlines()
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+
+	body, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return body, p.expect("done")
+}
+
+// case := 'case' word '\n'* 'in' '\n'* (item (';;' '\n'*)?)* 'esac'
+// item := '('? word ('|' word)* ')' list
+func (p *parser) parseCase() (clause, error) {
+	p.advance()
+
+	tok := p.peek()
+	if tok.kind != tokenWord {
+		return nil, p.unexpected(tok)
+	}
+	p.advance()
+
+	c := &caseClause{subject: tok.word}
+
+	p.skipNewlines()
+	if err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	p.skipNewlines()
+
+	for !p.isReserved("esac") {
+		if p.isOperator("(") {
+			p.advance()
+		}
+
+		item := caseItem{}
+		for {
+			tok := p.peek()
+			if tok.kind != tokenWord {
+				return nil, p.unexpected(tok)
+			}
+			item.patterns = append(item.patterns, p.advance().word)
+
+			if !p.isOperator("|") {
+				break
+			}
+			p.advance()
+		}
+
+		if !p.isOperator(")") {
+			return nil, p.unexpected(p.peek())
+		}
+		p.advance()
+
+		// Тело ветки может быть пустым
+		body, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		item.body = body
+		c.items = append(c.items, item)
+
+		// ;; можно не ставить только после последней ветки
+		if !p.isOperator(";;") {
+			break
+		}
+		p.advance()
+		p.skipNewlines()
+	}
+
+	return c, p.expect("esac")
+}
+
+// Перенаправление с дескриптором по умолчанию: 0 для <, 1 для остальных
+func newRedirect(tok token, target word) redirect {
+	fd := tok.fd
+	if fd < 0 {
+		fd = 1
+		if tok.text == "<" {
+			fd = 0
+		}
+	}
+
+	return redirect{fd: fd, op: tok.text, target: target}
+}
+
+// Разбирает слово NAME=value. Имя должно быть записано без кавычек
+func parseAssignment(w word) (assignment, bool) {
+	if len(w) == 0 || w[0].quoting != unquoted {
+		return assignment{}, false
+	}
+
+	name, rest, ok := strings.Cut(w[0].text, "=")
+	if !ok || !isName(name) {
+		return assignment{}, false
+	}
+
+	value := word{}
+	if rest != "" {
+		value = append(value, wordPart{text: rest, quoting: unquoted})
+	}
+	value = append(value, w[1:]...)
+
+	return assignment{name: name, value: value}, true
+}
+
+// Допустимое имя переменной: буквы, цифры и _, не начинается с цифры
+func isName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Записывает дерево в виде [arg] [arg] | [arg] && [arg] ; ... для сравнения в тестах
+func formatList(list *commandList) string {
+	items := make([]string, 0, len(list.items))
+
+	for _, item := range list.items {
+		b := strings.Builder{}
+		for i, pl := range item.pipelines {
+			if i > 0 {
+				b.WriteString(" " + item.ops[i-1] + " ")
+			}
+
+			commands := make([]string, 0, len(pl.commands))
+			for _, cmd := range pl.commands {
+				commands = append(commands, formatCommand(cmd))
+			}
+			b.WriteString(strings.Join(commands, " | "))
+		}
+		if item.background {
+			b.WriteString(" &")
+		}
+		items = append(items, b.String())
+	}
+
+	return strings.Join(items, " ; ")
+}
+
+func formatCommand(cmd command) string {
+	switch cmd := cmd.(type) {
+	case *simpleCommand:
+		args := make([]string, 0, len(cmd.args))
+		for _, a := range cmd.assigns {
+			args = append(args, a.name+"=["+a.value.String()+"]")
+		}
+		for _, arg := range cmd.args {
+			args = append(args, "["+arg.String()+"]")
+		}
+		for _, r := range cmd.redirects {
+			args = append(args, fmt.Sprintf("%d%s[%s]", r.fd, r.op, r.target))
+		}
+		return strings.Join(args, " ")
+	case *compoundCommand:
+		text := formatClause(cmd.clause)
+		for _, r := range cmd.redirects {
+			text += fmt.Sprintf(" %d%s[%s]", r.fd, r.op, r.target)
+		}
+		return text
+	default:
+		return fmt.Sprintf("%T", cmd)
+	}
+}
+
+// Записывает составную команду с телами в фигурных скобках: if {[true]} then {[echo] [a]} fi
+func formatClause(c clause) string {
+	body := func(list *commandList) string {
+		return "{" + formatList(list) + "}"
+	}
+
+	switch c := c.(type) {
+	case *ifClause:
+		b := strings.Builder{}
+		for i := range c.conds {
+			keyword := "if"
+			if i > 0 {
+				keyword = " elif"
+			}
+			fmt.Fprintf(&b, "%s %s then %s", keyword, body(c.conds[i]), body(c.bodies[i]))
+		}
+		if c.elseBody != nil {
+			fmt.Fprintf(&b, " else %s", body(c.elseBody))
+		}
+		return b.String() + " fi"
+	case *whileLoop:
+		return fmt.Sprintf("while %s do %s done", body(c.cond), body(c.body))
+	case *forLoop:
+		words := ""
+		if c.hasIn {
+			words = " in"
+			for _, w := range c.words {
+				words += " [" + w.String() + "]"
+			}
+		}
+		return fmt.Sprintf("for %s%s do %s done", c.name, words, body(c.body))
+	case *caseClause:
+		b := strings.Builder{}
+		fmt.Fprintf(&b, "case [%s] in", c.subject)
+		for _, item := range c.items {
+			patterns := make([]string, 0, len(item.patterns))
+			for _, w := range item.patterns {
+				patterns = append(patterns, "["+w.String()+"]")
+			}
+			fmt.Fprintf(&b, " %s) %s ;;", strings.Join(patterns, "|"), body(item.body))
+		}
+		return b.String() + " esac"
+	default:
+		return fmt.Sprintf("%T", c)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"   # только комментарий", ""},
+		{"echo hello world", "[echo] [hello] [world]"},
+		{`echo "hello world"`, "[echo] [hello world]"},
+		{`grep 'a b' file`, "[grep] [a b] [file]"},
+		{`echo "a"'b'c`, "[echo] [abc]"},
+		{`echo ""`, "[echo] []"},
+		{`echo '' x`, "[echo] [] [x]"},
+		{`echo a\ b`, "[echo] [a b]"},
+		{`echo \"quoted\"`, `[echo] ["quoted"]`},
+		{`echo "say \"hi\" \\ \n"`, `[echo] [say "hi" \ \n]`},
+		{`echo 'no \escapes "here"'`, `[echo] [no \escapes "here"]`},
+		{`echo "|;&&"`, "[echo] [|;&&]"},
+		{`echo a#b # comment`, "[echo] [a#b]"},
+		{"ps|grep go", "[ps] | [grep] [go]"},
+		{"ps | grep go|wc -l", "[ps] | [grep] [go] | [wc] [-l]"},
+		{"a;b", "[a] ; [b]"},
+		{"a ; b ;", "[a] ; [b]"},
+		{"a&&b||c", "[a] && [b] || [c]"},
+		{"a | b && c | d", "[a] | [b] && [c] | [d]"},
+		{"a\nb\n\nc", "[a] ; [b] ; [c]"},
+		{"a &&\n b", "[a] && [b]"},
+		{"a |\n b", "[a] | [b]"},
+		{"echo a \\\n b", "[echo] [a] [b]"},
+		{`echo \ü`, "[echo] [ü]"},
+		{"echo a > out", "[echo] [a] 1>[out]"},
+		{"echo a>out>>log", "[echo] [a] 1>[out] 1>>[log]"},
+		{"sort < in > out", "[sort] 0<[in] 1>[out]"},
+		{"ls 2> err", "[ls] 2>[err]"},
+		{"ls 2>&1 | cat", "[ls] 2>&[1] | [cat]"},
+		{"ls > out 2>&1", "[ls] 1>[out] 2>&[1]"},
+		{"ls &> all; ls &>> all", "[ls] 1&>[all] ; [ls] 1&>>[all]"},
+		{"> empty", "1>[empty]"},
+		// Номер дескриптора - только вплотную к оператору
+		{"echo 2 > out", "[echo] [2] 1>[out]"},
+		{"echo a2>out", "[echo] [a2] 1>[out]"},
+		{`echo "2>x" '>'`, "[echo] [2>x] [>]"},
+		{"FOO=bar", "FOO=[bar]"},
+		{"A=1 B='x y' cmd C=3", "A=[1] B=[x y] [cmd] [C=3]"},
+		{`"A=1" cmd`, "[A=1] [cmd]"},
+		{"1A=x =y", "[1A=x] [=y]"},
+		{"echo ${A:-a b} $B", "[echo] [${A:-a b}] [$B]"},
+		{"sleep 1 &", "[sleep] [1] &"},
+		{"a & b&c", "[a] & ; [b] & ; [c]"},
+		{"a && b & c", "[a] && [b] & ; [c]"},
+		{"a&>f &", "[a] 1&>[f] &"},
+	}
+
+	for _, test := range tests {
+		list, err := parse(test.input)
+		if err != nil {
+			t.Errorf("parse(%q) error = %v", test.input, err)
+			continue
+		}
+
+		if got := formatList(list); got != test.expected {
+			t.Errorf("parse(%q) = %s, expected %s", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestParseQuoting(t *testing.T) {
+	list, err := parse(`echo a"b"'c'\d`)
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+
+	arg := list.items[0].pipelines[0].commands[0].(*simpleCommand).args[1]
+	expected := word{{"a", unquoted}, {"b", doubleQuoted}, {"c", literal}, {"d", literal}}
+
+	if fmt.Sprint(arg) != fmt.Sprint(expected) || len(arg) != len(expected) {
+		t.Fatalf("word = %v, expected %v", arg, expected)
+	}
+	for i := range arg {
+		if arg[i] != expected[i] {
+			t.Errorf("part %d = %+v, expected %+v", i, arg[i], expected[i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	incomplete := []string{`echo "abc`, `echo 'abc`, `echo abc\`, "a |", "a &&", "a ||\n", "echo ${A"}
+	for _, input := range incomplete {
+		if _, err := parse(input); !errors.Is(err, errIncomplete) {
+			t.Errorf("parse(%q) error = %v, expected incomplete input", input, err)
+		}
+	}
+
+	invalid := []string{"& a", "a & & b", "| a", "a | | b", "; a", "a ;; b", "&& a", "a || ; b", "echo >", "echo > | cat", "echo < ;"}
+	for _, input := range invalid {
+		if _, err := parse(input); err == nil || errors.Is(err, errIncomplete) {
+			t.Errorf("parse(%q) error = %v, expected syntax error", input, err)
+		}
+	}
+}
+
+func TestParseCompound(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if true; then echo a; fi", "if {[true]} then {[echo] [a]} fi"},
+		{"if a\nthen\n  b\nelif c; then d; else e; fi", "if {[a]} then {[b]} elif {[c]} then {[d]} else {[e]} fi"},
+		{"if a && b; then c | d; fi > out", "if {[a] && [b]} then {[c] | [d]} fi 1>[out]"},
+		{"while test -n x; do echo; done | cat", "while {[test] [-n] [x]} do {[echo]} done | [cat]"},
+		{"for x in a 'b c'; do echo $x; done", "for x in [a] [b c] do {[echo] [$x]} done"},
+		{"for x\ndo echo; done", "for x do {[echo]} done"},
+		{"for x; do echo; done &", "for x do {[echo]} done &"},
+		{"for x in; do echo; done", "for x in do {[echo]} done"},
+		{"case $x in a|b) echo ab;; *) echo other;; esac", "case [$x] in [a]|[b]) {[echo] [ab]} ;; [*]) {[echo] [other]} ;; esac"},
+		{"case x in\n  (a) ;;\n  b)\n    echo b\nesac", "case [x] in [a]) {} ;; [b]) {[echo] [b]} ;; esac"},
+		{"case x in esac", "case [x] in esac"},
+		// Зарезервированные слова не на месте имени команды и в кавычках - обычные слова
+		{"echo if then fi done", "[echo] [if] [then] [fi] [done]"},
+		{"'if' true", "[if] [true]"},
+		{"if true; then if false; then a; fi; fi", "if {[true]} then {if {[false]} then {[a]} fi} fi"},
+	}
+
+	for _, test := range tests {
+		list, err := parse(test.input)
+		if err != nil {
+			t.Errorf("parse(%q) error = %v", test.input, err)
+			continue
+		}
+		if got := formatList(list); got != test.expected {
+			t.Errorf("parse(%q) = %s, expected %s", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestParseCompoundErrors(t *testing.T) {
+	// Незавершённая конструкция продолжается на следующей строке
+	incomplete := []string{"if true", "if true; then", "if true; then echo\n", "if a; then b; else", "while true; do",
+		"for x", "for x in a b", "for x in a; do echo; ", "case x", "case x in", "case x in a) echo", "case x in a|"}
+	for _, input := range incomplete {
+		if _, err := parse(input); !errors.Is(err, errIncomplete) {
+			t.Errorf("parse(%q) error = %v, expected incomplete input", input, err)
+		}
+	}
+
+	invalid := []string{"fi", "then echo", "if; then a; fi", "if true; then fi", "if a; then b; fi c", "while a; done",
+		"for 1 in a; do b; done", "for x in a | b; do c; done", "done", "case x in a echo;; esac", "esac", "echo (", "echo a)"}
+	for _, input := range invalid {
+		if _, err := parse(input); err == nil || errors.Is(err, errIncomplete) {
+			t.Errorf("parse(%q) error = %v, expected syntax error", input, err)
+		}
+	}
+}
+
+func TestParseText(t *testing.T) {
+	list, err := parse("  sleep 1 |  cat   &&  echo 'a  b' # comment\n echo x &")
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+
+	if text := list.items[0].text; text != "sleep 1 |  cat   &&  echo 'a  b'" {
+		t.Errorf("andOr text = %q", text)
+	}
+	if text := list.items[0].pipelines[0].text; text != "sleep 1 |  cat" {
+		t.Errorf("pipeline text = %q", text)
+	}
+	if text := list.items[1].text; text != "echo x" || !list.items[1].background {
+		t.Errorf("background item = %q, %v", text, list.items[1].background)
+	}
+}
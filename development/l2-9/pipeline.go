@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// Код завершения, если команда не найдена
+const statusNotFound = 127
+
+// Выполняет конвейер на переднем плане и возвращает код завершения его последней команды
+func (sh *shell) runPipeline(pl *pipeline, s streams) int {
+	// Копия шелла для составной команды выполняет свои конвейеры в том же задании
+	if sh.job != nil {
+		return <-sh.startPipeline(pl, s, sh.job)
+	}
+
+	// Составная команда вне конвейера выполняется в самом шелле, а каждая её команда - отдельное задание
+	if cmd, ok := pl.commands[0].(*compoundCommand); ok && len(pl.commands) == 1 {
+		return sh.runCompound(cmd, s)
+	}
+
+	j := newJob(pl.text, true)
+
+	status := sh.startPipeline(pl, s, j)
+	go func() {
+		j.finish(<-status)
+	}()
+
+	return sh.waitForeground(j, s)
+}
+
+// Запускает конвейер cmd1 | cmd2 | ... | cmdN в задании j. Все команды работают одновременно, stdout каждой
+// соединён через os.Pipe со stdin следующей, внешние команды образуют новую группу процессов.
+// Возвращает канал, в который придёт код завершения последней команды
+func (sh *shell) startPipeline(pl *pipeline, s streams, j *job) <-chan int {
+	stages := pl.commands
+	waits := make([]func() int, 0, len(stages))
+
+	// Следующий конвейер задания присоединяется к его группе, пока в ней есть процессы:
+	// так kill %1 и Ctrl+C действуют на все команды цикла в конвейере
+	if pgid := j.group(); pgid == 0 || syscall.Kill(-pgid, 0) != nil {
+		j.newGroup()
+	}
+
+	stdin := s.stdin
+
+	for i, cmd := range stages {
+		stage := streams{stdin: stdin, stdout: s.stdout, stderr: s.stderr}
+
+		// Концы каналов, которые шелл закрывает, когда команда перестанет в них нуждаться
+		closers := make([]io.Closer, 0, 2)
+		if reader, ok := stdin.(*os.File); ok && i > 0 {
+			closers = append(closers, reader)
+		}
+
+		if i < len(stages)-1 {
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				fmt.Fprintf(s.stderr, "pipe error: %v\n", err)
+				closeAll(closers)
+				waits = append(waits, exited(1))
+				break
+			}
+
+			stage.stdout = writer
+			closers = append(closers, writer)
+			stdin = reader
+		}
+
+		// Команды конвейера из нескольких команд выполняются одновременно, как в подоболочках:
+		// cd, присваивания и exit в одной из них не действуют ни на шелл, ни на другие команды
+		stageShell := sh
+		if len(stages) > 1 {
+			stageShell = sh.subshell()
+		}
+
+		waits = append(waits, stageShell.startCommand(cmd, stage, closers, j))
+	}
+
+	// Ожидание начинается, когда запущены все команды: пока лидер группы не собран, к его группе
+	// могут присоединиться следующие процессы, даже если он уже завершился
+	statuses := make([]int, len(waits))
+	wg := sync.WaitGroup{}
+
+	for i, wait := range waits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = wait()
+		}()
+	}
+
+	result := make(chan int, 1)
+	go func() {
+		wg.Wait()
+		result <- statuses[len(statuses)-1]
+	}()
+
+	return result
+}
+
+// Запускает одну команду конвейера и возвращает функцию, которая ждёт её код завершения.
+// closers закрываются: для внешней команды сразу после запуска, так как у процесса свои копии дескрипторов,
+// для встроенной - после завершения
+func (sh *shell) startCommand(cmd command, s streams, closers []io.Closer, j *job) func() int {
+	switch cmd := cmd.(type) {
+	case *simpleCommand:
+		return sh.startSimpleCommand(cmd, s, closers, j)
+	case *compoundCommand:
+		// В конвейере или в фоне составная команда выполняется, как в подоболочке: в копии шелла,
+		// которая не меняет его переменные и текущий каталог
+		sub := sh.subshell()
+		sub.job = j
+		return func() int {
+			defer closeAll(closers)
+			return sub.runCompound(cmd, s)
+		}
+	default:
+		closeAll(closers)
+		fmt.Fprintf(s.stderr, "unsupported command %T\n", cmd)
+		return exited(1)
+	}
+}
+
+func (sh *shell) startSimpleCommand(cmd *simpleCommand, s streams, closers []io.Closer, j *job) func() int {
+	// Трассировка set -x идёт в stderr шелла, а не в перенаправленный stderr команды
+	trace := s.stderr
+
+	s, files, err := applyRedirects(sh, cmd.redirects, s)
+	if err != nil {
+		closeAll(closers)
+		fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+		return exited(1)
+	}
+	closers = append(closers, files...)
+
+	args, err := sh.expandWords(cmd.args)
+	if err != nil {
+		closeAll(closers)
+		fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+		return exited(1)
+	}
+	sh.trace(trace, cmd.assigns, args)
+
+	// Без команды присваивания меняют переменные шелла, а перенаправления только создают файлы
+	if len(args) == 0 {
+		closeAll(closers)
+		return exited(sh.assign(cmd.assigns, s))
+	}
+
+	// Присваивания перед командой действуют только на эту команду
+	env := sh
+	if len(cmd.assigns) > 0 {
+		if env, err = sh.withAssignments(cmd.assigns); err != nil {
+			closeAll(closers)
+			fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+			return exited(1)
+		}
+	}
+
+	if run, ok := builtins[args[0]]; ok {
+		return func() int {
+			defer closeAll(closers)
+			return run(env, args, s)
+		}
+	}
+
+	return sh.startExternal(args, env.environ(), s, closers, j)
+}
+
+// Выполняет внешнюю команду вне задания, в группе процессов шелла
+func (sh *shell) executeExternal(args []string, env []string, s streams) int {
+	return sh.startExternal(args, env, s, nil, nil)()
+}
+
+// Запускает внешнюю команду в группе процессов задания j
+func (sh *shell) startExternal(args []string, env []string, s streams, closers []io.Closer, j *job) func() int {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	cmd.Dir = sh.dir
+	cmd.Stdin = s.stdin
+	cmd.Stdout = s.stdout
+	cmd.Stderr = s.stderr
+
+	var err error
+	if j != nil {
+		err = j.start(cmd, sh.terminal)
+	} else {
+		err = cmd.Start()
+	}
+	closeAll(closers)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "command error: %v\n", err)
+		return exited(statusNotFound)
+	}
+
+	return func() int {
+		if j != nil {
+			j.track(cmd.Process.Pid)
+		}
+
+		if err := cmd.Wait(); err != nil {
+			return exitStatus(err)
+		}
+		return 0
+	}
+}
+
+// Функция ожидания для команды, код завершения которой уже известен
+func exited(status int) func() int {
+	return func() int {
+		return status
+	}
+}
+
+// Код завершения процесса по ошибке exec. Процесс, убитый сигналом, завершается с кодом 128+сигнал
+func exitStatus(err error) int {
+	exitErr := &exec.ExitError{}
+	if !errors.As(err, &exitErr) {
+		return 1
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		_ = closer.Close()
+	}
+}
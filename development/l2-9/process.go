@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// Значения si_code в siginfo_t для waitid
+const (
+	cldStopped   = 5
+	cldContinued = 6
+)
+
+// Тип идентификатора waitid: ожидание процесса по PID
+const waitPID = 1
+
+// Информация о смене состояния дочернего процесса, начало структуры siginfo_t
+type childInfo struct {
+	signo  int32
+	errno  int32
+	code   int32
+	_      int32
+	pid    int32
+	uid    uint32
+	status int32
+	_      [100]byte
+}
+
+// Ждёт смены состояния дочернего процесса через waitid. Пакет syscall даёт только wait4,
+// а он забирает завершившийся процесс, который должен собрать exec.Cmd.Wait
+func waitChild(pid int, options int) (childInfo, error) {
+	info := childInfo{}
+
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, waitPID, uintptr(pid), uintptr(unsafe.Pointer(&info)), uintptr(options), 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return info, errno
+		}
+		return info, nil
+	}
+}
+
+// Сообщает, является ли дескриптор терминалом
+func isTerminal(fd int) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// Делает группу процессов pgid активной группой терминала fd: ей достаются ввод и сигналы от Ctrl+C и Ctrl+Z.
+// Фоновый процесс, меняющий активную группу, получает SIGTTOU, поэтому на время вызова сигнал игнорируется
+func setForegroundGroup(fd int, pgid int) error {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	id := int32(pgid)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&id)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Режим терминала
+func getTermios(fd int) (syscall.Termios, error) {
+	termios := syscall.Termios{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		return termios, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd int, termios syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCSETS, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Ширина терминала в столбцах или 80, если её не удалось узнать
+func terminalWidth(fd int) int {
+	size := struct {
+		rows, cols, x, y uint16
+	}{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.cols == 0 {
+		return 80
+	}
+	return int(size.cols)
+}
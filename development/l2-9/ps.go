@@ -0,0 +1,484 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+)
+
+// Каталог с информацией о процессах
+const procRoot = "/proc"
+
+// Тиков процессорного времени в секунде (USER_HZ). В Linux для пользовательских программ всегда 100
+const clockTicks = 100
+
+// Столбцы ps по умолчанию
+const defaultPSColumns = "pid,ppid,user,stat,time,rss,cmd"
+
+var errBadProcStat = errors.New("bad stat format")
+
+// Процесс из /proc
+type process struct {
+	pid  int
+	ppid int
+	user string
+	// Состояние: R, S, D, Z, T и т.д.
+	state string
+	// Время процессора в режимах пользователя и ядра
+	cpuTime time.Duration
+	// Резидентная память в КБ
+	rss int64
+	// Имя исполняемого файла
+	comm string
+	// Командная строка. У потоков ядра пустая
+	cmdline string
+}
+
+// Столбец вывода ps
+type psColumn struct {
+	header string
+	// Числа выравниваются по правому краю
+	right bool
+	value func(p *process) string
+	// Порядок для сортировки
+	compare func(a, b *process) int
+}
+
+var psColumns = map[string]psColumn{
+	"pid": {
+		header:  "PID",
+		right:   true,
+		value:   func(p *process) string { return strconv.Itoa(p.pid) },
+		compare: func(a, b *process) int { return cmp.Compare(a.pid, b.pid) },
+	},
+	"ppid": {
+		header:  "PPID",
+		right:   true,
+		value:   func(p *process) string { return strconv.Itoa(p.ppid) },
+		compare: func(a, b *process) int { return cmp.Compare(a.ppid, b.ppid) },
+	},
+	"user": {
+		header:  "USER",
+		value:   func(p *process) string { return p.user },
+		compare: func(a, b *process) int { return strings.Compare(a.user, b.user) },
+	},
+	"stat": {
+		header:  "STAT",
+		value:   func(p *process) string { return p.state },
+		compare: func(a, b *process) int { return strings.Compare(a.state, b.state) },
+	},
+	"time": {
+		header:  "TIME",
+		right:   true,
+		value:   func(p *process) string { return formatCPUTime(p.cpuTime) },
+		compare: func(a, b *process) int { return cmp.Compare(a.cpuTime, b.cpuTime) },
+	},
+	"rss": {
+		header:  "RSS",
+		right:   true,
+		value:   func(p *process) string { return strconv.FormatInt(p.rss, 10) },
+		compare: func(a, b *process) int { return cmp.Compare(a.rss, b.rss) },
+	},
+	"comm": {
+		header:  "COMMAND",
+		value:   func(p *process) string { return p.comm },
+		compare: func(a, b *process) int { return strings.Compare(a.comm, b.comm) },
+	},
+	"cmd": {
+		header:  "CMD",
+		value:   (*process).command,
+		compare: func(a, b *process) int { return strings.Compare(a.command(), b.command()) },
+	},
+}
+
+// Другие названия столбцов, как в procps
+var psAliases = map[string]string{
+	"state":   "stat",
+	"s":       "stat",
+	"cputime": "time",
+	"rssize":  "rss",
+	"args":    "cmd",
+	"command": "cmd",
+	"ucmd":    "comm",
+}
+
+// Командная строка процесса. Для потоков ядра - имя в квадратных скобках
+func (p *process) command() string {
+	if p.cmdline == "" {
+		return "[" + p.comm + "]"
+	}
+	return p.cmdline
+}
+
+// Параметры вывода ps
+type psOptions struct {
+	columns []string
+	// Ключи сортировки, перед ключом по убыванию стоит -
+	sort []string
+	tree bool
+}
+
+// Выводит список процессов из /proc:
+// ps [-e] [-o столбцы] [--sort [-]столбец,...] [-H|--forest]
+func executePS(sh *shell, args []string, s streams) int {
+	opts, err := parsePSArgs(args[1:])
+	if err != nil {
+		fmt.Fprintf(s.stderr, "ps error: %v\n", err)
+		return 1
+	}
+
+	processes, err := readProcesses(procRoot)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "ps error: %v\n", err)
+		return 1
+	}
+
+	if err := writeProcesses(s.stdout, processes, opts); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func parsePSArgs(args []string) (psOptions, error) {
+	opts := psOptions{columns: strings.Split(defaultPSColumns, ","), sort: []string{"pid"}}
+
+	// Значение флага: -o LIST, -oLIST или --sort=LIST
+	value := func(i *int, arg, flag string) (string, error) {
+		if v, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return v, nil
+		}
+		if v := strings.TrimPrefix(arg, flag); v != "" {
+			return v, nil
+		}
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("option %s requires an argument", flag)
+		}
+		*i++
+		return args[*i], nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "-e" || arg == "-A":
+			// Процессы и так выводятся все
+		case arg == "-H" || arg == "--forest":
+			opts.tree = true
+		case strings.HasPrefix(arg, "--sort"):
+			list, err := value(&i, arg, "--sort")
+			if err != nil {
+				return opts, err
+			}
+			opts.sort = strings.Split(list, ",")
+		case strings.HasPrefix(arg, "-o"):
+			list, err := value(&i, arg, "-o")
+			if err != nil {
+				return opts, err
+			}
+			opts.columns = strings.Split(list, ",")
+		default:
+			return opts, fmt.Errorf("invalid option: %s", arg)
+		}
+	}
+
+	for i, name := range opts.columns {
+		column, err := lookupPSColumn(name)
+		if err != nil {
+			return opts, err
+		}
+		opts.columns[i] = column
+	}
+
+	for i, key := range opts.sort {
+		desc := strings.HasPrefix(key, "-")
+		column, err := lookupPSColumn(strings.TrimLeft(key, "+-"))
+		if err != nil {
+			return opts, err
+		}
+		if desc {
+			column = "-" + column
+		}
+		opts.sort[i] = column
+	}
+
+	return opts, nil
+}
+
+func lookupPSColumn(name string) (string, error) {
+	name = strings.ToLower(name)
+	if alias, ok := psAliases[name]; ok {
+		name = alias
+	}
+
+	if _, ok := psColumns[name]; !ok {
+		return "", fmt.Errorf("unknown column: %q", name)
+	}
+	return name, nil
+}
+
+// Читает все процессы. Процессы, завершившиеся во время чтения, пропускаются
+func readProcesses(root string) ([]*process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[uint32]string)
+	processes := make([]*process, 0, len(entries))
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		p, err := readProcess(filepath.Join(root, entry.Name()), pid, users)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ESRCH) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("process %d: %w", pid, err)
+		}
+		processes = append(processes, p)
+	}
+
+	return processes, nil
+}
+
+// Читает процесс из каталога /proc/<pid>. users - кэш имён пользователей по UID
+func readProcess(dir string, pid int, users map[uint32]string) (*process, error) {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := parseProcStat(string(stat))
+	if err != nil {
+		return nil, err
+	}
+	p.pid = pid
+
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	p.cmdline = printable(strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " ")))
+
+	// Владелец каталога процесса - его действующий пользователь
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		p.user = lookupUser(st.Uid, users)
+	}
+
+	return p, nil
+}
+
+// Разбирает /proc/<pid>/stat. Имя команды в скобках может содержать пробелы и скобки,
+// поэтому поля считаются от последней закрывающей скобки
+func parseProcStat(stat string) (*process, error) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return nil, errBadProcStat
+	}
+
+	// Поля после имени: state ppid pgrp session tty_nr tpgid flags minflt cminflt majflt cmajflt utime stime ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil, errBadProcStat
+	}
+
+	numbers := make([]int64, 0, 4)
+	for _, i := range []int{1, 11, 12, 21} {
+		n, err := strconv.ParseInt(fields[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errBadProcStat, err)
+		}
+		numbers = append(numbers, n)
+	}
+	ppid, utime, stime, rssPages := numbers[0], numbers[1], numbers[2], numbers[3]
+
+	return &process{
+		ppid:    int(ppid),
+		state:   fields[0],
+		cpuTime: time.Duration(utime+stime) * time.Second / clockTicks,
+		rss:     rssPages * int64(os.Getpagesize()) / 1024,
+		comm:    printable(stat[open+1 : end]),
+	}, nil
+}
+
+// Управляющие символы в аргументах процесса заменяются на ?, чтобы не ломать таблицу
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// Имя пользователя по UID или сам UID, если пользователь неизвестен
+func lookupUser(uid uint32, users map[uint32]string) string {
+	if name, ok := users[uid]; ok {
+		return name
+	}
+
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	users[uid] = name
+
+	return name
+}
+
+// Время процессора в формате [ДД-]ЧЧ:ММ:СС
+func formatCPUTime(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	days, seconds := seconds/86400, seconds%86400
+
+	clock := fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	if days > 0 {
+		return fmt.Sprintf("%d-%s", days, clock)
+	}
+	return clock
+}
+
+// Упорядочивает процессы по ключам сортировки, при равенстве - по PID
+func sortProcesses(processes []*process, keys []string) {
+	slices.SortStableFunc(processes, func(a, b *process) int {
+		for _, key := range keys {
+			name, desc := strings.CutPrefix(key, "-")
+			c := psColumns[name].compare(a, b)
+			if desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.pid, b.pid)
+	})
+}
+
+// Строка вывода: процесс и глубина в дереве
+type psRow struct {
+	p     *process
+	depth int
+}
+
+// Упорядочивает процессы деревом: потомки идут сразу за родителем, в каждом уровне - по ключам сортировки.
+// Корни дерева - процессы, родителя которых нет в списке
+func treeRows(processes []*process) []psRow {
+	children := make(map[int][]*process)
+	known := make(map[int]bool, len(processes))
+	for _, p := range processes {
+		known[p.pid] = true
+	}
+
+	roots := make([]*process, 0)
+	for _, p := range processes {
+		if known[p.ppid] && p.ppid != p.pid {
+			children[p.ppid] = append(children[p.ppid], p)
+		} else {
+			roots = append(roots, p)
+		}
+	}
+
+	rows := make([]psRow, 0, len(processes))
+
+	var walk func(p *process, depth int)
+	walk = func(p *process, depth int) {
+		rows = append(rows, psRow{p: p, depth: depth})
+		for _, child := range children[p.pid] {
+			walk(child, depth+1)
+		}
+	}
+	for _, p := range roots {
+		walk(p, 0)
+	}
+
+	return rows
+}
+
+// Выводит процессы таблицей. В дереве имя команды сдвигается по глубине процесса
+func writeProcesses(w io.Writer, processes []*process, opts psOptions) error {
+	sortProcesses(processes, opts.sort)
+
+	rows := make([]psRow, 0, len(processes))
+	if opts.tree {
+		rows = treeRows(processes)
+	} else {
+		for _, p := range processes {
+			rows = append(rows, psRow{p: p})
+		}
+	}
+
+	table := make([][]string, 0, len(rows)+1)
+
+	header := make([]string, 0, len(opts.columns))
+	for _, name := range opts.columns {
+		header = append(header, psColumns[name].header)
+	}
+	table = append(table, header)
+
+	for _, row := range rows {
+		line := make([]string, 0, len(opts.columns))
+		for _, name := range opts.columns {
+			value := psColumns[name].value(row.p)
+			if opts.tree && row.depth > 0 && (name == "cmd" || name == "comm") {
+				value = strings.Repeat("    ", row.depth-1) + " \\_ " + value
+			}
+			line = append(line, value)
+		}
+		table = append(table, line)
+	}
+
+	widths := make([]int, len(opts.columns))
+	for _, line := range table {
+		for i, value := range line {
+			widths[i] = max(widths[i], len(value))
+		}
+	}
+
+	for _, line := range table {
+		b := strings.Builder{}
+		for i, value := range line {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+
+			switch {
+			case psColumns[opts.columns[i]].right:
+				fmt.Fprintf(&b, "%*s", widths[i], value)
+			case i == len(line)-1:
+				// Последний текстовый столбец не дополняется пробелами
+				b.WriteString(value)
+			default:
+				fmt.Fprintf(&b, "%-*s", widths[i], value)
+			}
+		}
+		b.WriteByte('\n')
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			// Следующая команда конвейера могла завершиться, не дочитав вывод
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Создаёт каталог в формате /proc с процессами pid -> stat и cmdline
+func writeProcRoot(t *testing.T, processes map[string][2]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for pid, files := range processes {
+		dir := filepath.Join(root, pid)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(files[0]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(files[1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Не процессы
+	if err := os.Mkdir(filepath.Join(root, "sys"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+// Строка stat: pid (comm) state ppid, затем нули до utime, stime и rss
+func procStat(pid, comm, state, ppid, utime, stime, rss string) string {
+	zeros := strings.Repeat("0 ", 9)
+	return pid + " (" + comm + ") " + state + " " + ppid + " " + zeros + utime + " " + stime + " " + strings.Repeat("0 ", 8) + rss + " 0 0\n"
+}
+
+func TestPS(t *testing.T) {
+	root := writeProcRoot(t, map[string][2]string{
+		"1":   {procStat("1", "init", "S", "0", "150", "50", "100"), "/sbin/init\x00"},
+		"2":   {procStat("2", "kthreadd", "S", "0", "0", "0", "0"), ""},
+		"40":  {procStat("40", "sh", "S", "1", "0", "10", "300"), "sh\x00-c\x00sleep 1; echo) x\x00"},
+		"41":  {procStat("41", "sleep) (x", "R", "40", "360000", "0", "200"), "sleep\x001\x00"},
+		"300": {procStat("300", "orphan", "Z", "77", "8640000", "0", "0"), "orphan\nline\x00"},
+	})
+
+	processes, err := readProcesses(root)
+	if err != nil {
+		t.Fatalf("readProcesses error = %v", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			[]string{"-o", "pid,ppid,stat,time,cmd"},
+			"PID PPID STAT       TIME CMD\n" +
+				"  1    0 S      00:00:02 /sbin/init\n" +
+				"  2    0 S      00:00:00 [kthreadd]\n" +
+				" 40    1 S      00:00:00 sh -c sleep 1; echo) x\n" +
+				" 41   40 R      01:00:00 sleep 1\n" +
+				"300   77 Z    1-00:00:00 orphan?line\n",
+		},
+		{
+			[]string{"--sort=-time,pid", "-opid,comm"},
+			"PID COMMAND\n300 orphan\n 41 sleep) (x\n  1 init\n 40 sh\n  2 kthreadd\n",
+		},
+		{
+			[]string{"-e", "--forest", "-o", "pid,args"},
+			"PID CMD\n  1 /sbin/init\n 40  \\_ sh -c sleep 1; echo) x\n 41      \\_ sleep 1\n  2 [kthreadd]\n300 orphan?line\n",
+		},
+		{
+			[]string{"-H", "--sort", "-pid", "-o", "comm,stat"},
+			"COMMAND           STAT\norphan            Z\nkthreadd          S\ninit              S\n \\_ sh            S\n     \\_ sleep) (x R\n",
+		},
+	}
+
+	for _, test := range tests {
+		opts, err := parsePSArgs(test.args)
+		if err != nil {
+			t.Errorf("parsePSArgs(%q) error = %v", test.args, err)
+			continue
+		}
+
+		b := strings.Builder{}
+		if err := writeProcesses(&b, processes, opts); err != nil {
+			t.Errorf("%q error = %v", test.args, err)
+		}
+		if b.String() != test.expected {
+			t.Errorf("%q =\n%s\nexpected\n%s", test.args, b.String(), test.expected)
+		}
+	}
+}
+
+func TestPSErrors(t *testing.T) {
+	tests := [][]string{{"-o", "pid,bogus"}, {"--sort=-bogus"}, {"-o"}, {"-x"}}
+
+	for _, args := range tests {
+		if _, err := parsePSArgs(args); err == nil {
+			t.Errorf("parsePSArgs(%q) error = nil", args)
+		}
+	}
+
+	if _, err := parseProcStat("1 (broken"); err == nil {
+		t.Errorf("parseProcStat error = nil")
+	}
+}
+
+func TestPSSelf(t *testing.T) {
+	// Процесс теста есть в выводе настоящего /proc
+	stdout, status := runInput(t, "ps -o pid,ppid | grep -c '^ *"+strconv.Itoa(os.Getpid())+" '")
+	if status != 0 || stdout != "1\n" {
+		t.Errorf("ps = %q, %d, expected \"1\\n\", 0", stdout, status)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Применяет перенаправления слева направо: в "cmd > f 2>&1" оба потока идут в f,
+// а в "cmd 2>&1 > f" stderr остаётся там, куда шёл stdout до перенаправления.
+// Возвращает потоки команды и открытые файлы, которые нужно закрыть после неё
+func applyRedirects(sh *shell, redirects []redirect, s streams) (streams, []io.Closer, error) {
+	files := make([]io.Closer, 0, len(redirects))
+
+	for _, r := range redirects {
+		target, err := sh.expandRedirectTarget(r.target)
+		if err != nil {
+			closeAll(files)
+			return s, nil, err
+		}
+
+		if r.op == ">&" {
+			stream, err := s.get(target)
+			if err != nil {
+				closeAll(files)
+				return s, nil, err
+			}
+			if err := s.set(r.fd, stream); err != nil {
+				closeAll(files)
+				return s, nil, err
+			}
+			continue
+		}
+
+		file, err := openRedirect(r.op, target, sh.path(target))
+		if err != nil {
+			closeAll(files)
+			return s, nil, err
+		}
+		files = append(files, file)
+
+		switch r.op {
+		case "&>", "&>>":
+			s.stdout, s.stderr = file, file
+		default:
+			if err := s.set(r.fd, file); err != nil {
+				closeAll(files)
+				return s, nil, err
+			}
+		}
+	}
+
+	return s, files, nil
+}
+
+// Открывает файл перенаправления: < на чтение, > с очисткой, >> на дописывание.
+// path - имя файла в команде, resolved - путь к нему относительно текущего каталога шелла
+func openRedirect(op, path, resolved string) (*os.File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("ambiguous redirect: empty file name")
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch op {
+	case "<":
+		flag = os.O_RDONLY
+	case ">>", "&>>":
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(resolved, flag, 0o644)
+	if pathErr := (*os.PathError)(nil); errors.As(err, &pathErr) {
+		// "file: permission denied" вместо "open file: permission denied"
+		return nil, fmt.Errorf("%s: %w", path, pathErr.Err)
+	}
+
+	return file, err
+}
+
+// Поток по номеру дескриптора из >&N
+func (s streams) get(fd string) (any, error) {
+	switch fd {
+	case "0":
+		return s.stdin, nil
+	case "1":
+		return s.stdout, nil
+	case "2":
+		return s.stderr, nil
+	default:
+		return nil, fmt.Errorf("%s: bad file descriptor", fd)
+	}
+}
+
+// Заменяет поток с номером fd. stream должен поддерживать нужное направление
+func (s *streams) set(fd int, stream any) error {
+	switch fd {
+	case 0:
+		if reader, ok := stream.(io.Reader); ok {
+			s.stdin = reader
+			return nil
+		}
+	case 1:
+		if writer, ok := stream.(io.Writer); ok {
+			s.stdout = writer
+			return nil
+		}
+	case 2:
+		if writer, ok := stream.(io.Writer); ok {
+			s.stderr = writer
+			return nil
+		}
+	default:
+		return fmt.Errorf("%d: bad file descriptor", fd)
+	}
+
+	return fmt.Errorf("%d: file descriptor is not open for this direction", fd)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Код завершения при синтаксической ошибке в скрипте или неверных аргументах шелла
+const statusUsage = 2
+
+// Параметры set: буква для set -e и полное имя для set -o errexit
+var shellOptions = []struct {
+	flag byte
+	name string
+}{
+	// Шелл завершается, если команда вернула ненулевой код
+	{'e', "errexit"},
+	// Команды выводятся в stderr перед выполнением
+	{'x', "xtrace"},
+}
+
+func (sh *shell) option(name string) bool {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.options[name]
+}
+
+// Включает или выключает параметр по букве или полному имени
+func (sh *shell) setOption(name string, on bool) error {
+	for _, opt := range shellOptions {
+		if name == opt.name || name == string(opt.flag) {
+			sh.mu.Lock()
+			defer sh.mu.Unlock()
+
+			sh.options[opt.name] = on
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: invalid option", name)
+}
+
+// Завершает шелл с кодом code после текущей команды
+func (sh *shell) requestExit(code int) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.exiting = true
+	sh.exitCode = code
+}
+
+func (sh *shell) exitRequested() (int, bool) {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.exitCode, sh.exiting
+}
+
+// Выводит команду перед выполнением при set -x: + NAME=value cmd args
+func (sh *shell) trace(w io.Writer, assigns []assignment, args []string) {
+	if !sh.option("xtrace") {
+		return
+	}
+
+	words := make([]string, 0, len(assigns)+len(args))
+	for _, a := range assigns {
+		value, _ := sh.expandAssignment(a.value)
+		words = append(words, a.name+"="+quoteTrace(value))
+	}
+	for _, arg := range args {
+		words = append(words, quoteTrace(arg))
+	}
+
+	fmt.Fprintf(w, "+ %s\n", strings.Join(words, " "))
+}
+
+// Аргумент в одинарных кавычках, если без них он прочитался бы иначе
+func quoteTrace(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]#~{}") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Меняет параметры шелла: set [-ex] [+ex] [-o имя] [+o имя] [-- аргументы]. -o без имени выводит параметры,
+// аргументы после -- или после параметров становятся позиционными. Без аргументов выводит переменные
+func executeSet(sh *shell, args []string, s streams) int {
+	if len(args) < 2 {
+		for _, entry := range sh.variables() {
+			name, value, _ := strings.Cut(entry, "=")
+			fmt.Fprintf(s.stdout, "%s=%s\n", name, quoteValue(value))
+		}
+		return 0
+	}
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			sh.setPositional(args[i+1:])
+			return 0
+		}
+
+		on := arg[0] == '-'
+		if (arg[0] != '-' && arg[0] != '+') || len(arg) < 2 {
+			sh.setPositional(args[i:])
+			return 0
+		}
+
+		if arg[1:] == "o" {
+			if i+1 >= len(args) {
+				printOptions(sh, s)
+				return 0
+			}
+			i++
+			if err := sh.setOption(args[i], on); err != nil {
+				fmt.Fprintf(s.stderr, "set: %v\n", err)
+				return statusUsage
+			}
+			continue
+		}
+
+		for _, flag := range arg[1:] {
+			if err := sh.setOption(string(flag), on); err != nil {
+				fmt.Fprintf(s.stderr, "set: %c%v\n", arg[0], err)
+				return statusUsage
+			}
+		}
+	}
+
+	return 0
+}
+
+func printOptions(sh *shell, s streams) {
+	for _, opt := range shellOptions {
+		state := "off"
+		if sh.option(opt.name) {
+			state = "on"
+		}
+		fmt.Fprintf(s.stdout, "%-15s%s\n", opt.name, state)
+	}
+}
+
+// Завершает шелл: exit [код]. Без кода - с кодом последней команды
+func executeExit(sh *shell, args []string, s streams) int {
+	status, _ := sh.getVar("?")
+	code, _ := strconv.Atoi(status)
+
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Fprintf(s.stderr, "exit: %s: numeric argument required\n", args[1])
+			n = statusUsage
+		}
+		code = n & 0xff
+	}
+
+	sh.requestExit(code)
+	return code
+}
+
+// Читает строки по одному байту, чтобы не забрать из общего ввода строки, которые прочитают запущенные команды:
+// в "printf 'cat\nhello\n' | goshell" строку hello должен получить cat
+type unbufferedReader struct {
+	in io.Reader
+}
+
+func (r *unbufferedReader) readLine(prompt string) (string, error) {
+	line := make([]byte, 0, 128)
+	b := make([]byte, 1)
+
+	for {
+		n, err := r.in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// Параметры запуска шелла
+type invocation struct {
+	// Команды из -c
+	command    string
+	hasCommand bool
+	// Файл скрипта
+	script string
+	// $0 и позиционные параметры
+	name string
+	args []string
+	// Параметры set из командной строки
+	options []string
+}
+
+// Разбирает аргументы: goshell [-ex] [-o имя] [-c команда [имя [аргументы]] | скрипт [аргументы]]
+func parseInvocation(name string, args []string) (invocation, error) {
+	inv := invocation{name: name}
+
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+
+		if arg == "-o" {
+			if i+1 >= len(args) {
+				return inv, errors.New("-o: option requires an argument")
+			}
+			i++
+			inv.options = append(inv.options, args[i])
+			continue
+		}
+
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'c':
+				inv.hasCommand = true
+			default:
+				inv.options = append(inv.options, string(flag))
+			}
+		}
+	}
+
+	rest := args[i:]
+
+	if inv.hasCommand {
+		if len(rest) == 0 {
+			return inv, errors.New("-c: option requires an argument")
+		}
+		inv.command = rest[0]
+		if len(rest) > 1 {
+			inv.name = rest[1]
+			inv.args = slices.Clone(rest[2:])
+		}
+		return inv, nil
+	}
+
+	if len(rest) > 0 {
+		inv.script = rest[0]
+		inv.name = rest[0]
+		inv.args = slices.Clone(rest[1:])
+	}
+
+	return inv, nil
+}
+
+// Выполняет скрипт из файла
+func (sh *shell) runScript(path string, std streams) int {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(std.stderr, "GoShell: %v\n", err)
+		if errors.Is(err, os.ErrNotExist) {
+			return statusNotFound
+		}
+		return 126
+	}
+	defer file.Close()
+
+	return sh.runLines(newScannerReader(file, io.Discard), std, path, false)
+}
+
+// Выполняет команды из аргумента -c
+func (sh *shell) runCommandString(command string, std streams) int {
+	list, err := parse(command)
+	if errors.Is(err, errIncomplete) {
+		err = errors.New("syntax error: unexpected end of file")
+	}
+	if err != nil {
+		fmt.Fprintf(std.stderr, "GoShell: -c: %v\n", err)
+		return statusUsage
+	}
+
+	status := sh.runList(list, std)
+	if code, ok := sh.exitRequested(); ok {
+		return code
+	}
+	return status
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Запускает шелл с аргументами и вводом, как из командной строки, и возвращает stdout, stderr и код завершения
+func runShell(t *testing.T, args []string, input string) (string, string, int) {
+	t.Helper()
+
+	// Ввод - файл, как у настоящего шелла: запущенные команды читают его с того места, где остановился шелл
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	stdout, stderr := &lockedBuffer{}, &lockedBuffer{}
+	status := run("goshell", args, streams{stdin: stdin, stdout: stdout, stderr: stderr})
+
+	return stdout.String(), stderr.String(), status
+}
+
+func TestCommandString(t *testing.T) {
+	tests := []struct {
+		args     []string
+		stdout   string
+		stderr   string
+		expected int
+	}{
+		{[]string{"-c", "echo hello; false"}, "hello\n", "", 1},
+		{[]string{"-c", "echo $0 $# \"$@\"", "name", "a", "b c"}, "name 2 a b c\n", "", 0},
+		{[]string{"-c", "printf '[%s]' \"$@\"", "name", "a", "b c"}, "[a][b c]", "", 0},
+		{[]string{"-c", "exit 3; echo no"}, "", "", 3},
+		{[]string{"-c", "false; exit"}, "", "", 1},
+		{[]string{"-c", "exit 300"}, "", "", 44},
+		{[]string{"-c", "echo 'open"}, "", "GoShell: -c: syntax error: unexpected end of file\n", 2},
+		{[]string{"-c"}, "", "GoShell: -c: option requires an argument\n", 2},
+		{[]string{"-q", "-c", "true"}, "", "GoShell: q: invalid option\n", 2},
+		{[]string{"-e", "-c", "echo a; false; echo b"}, "a\n", "", 1},
+		{[]string{"-x", "-c", "A=1 echo \"$A\" 'b c' > /dev/null"}, "", "+ A=1 echo '' 'b c'\n", 0},
+	}
+
+	for _, test := range tests {
+		stdout, stderr, status := runShell(t, test.args, "")
+		if stdout != test.stdout || stderr != test.stderr || status != test.expected {
+			t.Errorf("goshell %q = %q, %q, %d, expected %q, %q, %d",
+				test.args, stdout, stderr, status, test.stdout, test.stderr, test.expected)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdout   string
+		expected int
+	}{
+		// Ошибка в проверке && и || не завершает шелл при set -e
+		{"set -e; false || echo handled; false && echo no; echo after; false; echo no", "handled\nafter\n", 1},
+		{"set -e; set +e; false; echo continued", "continued\n", 0},
+		{"set -o errexit; set -o | grep errexit; set +o errexit; false", "errexit        on\n", 1},
+		{"set -- a 'b c'; echo $# $2; set x; echo $# $1", "2 b c\n1 x\n", 0},
+		{"FOO='a b'; set | grep ^FOO=", "FOO=\"a b\"\n", 0},
+		{"set -k", "", 2},
+		{"set -o nope", "", 2},
+	}
+
+	for _, test := range tests {
+		stdout, _, status := runShell(t, []string{"-c", test.input}, "")
+		if stdout != test.stdout || status != test.expected {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.stdout, test.expected)
+		}
+	}
+}
+
+func TestPipelineSubshells(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		stdout   string
+		expected int
+	}{
+		// Встроенные команды конвейера из нескольких команд не меняют шелл
+		{"cd / | cat; pwd", wd + "\n", 0},
+		{"X=1 | cat; echo \"[$X]\"", "[]\n", 0},
+		{"exit 3 | cat; echo after $?", "after 0\n", 0},
+		{"echo x | exit 4; echo after $?", "after 4\n", 0},
+		// Внешние команды, test и перенаправления подоболочки работают в её текущем каталоге
+		{"for d in /; do cd $d; pwd; ls -d proc; test -d proc && echo dir; done | cat; pwd", "/\nproc\ndir\n" + wd + "\n", 0},
+		{"for d in /nonexistent; do cd $d; done | cat; pwd", wd + "\n", 0},
+	}
+
+	for _, test := range tests {
+		stdout, _, status := runShell(t, []string{"-c", test.input}, "")
+		if stdout != test.stdout || status != test.expected {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.stdout, test.expected)
+		}
+	}
+}
+
+func TestScript(t *testing.T) {
+	dir := t.TempDir()
+
+	script := filepath.Join(dir, "script.sh")
+	content := "#!/usr/bin/env goshell\n# комментарий\necho \"$0\" $# $1\n\necho 'multi\nline'\nexit $2\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, status := runShell(t, []string{script, "first", "5"}, "")
+	expected := script + " 2 first\nmulti\nline\n"
+	if stdout != expected || stderr != "" || status != 5 {
+		t.Errorf("script = %q, %q, %d, expected %q, \"\", 5", stdout, stderr, status, expected)
+	}
+
+	broken := filepath.Join(dir, "broken.sh")
+	if err := os.WriteFile(broken, []byte("echo before\necho x |\n| cat\necho after\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, status = runShell(t, []string{broken}, "")
+	if stdout != "before\n" || !strings.HasPrefix(stderr, "GoShell: "+broken+": line 3: ") || status != 2 {
+		t.Errorf("broken script = %q, %q, %d", stdout, stderr, status)
+	}
+
+	_, stderr, status = runShell(t, []string{filepath.Join(dir, "missing.sh")}, "")
+	if stderr == "" || status != statusNotFound {
+		t.Errorf("missing script = %q, %d, expected error and %d", stderr, status, statusNotFound)
+	}
+}
+
+func TestStdinScript(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdout   string
+		expected int
+	}{
+		// Без терминала приглашения и коды завершения не выводятся
+		{"echo one\nfalse\necho two\n", "one\ntwo\n", 0},
+		{"echo 'a\nb'\nfalse", "a\nb\n", 1},
+		// Команда дочитывает строки ввода, которые шелл ещё не прочитал
+		{"sh -c 'read line; echo got $line'\nfrom stdin\necho done\n", "got from stdin\ndone\n", 0},
+		{"echo before\nexit 4\necho after\n", "before\n", 4},
+		{"echo 'open\n", "", 2},
+		{"quit\necho no\n", "", 0},
+	}
+
+	for _, test := range tests {
+		stdout, _, status := runShell(t, nil, test.input)
+		if stdout != test.stdout || status != test.expected {
+			t.Errorf("%q = %q, %d, expected %q, %d", test.input, stdout, status, test.stdout, test.expected)
+		}
+	}
+}
+
+// Скрипты testdata/scripts/*.sh выполняются с временным каталогом в $1. Их общий вывод stdout и stderr
+// и ненулевой код завершения, записанный как exit status N, сравниваются с файлом .out рядом со скриптом
+func TestScriptFixtures(t *testing.T) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", "scripts", "*.sh"))
+	if err != nil || len(scripts) == 0 {
+		t.Fatalf("no script fixtures: %v", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, script := range scripts {
+		t.Run(filepath.Base(script), func(t *testing.T) {
+			// Скрипты меняют текущий каталог
+			defer func() {
+				if err := os.Chdir(wd); err != nil {
+					t.Fatal(err)
+				}
+			}()
+
+			expected, err := os.ReadFile(strings.TrimSuffix(script, ".sh") + ".out")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output := &lockedBuffer{}
+			status := run("goshell", []string{filepath.Join(wd, script), t.TempDir()}, streams{stdout: output, stderr: output})
+			if status != 0 {
+				fmt.Fprintf(output, "exit status %d\n", status)
+			}
+
+			if got := output.String(); got != string(expected) {
+				t.Errorf("output:\n%s\nexpected:\n%s", got, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Состояние шелла: переменные и код завершения последней команды
+type shell struct {
+	vars map[string]string
+	// Переменные, которые передаются в окружение запускаемых команд
+	exported map[string]bool
+	// Код завершения последнего конвейера, $?
+	status int
+	// Имя шелла или скрипта, $0, и позиционные параметры $1, $2, ...
+	name string
+	args []string
+
+	// Включённые параметры set: errexit, xtrace
+	options map[string]bool
+
+	// Шелл завершается командой exit или из-за set -e
+	exiting  bool
+	exitCode int
+	// Ctrl+C прервал команду на переднем плане: оставшиеся команды строки не выполняются
+	interrupted bool
+	// Глубина вложенности условий if и while, в которых ошибка не завершает шелл при set -e
+	conditions int
+	// Глубина вложенности циклов while и for
+	loops int
+	// После break и continue: сколько циклов осталось покинуть и начинает ли последний из них следующую итерацию
+	loopJumps    int
+	loopContinue bool
+
+	// Задание, в котором выполняется копия шелла для составной команды в конвейере или в фоне
+	job *job
+	// Текущий каталог подоболочки. cd в ней меняет только его, а не каталог процесса, общий для всех команд.
+	// Пустой у самого шелла: его текущий каталог - каталог процесса
+	dir string
+
+	// Встроенные команды конвейера выполняются параллельно и могут менять переменные
+	mu *sync.RWMutex
+
+	jobs    *jobTable
+	history *history
+	// Терминал, на котором шелл управляет заданиями, или -1
+	terminal int
+	// Группа процессов шелла
+	pgid int
+}
+
+// Создаёт шелл, экспортированные переменные которого - окружение процесса
+func newShell() *shell {
+	sh := &shell{
+		vars:     make(map[string]string),
+		exported: make(map[string]bool),
+		options:  make(map[string]bool),
+		mu:       &sync.RWMutex{},
+		name:     "goshell",
+		jobs:     newJobTable(),
+		history:  newHistory(historySize),
+		terminal: -1,
+	}
+
+	for _, entry := range os.Environ() {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			sh.vars[name] = value
+			sh.exported[name] = true
+		}
+	}
+
+	return sh
+}
+
+// Значение переменной, включая специальные $?, $$, $!, $# и $*, и позиционных параметров $0, $1, ...
+func (sh *shell) getVar(name string) (string, bool) {
+	switch name {
+	case "$":
+		return strconv.Itoa(os.Getpid()), true
+	case "!":
+		if pid := sh.jobs.getLastPid(); pid != 0 {
+			return strconv.Itoa(pid), true
+		}
+		return "", false
+	}
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	switch name {
+	case "?":
+		return strconv.Itoa(sh.status), true
+	case "#":
+		return strconv.Itoa(len(sh.args)), true
+	case "*", "@":
+		return strings.Join(sh.args, " "), true
+	case "0":
+		return sh.name, true
+	}
+
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 1 || n > len(sh.args) {
+			return "", false
+		}
+		return sh.args[n-1], true
+	}
+
+	value, ok := sh.vars[name]
+	return value, ok
+}
+
+func (sh *shell) setVar(name, value string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.vars[name] = value
+}
+
+// Помечает переменную для передачи в окружение. Переменная без значения в окружение не попадает, пока её не зададут
+func (sh *shell) exportVar(name string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.exported[name] = true
+}
+
+func (sh *shell) unsetVar(name string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	delete(sh.vars, name)
+	delete(sh.exported, name)
+}
+
+// Позиционные параметры $1, $2, ...
+func (sh *shell) positional() []string {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return slices.Clone(sh.args)
+}
+
+func (sh *shell) setPositional(args []string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.args = slices.Clone(args)
+}
+
+func (sh *shell) setStatus(status int) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.status = status
+}
+
+// Окружение для запускаемых команд в формате NAME=value, упорядоченное по имени
+func (sh *shell) environ() []string {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	env := make([]string, 0, len(sh.exported))
+	for name := range sh.exported {
+		if value, ok := sh.vars[name]; ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	slices.Sort(env)
+
+	return env
+}
+
+// Все переменные шелла в формате NAME=value, упорядоченные по имени
+func (sh *shell) variables() []string {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	vars := make([]string, 0, len(sh.vars))
+	for name, value := range sh.vars {
+		vars = append(vars, name+"="+value)
+	}
+	slices.Sort(vars)
+
+	return vars
+}
+
+// Выполняет присваивания NAME=value без команды
+func (sh *shell) assign(assigns []assignment, s streams) int {
+	for _, a := range assigns {
+		value, err := sh.expandAssignment(a.value)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "GoShell: %v\n", err)
+			return 1
+		}
+		sh.setVar(a.name, value)
+	}
+
+	return 0
+}
+
+// Копия шелла с присваиваниями перед командой (FOO=1 cmd), экспортированными только для этой команды
+func (sh *shell) withAssignments(assigns []assignment) (*shell, error) {
+	values := make([]string, 0, len(assigns))
+	for _, a := range assigns {
+		value, err := sh.expandAssignment(a.value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	scoped := sh.clone()
+	for i, a := range assigns {
+		scoped.vars[a.name] = values[i]
+		scoped.exported[a.name] = true
+	}
+
+	return scoped, nil
+}
+
+// Копия шелла с собственными переменными. Список заданий и терминал у копий общие
+func (sh *shell) clone() *shell {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	scoped := *sh
+	scoped.vars = maps.Clone(sh.vars)
+	scoped.exported = maps.Clone(sh.exported)
+	scoped.options = maps.Clone(sh.options)
+	scoped.mu = &sync.RWMutex{}
+
+	return &scoped
+}
+
+// Копия шелла для команды конвейера или фонового задания, как подоболочка: её переменные, текущий каталог
+// и exit не влияют на шелл
+func (sh *shell) subshell() *shell {
+	sub := sh.clone()
+	if sub.dir == "" {
+		if dir, err := os.Getwd(); err == nil {
+			sub.dir = dir
+		} else {
+			sub.dir = "."
+		}
+	}
+
+	return sub
+}
+
+// Путь относительно текущего каталога шелла
+func (sh *shell) path(name string) string {
+	if sh.dir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(sh.dir, name)
+}
+
+// Экспортирует переменные: export NAME=value или export NAME. Без аргументов выводит экспортированные переменные
+func executeExport(sh *shell, args []string, s streams) int {
+	if len(args) < 2 {
+		for _, entry := range sh.environ() {
+			name, value, _ := strings.Cut(entry, "=")
+			fmt.Fprintf(s.stdout, "export %s=%s\n", name, quoteValue(value))
+		}
+		return 0
+	}
+
+	status := 0
+
+	for _, arg := range args[1:] {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !isName(name) {
+			fmt.Fprintf(s.stderr, "export: `%s': not a valid identifier\n", arg)
+			status = 1
+			continue
+		}
+
+		if hasValue {
+			sh.setVar(name, value)
+		}
+		sh.exportVar(name)
+	}
+
+	return status
+}
+
+// Удаляет переменные
+func executeUnset(sh *shell, args []string, s streams) int {
+	status := 0
+
+	for _, name := range args[1:] {
+		if !isName(name) {
+			fmt.Fprintf(s.stderr, "unset: `%s': not a valid identifier\n", name)
+			status = 1
+			continue
+		}
+		sh.unsetVar(name)
+	}
+
+	return status
+}
+
+// Выводит окружение. env NAME=value... cmd запускает cmd с дополнительными переменными
+func executeEnv(sh *shell, args []string, s streams) int {
+	env := sh.environ()
+
+	i := 1
+	for ; i < len(args); i++ {
+		name, _, ok := strings.Cut(args[i], "=")
+		if !ok || !isName(name) {
+			break
+		}
+		env = append(env, args[i])
+	}
+
+	if i < len(args) {
+		return sh.executeExternal(args[i:], env, s)
+	}
+
+	for _, entry := range env {
+		fmt.Fprintln(s.stdout, entry)
+	}
+
+	return 0
+}
+
+// Значение в двойных кавычках, которое можно снова ввести в шелл
+func quoteValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Проверки файлов по режиму: -f путь и т. п. Ложны, если файла нет
+var fileModeTests = map[string]func(info os.FileInfo) bool{
+	"-e": func(os.FileInfo) bool { return true },
+	"-f": func(info os.FileInfo) bool { return info.Mode().IsRegular() },
+	"-d": func(info os.FileInfo) bool { return info.IsDir() },
+	"-s": func(info os.FileInfo) bool { return info.Size() > 0 },
+	"-L": func(info os.FileInfo) bool { return info.Mode()&os.ModeSymlink != 0 },
+	"-h": func(info os.FileInfo) bool { return info.Mode()&os.ModeSymlink != 0 },
+	"-p": func(info os.FileInfo) bool { return info.Mode()&os.ModeNamedPipe != 0 },
+	"-S": func(info os.FileInfo) bool { return info.Mode()&os.ModeSocket != 0 },
+	"-c": func(info os.FileInfo) bool { return info.Mode()&os.ModeCharDevice != 0 },
+	"-b": func(info os.FileInfo) bool { return info.Mode()&(os.ModeDevice|os.ModeCharDevice) == os.ModeDevice },
+}
+
+// Проверки прав: режим для access, который учитывает владельца, группу и root так же, как ядро
+var fileAccessTests = map[string]uint32{"-r": 4, "-w": 2, "-x": 1}
+
+func isFileTest(op string) bool {
+	_, isMode := fileModeTests[op]
+	_, isAccess := fileAccessTests[op]
+	return isMode || isAccess
+}
+
+func testFile(op, path string) bool {
+	if mode, ok := fileAccessTests[op]; ok {
+		return syscall.Access(path, mode) == nil
+	}
+
+	// -L и -h проверяют саму ссылку, остальные - файл, на который она указывает
+	stat := os.Stat
+	if op == "-L" || op == "-h" {
+		stat = os.Lstat
+	}
+
+	info, err := stat(path)
+	return err == nil && fileModeTests[op](info)
+}
+
+// Операторы с двумя операндами
+var binaryTests = map[string]func(a, b string) (bool, error){
+	"=":   func(a, b string) (bool, error) { return a == b, nil },
+	"==":  func(a, b string) (bool, error) { return a == b, nil },
+	"!=":  func(a, b string) (bool, error) { return a != b, nil },
+	"<":   func(a, b string) (bool, error) { return a < b, nil },
+	">":   func(a, b string) (bool, error) { return a > b, nil },
+	"-eq": compareIntegers(func(a, b int64) bool { return a == b }),
+	"-ne": compareIntegers(func(a, b int64) bool { return a != b }),
+	"-lt": compareIntegers(func(a, b int64) bool { return a < b }),
+	"-le": compareIntegers(func(a, b int64) bool { return a <= b }),
+	"-gt": compareIntegers(func(a, b int64) bool { return a > b }),
+	"-ge": compareIntegers(func(a, b int64) bool { return a >= b }),
+}
+
+// Сравнения файлов. Ложны, если хотя бы одного из файлов нет
+var fileCompareTests = map[string]func(a, b os.FileInfo) bool{
+	"-nt": func(a, b os.FileInfo) bool { return a.ModTime().After(b.ModTime()) },
+	"-ot": func(a, b os.FileInfo) bool { return a.ModTime().Before(b.ModTime()) },
+	"-ef": os.SameFile,
+}
+
+func compareIntegers(compare func(a, b int64) bool) func(a, b string) (bool, error) {
+	return func(a, b string) (bool, error) {
+		x, err := parseTestInteger(a)
+		if err != nil {
+			return false, err
+		}
+		y, err := parseTestInteger(b)
+		if err != nil {
+			return false, err
+		}
+		return compare(x, y), nil
+	}
+}
+
+func parseTestInteger(text string) (int64, error) {
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: integer expression expected", text)
+	}
+	return n, nil
+}
+
+func compareFiles(op, a, b string) bool {
+	x, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	y, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return fileCompareTests[op](x, y)
+}
+
+// Проверяет выражение: test выражение или [ выражение ]. Код 0 - истина, 1 - ложь, 2 - ошибка в выражении.
+// Выражение: строка (истина, если не пустая), -z/-n строка, проверки файлов -e -f -d -r -w -x -s -L и др.,
+// сравнения строк = != < >, чисел -eq -ne -lt -le -gt -ge, файлов -nt -ot -ef, а также ! выражение,
+// выражение -a выражение, выражение -o выражение и скобки ( выражение )
+func executeTest(sh *shell, args []string, s streams) int {
+	name, args := args[0], args[1:]
+
+	if name == "[" {
+		if len(args) == 0 || args[len(args)-1] != "]" {
+			fmt.Fprintln(s.stderr, "[: missing `]'")
+			return 2
+		}
+		args = args[:len(args)-1]
+	}
+
+	result, err := evaluateTest(sh, args)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "%s: %v\n", name, err)
+		return 2
+	}
+
+	if result {
+		return 0
+	}
+	return 1
+}
+
+func evaluateTest(sh *shell, args []string) (bool, error) {
+	// Пустое выражение ложно
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	e := testExpression{sh: sh, args: args}
+
+	result, err := e.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if e.pos < len(args) {
+		return false, fmt.Errorf("%s: unexpected argument", args[e.pos])
+	}
+
+	return result, nil
+}
+
+// Разбор выражения test. Приоритет операторов: ! выше -a, -a выше -o.
+// Пути к файлам отсчитываются от текущего каталога шелла sh
+type testExpression struct {
+	sh   *shell
+	args []string
+	pos  int
+}
+
+func (e *testExpression) peek(offset int) (string, bool) {
+	if e.pos+offset >= len(e.args) {
+		return "", false
+	}
+	return e.args[e.pos+offset], true
+}
+
+// or := and ('-o' and)*
+func (e *testExpression) parseOr() (bool, error) {
+	result, err := e.parseAnd()
+	if err != nil {
+		return false, err
+	}
+
+	for arg, ok := e.peek(0); ok && arg == "-o"; arg, ok = e.peek(0) {
+		e.pos++
+		right, err := e.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		result = result || right
+	}
+
+	return result, nil
+}
+
+// and := not ('-a' not)*
+func (e *testExpression) parseAnd() (bool, error) {
+	result, err := e.parseNot()
+	if err != nil {
+		return false, err
+	}
+
+	for arg, ok := e.peek(0); ok && arg == "-a"; arg, ok = e.peek(0) {
+		e.pos++
+		right, err := e.parseNot()
+		if err != nil {
+			return false, err
+		}
+		result = result && right
+	}
+
+	return result, nil
+}
+
+// not := '!' not | primary. ! последним аргументом - обычная непустая строка
+func (e *testExpression) parseNot() (bool, error) {
+	if arg, _ := e.peek(0); arg == "!" {
+		if _, ok := e.peek(1); ok {
+			e.pos++
+			result, err := e.parseNot()
+			return !result, err
+		}
+	}
+
+	return e.parsePrimary()
+}
+
+// primary := '(' or ')' | строка оператор строка | -оператор строка | строка.
+// Бинарный оператор проверяется первым: в [ "$a" = -f ] -f - строка
+func (e *testExpression) parsePrimary() (bool, error) {
+	arg, ok := e.peek(0)
+	if !ok {
+		return false, errors.New("argument expected")
+	}
+
+	if op, ok := e.peek(1); ok {
+		right, hasRight := e.peek(2)
+		if compare, isBinary := binaryTests[op]; isBinary && hasRight {
+			e.pos += 3
+			return compare(arg, right)
+		}
+		if _, isFileCompare := fileCompareTests[op]; isFileCompare && hasRight {
+			e.pos += 3
+			return compareFiles(op, e.sh.path(arg), e.sh.path(right)), nil
+		}
+	}
+
+	operand, hasOperand := e.peek(1)
+
+	switch {
+	case arg == "(" && hasOperand:
+		e.pos++
+		result, err := e.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if closing, _ := e.peek(0); closing != ")" {
+			return false, errors.New("`)' expected")
+		}
+		e.pos++
+		return result, nil
+	case arg == "-z" && hasOperand:
+		e.pos += 2
+		return operand == "", nil
+	case arg == "-n" && hasOperand:
+		e.pos += 2
+		return operand != "", nil
+	case isFileTest(arg) && hasOperand:
+		e.pos += 2
+		return testFile(arg, e.sh.path(operand)), nil
+	}
+
+	// Одиночный аргумент истинен, если это непустая строка, включая -n, -f и т. п. без операнда
+	e.pos++
+	return arg != "", nil
+}
@@ -0,0 +1,59 @@
+# break и continue в циклах for и while, в том числе во вложенных и внутри if и case
+for i in 1 2 3 4 5; do
+	if [ "$i" = 4 ]; then
+		break
+	fi
+	echo "for $i"
+done
+echo "after break: $?"
+
+for i in 1 2 3 4; do
+	case $i in
+	2 | 3) continue ;;
+	esac
+	echo "odd or last $i"
+done
+
+s=
+while true; do
+	s="${s}x"
+	[ "$s" = xxx ] && break
+	echo "s=$s"
+done
+echo "while done s=$s"
+
+# break 2 и continue 2 покидают внешний цикл
+for i in a b c; do
+	for j in 1 2 3; do
+		[ "$j" = 2 ] && continue 2
+		[ "$i" = c ] && break 2
+		echo "$i$j"
+	done
+	echo "never after continue 2"
+done
+echo "outer done"
+
+# Счётчик больше вложенности завершает все циклы
+for i in 1 2; do
+	while true; do
+		echo "inner $i"
+		break 5
+	done
+	echo "never after break 5"
+done
+
+# break в конвейере выполняется в подоболочке и не завершает цикл
+for i in 1 2; do
+	echo "piped $i"
+	echo | break
+done
+
+# Команды после break в той же строке не выполняются
+for i in 1 2; do break; echo never; done
+echo "same line done"
+
+# Ошибки
+break
+echo "outside loop: $?"
+for i in 1; do break 0; echo "zero: $?"; done
+for i in 1; do continue x; echo "not a number: $?"; done
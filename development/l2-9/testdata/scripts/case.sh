@@ -0,0 +1,46 @@
+# case с шаблонами *, ?, наборами, альтернативами и экранированием
+for name in main.go notes.txt a b z README x.tar.gz '*' ''; do
+	case $name in
+		*.go) echo "$name: go source" ;;
+		*.tar.gz | *.zip) echo "$name: archive" ;;
+		"*") echo "$name: literal star" ;;
+		?) echo "$name: one char" ;;
+		[A-Z]*) echo "$name: capitalized" ;;
+		'') echo "empty" ;;
+		*) echo "$name: other"
+	esac
+done
+
+# Наборы и отрицание
+for c in a m x 7 -; do
+	case "$c" in
+		[a-f]) echo "$c: a-f";;
+		[!a-z0-9]) echo "$c: not alnum";;
+		[0-9]) echo "$c: digit";;
+		*) echo "$c: letter after f";;
+	esac
+done
+
+# Шаблон из переменной без кавычек - шаблон, в кавычках - строка
+pattern='a*'
+case abc in $pattern) echo "unquoted variable: match";; esac
+case abc in "$pattern") echo "quoted variable: match";; *) echo "quoted variable: no match";; esac
+case 'a*' in "$pattern") echo "quoted variable: literal match";; esac
+case 'a?c' in a\?c) echo "escaped: match";; esac
+case abc in a\?c) echo "escaped: no";; (a*) echo "parenthesized pattern: match";; esac
+
+# Код case - код выполненной ветки, без подходящей ветки - 0
+case x in x) false;; esac
+echo "branch status: $?"
+case x in y) true;; esac
+echo "no match status: $?"
+case x in x) ;; esac
+echo "empty branch status: $?"
+
+# Ветки с несколькими командами на разных строках
+case "$HOME" in
+	/*)
+		echo "absolute home"
+		echo "second command"
+		;;
+esac
@@ -0,0 +1,18 @@
+# set -e: ошибка в условиях if, while и в цепочках && и || шелл не завершает
+set -e
+
+if false; then echo never; else echo "if condition: continued"; fi
+while false; do echo never; done
+echo "while condition: continued"
+false || echo "or chain: continued"
+false && echo never
+echo "and chain: continued"
+
+for i in 1 2; do
+	[ "$i" = 2 ] || echo "loop body check: $i"
+done
+
+case x in
+	x) echo "last command fails"; false ;;
+esac
+echo "not reached"
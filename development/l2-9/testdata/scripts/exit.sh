@@ -0,0 +1,8 @@
+# exit в цикле завершает весь скрипт с указанным кодом
+for i in 1 2 3; do
+	echo "iteration $i"
+	if [ "$i" = 2 ]; then
+		exit 3
+	fi
+done
+echo "not reached"
@@ -0,0 +1,40 @@
+# for по словам, результатам подстановок и позиционным параметрам
+dir=$1
+
+for word in a "b c" 'd'; do
+	echo "word: $word"
+done
+
+list="x y  z"
+for item in $list; do echo "split: $item"; done
+for item in "$list"; do echo "quoted: $item"; done
+
+set -- first "second arg"
+for arg in "$@"; do echo "arg: $arg"; done
+for arg
+do
+	echo "implicit: $arg"
+done
+
+# Пустой список: тело не выполняется, код 0, переменная не меняется
+last=kept
+for last in; do echo never; done
+echo "empty: $? $last"
+for last in $UNSET_VARIABLE; do echo never; done
+
+# Переменная цикла сохраняет последнее значение
+for last in 1 2 3; do true; done
+echo "last: $last"
+
+# Вложенные циклы, конвейер и перенаправление
+for i in 1 2; do
+	for j in a b; do
+		echo "$i$j"
+	done
+done | sort -r
+for f in one two; do echo "$f"; done > "$dir/for.out"
+cat "$dir/for.out"
+
+# Код цикла - код последнего выполнения тела
+for i in 1 2; do [ "$i" = 1 ]; done
+echo "status: $?"
@@ -0,0 +1,39 @@
+# if/elif/else по кодам завершения встроенных и внешних команд
+if true; then
+	echo "true: then"
+fi
+
+if false; then
+	echo "false: then"
+else
+	echo "false: else"
+fi
+
+for value in one two three; do
+	if [ "$value" = one ]; then
+		echo "$value: first"
+	elif test "$value" = two
+	then
+		echo "$value: second"
+	else
+		echo "$value: other"
+	fi
+done
+
+# Условие - список команд, проверяется код последней
+if false; true; then echo "list: last status"; fi
+if echo hello | grep -q xyz; then echo "pipeline: then"; else echo "pipeline: else"; fi
+if printf 'a\nb\n' | grep -q b; then echo "grep: found"; fi
+if no-such-command 2>/dev/null; then echo "missing: then"; else echo "missing: $?"; fi
+
+# Код if - код выполненной ветки, без ветки - 0
+if true; then false; fi
+echo "branch status: $?"
+if false; then true; fi
+echo "no branch status: $?"
+
+# Вложенные if и перенаправление вывода всей конструкции
+if true; then
+	if [ -n "$HOME" ]; then echo nested; fi
+fi > "$1/if.out"
+cat "$1/if.out"
@@ -0,0 +1,61 @@
+# Встроенные test и [ ]: строки, числа, файлы и логические операторы.
+# Каждая строка выводит выражение и код завершения: 0 - истина, 1 - ложь, 2 - ошибка
+cd "$1"
+touch empty
+echo content > full
+mkdir dir
+ln -s full link
+chmod 644 full
+chmod 755 dir
+
+[ -n text ]; echo "-n text: $?"
+[ -z text ]; echo "-z text: $?"
+[ -z "" ]; echo "-z empty: $?"
+[ text ]; echo "string: $?"
+[ "" ]; echo "empty string: $?"
+[ ]; echo "no arguments: $?"
+[ abc = abc ]; echo "abc = abc: $?"
+[ abc == abd ]; echo "abc == abd: $?"
+[ abc != abd ]; echo "abc != abd: $?"
+[ a '<' b ]; echo "a < b: $?"
+[ b '>' a ]; echo "b > a: $?"
+[ 10 -eq 10 ]; echo "10 -eq 10: $?"
+[ -3 -lt 2 ]; echo "-3 -lt 2: $?"
+[ 2 -ge 3 ]; echo "2 -ge 3: $?"
+[ 5 -ne 5 ]; echo "5 -ne 5: $?"
+[ 7 -gt 6 ]; echo "7 -gt 6: $?"
+[ 7 -le 7 ]; echo "7 -le 7: $?"
+[ -e full ]; echo "-e full: $?"
+[ -e missing ]; echo "-e missing: $?"
+[ -f full ]; echo "-f full: $?"
+[ -f dir ]; echo "-f dir: $?"
+[ -d dir ]; echo "-d dir: $?"
+[ -s full ]; echo "-s full: $?"
+[ -s empty ]; echo "-s empty: $?"
+[ -L link ]; echo "-L link: $?"
+[ -L full ]; echo "-L full: $?"
+[ -f link ]; echo "-f link: $?"
+[ -r full ]; echo "-r full: $?"
+[ -x dir ]; echo "-x dir: $?"
+[ full -ef link ]; echo "full -ef link: $?"
+[ full -ef empty ]; echo "full -ef empty: $?"
+[ ! -e missing ]; echo "! -e missing: $?"
+[ ! -n text ]; echo "! -n text: $?"
+[ -e full -a -d dir ]; echo "-e full -a -d dir: $?"
+[ -e missing -a -d dir ]; echo "-e missing -a -d dir: $?"
+[ -e missing -o -d dir ]; echo "-e missing -o -d dir: $?"
+[ \( -n a -o -n "" \) -a -z "" ]; echo "parentheses: $?"
+[ ! \( a = a \) ]; echo "negated parentheses: $?"
+
+# Оператор без операнда - обычная непустая строка, бинарный оператор проверяется первым
+[ -n ]; echo "-n alone: $?"
+[ ! ]; echo "! alone: $?"
+[ -f = -f ]; echo "-f = -f: $?"
+test -d dir; echo "test -d dir: $?"
+test; echo "test without arguments: $?"
+
+# Ошибки в выражении: код 2 и сообщение
+[ abc -eq 1 ]; echo "not a number: $?"
+[ a = a; echo "missing bracket: $?"
+[ a b ]; echo "extra argument: $?"
+[ \( a ]; echo "unclosed parenthesis: $?"
@@ -0,0 +1,33 @@
+# Цикл while по условию из test и строкам без арифметики
+s=
+while [ "$s" != xxx ]; do
+	s="${s}x"
+	echo "s=$s"
+done
+echo "status after loop: $?"
+
+# Условие, которое сразу ложно: тело не выполняется, код 0
+while false; do echo never; done
+echo "never: $?"
+
+# Файл как флаг: цикл создаёт его и завершается
+cd "$1"
+while [ ! -f flag ]; do
+	echo "creating flag"
+	touch flag
+done
+
+# Вывод цикла в конвейер и в файл
+n=
+while test "$n" != 111; do n="${n}1"; echo "$n"; done | sort -r
+n=
+while [ "$n" != ab ]; do n="${n:-a}"; [ "$n" = a ] && n=ab; echo "$n"; done > while.out
+cat while.out
+
+# Переменные, изменённые в цикле вне конвейера, видны после него, в конвейере - нет
+v=before
+while [ "$v" = before ]; do v=after; done
+echo "v=$v"
+w=before
+while [ "$w" = before ]; do w=after; done | cat
+echo "w=$w"